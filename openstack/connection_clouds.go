@@ -0,0 +1,327 @@
+package openstack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ConnectionConfig is the cache key for the connection configuration,
+	// once merged with the contents of clouds.yaml and secure.yaml.
+	ConnectionConfig = "openstack_connection_config"
+)
+
+var (
+	// ErrCloudNotFound is returned when the requested cloud is not defined in
+	// clouds.yaml (nor in clouds-public.yaml, when referenced as a profile).
+	ErrCloudNotFound = errors.New("cloud not found")
+)
+
+// cloudsConfigDirs returns the directories where clouds.yaml, secure.yaml and
+// clouds-public.yaml are looked for, in the same order as openstacksdk.
+func cloudsConfigDirs() []string {
+	dirs := []string{"."}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".config", "openstack"))
+	}
+	return append(dirs, "/etc/openstack")
+}
+
+// findCloudsFile returns the first existing file with the given name among
+// the standard locations; if the given environment variable is set, its value
+// takes precedence over the standard locations.
+func findCloudsFile(name string, env string) string {
+	if path := os.Getenv(env); path != "" {
+		return path
+	}
+	for _, dir := range cloudsConfigDirs() {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// expandHome replaces a leading "~/" in path with the user's home directory.
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
+
+// cloudConfig is the subset of a clouds.yaml cloud entry that the plugin
+// understands; see https://docs.openstack.org/openstacksdk/latest/user/config/configuration.html
+type cloudConfig struct {
+	Profile  string `yaml:"profile"`
+	Cloud    string `yaml:"cloud"`
+	AuthType string `yaml:"auth_type"`
+	Auth     struct {
+		AuthURL                     string `yaml:"auth_url"`
+		Token                       string `yaml:"token"`
+		Username                    string `yaml:"username"`
+		UserID                      string `yaml:"user_id"`
+		Password                    string `yaml:"password"`
+		ProjectName                 string `yaml:"project_name"`
+		ProjectID                   string `yaml:"project_id"`
+		TenantName                  string `yaml:"tenant_name"`
+		TenantID                    string `yaml:"tenant_id"`
+		UserDomainName              string `yaml:"user_domain_name"`
+		UserDomainID                string `yaml:"user_domain_id"`
+		ProjectDomainName           string `yaml:"project_domain_name"`
+		ProjectDomainID             string `yaml:"project_domain_id"`
+		DomainName                  string `yaml:"domain_name"`
+		DomainID                    string `yaml:"domain_id"`
+		ApplicationCredentialID     string `yaml:"application_credential_id"`
+		ApplicationCredentialSecret string `yaml:"application_credential_secret"`
+	} `yaml:"auth"`
+	RegionName         string        `yaml:"region_name"`
+	Regions            []cloudRegion `yaml:"regions"`
+	Interface          string        `yaml:"interface"`
+	EndpointType       string        `yaml:"endpoint_type"`
+	CACert             string        `yaml:"cacert"`
+	Cert               string        `yaml:"cert"`
+	Key                string        `yaml:"key"`
+	Verify             *bool         `yaml:"verify"`
+	IdentityAPIVersion string        `yaml:"identity_api_version"`
+	ComputeAPIVersion  string        `yaml:"compute_api_version"`
+	VolumeAPIVersion   string        `yaml:"volume_api_version"`
+	ImageAPIVersion    string        `yaml:"image_api_version"`
+}
+
+// cloudRegion is an entry in the regions list, which can be either a plain
+// region name or an object with a name and some per-region overrides.
+type cloudRegion struct {
+	Name string `yaml:"name"`
+}
+
+func (r *cloudRegion) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		r.Name = node.Value
+		return nil
+	}
+	type plain cloudRegion
+	return node.Decode((*plain)(r))
+}
+
+// loadCloudConfig reads the named cloud from the given clouds.yaml (or from
+// the first one found in the standard locations if empty), then merges in the
+// profile from clouds-public.yaml it may refer to (underneath) and the entry
+// with the same name in secure.yaml (on top), as openstacksdk does.
+func loadCloudConfig(name string, cloudsFile string) (*cloudConfig, error) {
+	if cloudsFile == "" {
+		cloudsFile = findCloudsFile("clouds.yaml", "OS_CLIENT_CONFIG_FILE")
+	}
+	if cloudsFile == "" {
+		return nil, fmt.Errorf("no clouds.yaml file found looking for cloud %q", name)
+	}
+
+	cloud, err := readCloudNode(cloudsFile, "clouds", name)
+	if err != nil {
+		return nil, err
+	}
+	if cloud == nil {
+		return nil, fmt.Errorf("%w: %q in %s", ErrCloudNotFound, name, cloudsFile)
+	}
+
+	// the cloud may be based on a vendor profile in clouds-public.yaml
+	profile := ""
+	for _, key := range []string{"profile", "cloud"} {
+		if value := mappingValue(cloud, key); value != nil && value.Kind == yaml.ScalarNode {
+			profile = value.Value
+			break
+		}
+	}
+	if profile != "" {
+		if publicFile := findCloudsFile("clouds-public.yaml", "OS_CLIENT_PUBLIC_FILE"); publicFile != "" {
+			public, err := readCloudNode(publicFile, "public-clouds", profile)
+			if err != nil {
+				return nil, err
+			}
+			if public != nil {
+				cloud = mergeYAMLNodes(public, cloud)
+			}
+		}
+	}
+
+	// secrets are usually kept apart, in secure.yaml
+	if secureFile := findCloudsFile("secure.yaml", "OS_CLIENT_SECURE_FILE"); secureFile != "" {
+		secure, err := readCloudNode(secureFile, "clouds", name)
+		if err != nil {
+			return nil, err
+		}
+		if secure != nil {
+			cloud = mergeYAMLNodes(cloud, secure)
+		}
+	}
+
+	config := &cloudConfig{}
+	if err := cloud.Decode(config); err != nil {
+		return nil, fmt.Errorf("invalid configuration for cloud %q: %w", name, err)
+	}
+	return config, nil
+}
+
+// readCloudNode parses the given YAML file and returns the node describing
+// the named cloud under the given top-level section, or nil if there is none.
+func readCloudNode(path string, section string, name string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	document := &yaml.Node{}
+	if err := yaml.Unmarshal(data, document); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	if len(document.Content) == 0 {
+		return nil, nil
+	}
+	clouds := mappingValue(document.Content[0], section)
+	if clouds == nil {
+		return nil, nil
+	}
+	return mappingValue(clouds, name), nil
+}
+
+// mappingValue returns the value associated with key in a YAML mapping node.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// mergeYAMLNodes deep-merges overlay onto base: mappings are merged key by
+// key, any other value in overlay replaces the one in base; values are merged
+// as YAML nodes so that scalars such as "2.10" retain their original text.
+func mergeYAMLNodes(base *yaml.Node, overlay *yaml.Node) *yaml.Node {
+	if base == nil || base.Kind != yaml.MappingNode || overlay.Kind != yaml.MappingNode {
+		return overlay
+	}
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: base.Tag}
+	merged.Content = append(merged.Content, base.Content...)
+	for i := 0; i+1 < len(overlay.Content); i += 2 {
+		key, value := overlay.Content[i], overlay.Content[i+1]
+		found := false
+		for j := 0; j+1 < len(merged.Content); j += 2 {
+			if merged.Content[j].Value == key.Value {
+				merged.Content[j+1] = mergeYAMLNodes(merged.Content[j+1], value)
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged.Content = append(merged.Content, key, value)
+		}
+	}
+	return merged
+}
+
+// applyCloudConfig fills the values that were not explicitly set in the
+// connection configuration with those from the cloud; explicit connection
+// parameters always win, as with openstacksdk's explicit arguments.
+func applyCloudConfig(config *openstackConfig, cloud *cloudConfig) {
+	set := func(target **string, values ...string) {
+		if *target != nil {
+			return
+		}
+		for _, value := range values {
+			if value != "" {
+				*target = utils.PointerTo(value)
+				return
+			}
+		}
+	}
+
+	set(&config.EndpointUrl, cloud.Auth.AuthURL)
+	set(&config.UserID, cloud.Auth.UserID)
+	set(&config.Username, cloud.Auth.Username)
+	set(&config.Password, cloud.Auth.Password)
+	set(&config.ProjectID, cloud.Auth.ProjectID, cloud.Auth.TenantID)
+	set(&config.ProjectName, cloud.Auth.ProjectName, cloud.Auth.TenantName)
+	set(&config.DomainID, cloud.Auth.DomainID, cloud.Auth.UserDomainID, cloud.Auth.ProjectDomainID)
+	set(&config.DomainName, cloud.Auth.DomainName, cloud.Auth.UserDomainName, cloud.Auth.ProjectDomainName)
+	set(&config.AccessToken, cloud.Auth.Token)
+	set(&config.AppCredentialID, cloud.Auth.ApplicationCredentialID)
+	set(&config.AppCredentialSecret, cloud.Auth.ApplicationCredentialSecret)
+	set(&config.Region, cloud.RegionName)
+	if len(config.Regions) == 0 && config.Region == nil {
+		for _, region := range cloud.Regions {
+			config.Regions = append(config.Regions, region.Name)
+		}
+	}
+	set(&config.Interface, strings.TrimSuffix(cloud.Interface, "URL"), strings.TrimSuffix(cloud.EndpointType, "URL"))
+	set(&config.CACert, cloud.CACert)
+	set(&config.Cert, cloud.Cert)
+	set(&config.Key, cloud.Key)
+	if config.Insecure == nil && cloud.Verify != nil {
+		config.Insecure = utils.PointerTo(!*cloud.Verify)
+	}
+
+	// API versions with a minor component are microversions
+	microversion := func(version string, major string) string {
+		if strings.HasPrefix(version, major+".") {
+			return version
+		}
+		return ""
+	}
+	set(&config.IdentityV3Microversion, microversion(cloud.IdentityAPIVersion, "3"))
+	set(&config.ComputeV2Microversion, microversion(cloud.ComputeAPIVersion, "2"))
+	set(&config.BlockStorageV3Microversion, microversion(cloud.VolumeAPIVersion, "3"))
+	set(&config.ImageServiceV2Microversion, microversion(cloud.ImageAPIVersion, "2"))
+}
+
+// getCloudName returns the name of the cloud to load from clouds.yaml, if any;
+// the "cloud" parameter takes precedence over the OS_CLOUD environment variable.
+func getCloudName(config *openstackConfig) string {
+	if config.Cloud != nil {
+		return *config.Cloud
+	}
+	return os.Getenv("OS_CLOUD")
+}
+
+// getConnectionConfig returns the connection configuration; if a cloud is
+// selected, either through the "cloud" parameter or the OS_CLOUD environment
+// variable, the values from clouds.yaml and secure.yaml are merged in.
+func getConnectionConfig(ctx context.Context, d *plugin.QueryData) (*openstackConfig, error) {
+
+	// load configuration from cache
+	if cachedData, ok := d.ConnectionManager.Cache.Get(ConnectionConfig); ok {
+		return cachedData.(*openstackConfig), nil
+	}
+
+	openstackConfig := GetConfig(d.Connection)
+
+	if name := getCloudName(&openstackConfig); name != "" {
+		cloudsFile := ""
+		if openstackConfig.CloudsFile != nil {
+			cloudsFile = expandHome(*openstackConfig.CloudsFile)
+		}
+		plugin.Logger(ctx).Info("loading cloud configuration", "cloud", name, "file", cloudsFile)
+		cloud, err := loadCloudConfig(name, cloudsFile)
+		if err != nil {
+			plugin.Logger(ctx).Error("error loading cloud configuration", "cloud", name, "error", err)
+			return nil, err
+		}
+		applyCloudConfig(&openstackConfig, cloud)
+	}
+
+	d.ConnectionManager.Cache.Set(ConnectionConfig, &openstackConfig)
+	return &openstackConfig, nil
+}
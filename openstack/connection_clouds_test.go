@@ -0,0 +1,146 @@
+package openstack
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+)
+
+func setCloudsTestEnv(t *testing.T) string {
+	dir := filepath.Join("testdata", "clouds")
+	t.Setenv("OS_CLIENT_CONFIG_FILE", filepath.Join(dir, "clouds.yaml"))
+	t.Setenv("OS_CLIENT_SECURE_FILE", filepath.Join(dir, "secure.yaml"))
+	t.Setenv("OS_CLIENT_PUBLIC_FILE", filepath.Join(dir, "clouds-public.yaml"))
+	return dir
+}
+
+func TestLoadCloudConfig(t *testing.T) {
+	setCloudsTestEnv(t)
+
+	cloud, err := loadCloudConfig("devstack", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cloud.Auth.Username != "demo" {
+		t.Errorf("expected username %q, got %q", "demo", cloud.Auth.Username)
+	}
+	// the password comes from secure.yaml
+	if cloud.Auth.Password != "s3cr3t" {
+		t.Errorf("expected password from secure.yaml, got %q", cloud.Auth.Password)
+	}
+	// unquoted versions must retain their original text
+	if cloud.VolumeAPIVersion != "3.60" {
+		t.Errorf("expected volume API version %q, got %q", "3.60", cloud.VolumeAPIVersion)
+	}
+
+	// profiles from clouds-public.yaml are overridden by clouds.yaml
+	cloud, err = loadCloudConfig("vendor", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cloud.Auth.AuthURL != "https://identity.acme.example.com/v3" {
+		t.Errorf("expected auth URL from profile, got %q", cloud.Auth.AuthURL)
+	}
+	if cloud.Auth.Username != "customer" {
+		t.Errorf("expected username from clouds.yaml, got %q", cloud.Auth.Username)
+	}
+	if cloud.RegionName != "acme-east-1" || cloud.ImageAPIVersion != "2.10" {
+		t.Errorf("unexpected values from profile: region %q, image API version %q", cloud.RegionName, cloud.ImageAPIVersion)
+	}
+
+	if _, err = loadCloudConfig("missing", ""); !errors.Is(err, ErrCloudNotFound) {
+		t.Errorf("expected ErrCloudNotFound, got %v", err)
+	}
+}
+
+func TestLoadCloudConfigExplicitFile(t *testing.T) {
+	dir := setCloudsTestEnv(t)
+	// the explicit clouds_file takes precedence over OS_CLIENT_CONFIG_FILE
+	t.Setenv("OS_CLIENT_CONFIG_FILE", filepath.Join(dir, "does-not-exist.yaml"))
+
+	cloud, err := loadCloudConfig("multiregion", filepath.Join(dir, "clouds.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cloud.Auth.ApplicationCredentialSecret != "t0ps3cr3t" {
+		t.Errorf("expected application credential secret from secure.yaml, got %q", cloud.Auth.ApplicationCredentialSecret)
+	}
+}
+
+func TestApplyCloudConfig(t *testing.T) {
+	setCloudsTestEnv(t)
+
+	cloud, err := loadCloudConfig("devstack", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// explicit connection parameters win over clouds.yaml
+	config := openstackConfig{
+		Username: utils.PointerTo("admin"),
+	}
+	applyCloudConfig(&config, cloud)
+
+	expected := map[string]*string{
+		"endpoint_url":                 utils.PointerTo("https://keystone.example.com:5000/v3"),
+		"username":                     utils.PointerTo("admin"),
+		"password":                     utils.PointerTo("s3cr3t"),
+		"project_name":                 utils.PointerTo("demo"),
+		"domain_name":                  utils.PointerTo("Default"),
+		"region":                       utils.PointerTo("RegionOne"),
+		"interface":                    utils.PointerTo("internal"),
+		"cacert":                       utils.PointerTo("/etc/ssl/certs/internal-ca.pem"),
+		"compute_v2_microversion":      utils.PointerTo("2.79"),
+		"blockstorage_v3_microversion": utils.PointerTo("3.60"),
+		"identity_v3_microversion":     nil,
+	}
+	actual := map[string]*string{
+		"endpoint_url":                 config.EndpointUrl,
+		"username":                     config.Username,
+		"password":                     config.Password,
+		"project_name":                 config.ProjectName,
+		"domain_name":                  config.DomainName,
+		"region":                       config.Region,
+		"interface":                    config.Interface,
+		"cacert":                       config.CACert,
+		"compute_v2_microversion":      config.ComputeV2Microversion,
+		"blockstorage_v3_microversion": config.BlockStorageV3Microversion,
+		"identity_v3_microversion":     config.IdentityV3Microversion,
+	}
+	for key, value := range expected {
+		if !reflect.DeepEqual(value, actual[key]) {
+			t.Errorf("%s: expected %v, got %v", key, utils.ToJSON(value), utils.ToJSON(actual[key]))
+		}
+	}
+}
+
+func TestApplyCloudConfigRegions(t *testing.T) {
+	setCloudsTestEnv(t)
+
+	cloud, err := loadCloudConfig("multiregion", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := openstackConfig{}
+	applyCloudConfig(&config, cloud)
+	if !reflect.DeepEqual(config.Regions, []string{"RegionOne", "RegionTwo"}) {
+		t.Errorf("unexpected regions: %v", config.Regions)
+	}
+	if config.Interface == nil || *config.Interface != "public" {
+		t.Errorf("expected interface %q from endpoint_type, got %v", "public", config.Interface)
+	}
+	if config.Insecure == nil || !*config.Insecure {
+		t.Errorf("expected insecure mode with verify: false")
+	}
+
+	// an explicit region in the connection disables the regions from clouds.yaml
+	config = openstackConfig{Region: utils.PointerTo("RegionThree")}
+	applyCloudConfig(&config, cloud)
+	if len(config.Regions) != 0 {
+		t.Errorf("unexpected regions: %v", config.Regions)
+	}
+}
@@ -6,25 +6,41 @@ import (
 )
 
 type openstackConfig struct {
-	EndpointUrl                *string `cty:"endpoint_url"`
-	UserID                     *string `cty:"userid"`
-	Username                   *string `cty:"username"`
-	Password                   *string `cty:"password"`
-	Region                     *string `cty:"region"`
-	ProjectID                  *string `cty:"project_id"`
-	ProjectName                *string `cty:"project_name"`
-	DomainID                   *string `cty:"domain_id"`
-	DomainName                 *string `cty:"domain_name"`
-	AccessToken                *string `cty:"access_token"`
-	AppCredentialID            *string `cty:"app_credential_id"`
-	AppCredentialSecret        *string `cty:"app_credential_secret"`
-	AllowReauth                *bool   `cty:"allow_reauth"`
-	TraceLevel                 *string `cty:"trace_level"`
-	IdentityV3Microversion     *string `cty:"identity_v3_microversion"`
-	ComputeV2Microversion      *string `cty:"compute_v2_microversion"`
-	NetworkV2Microversion      *string `cty:"network_v2_microversion"`
-	BlockStorageV3Microversion *string `cty:"blockstorage_v3_microversion"`
-	ImageServiceV2Microversion *string `cty:"imageservice_v2_microversion"`
+	EndpointUrl                *string  `cty:"endpoint_url"`
+	UserID                     *string  `cty:"userid"`
+	Username                   *string  `cty:"username"`
+	Password                   *string  `cty:"password"`
+	Region                     *string  `cty:"region"`
+	Regions                    []string `cty:"regions"`
+	ProjectID                  *string  `cty:"project_id"`
+	ProjectName                *string  `cty:"project_name"`
+	DomainID                   *string  `cty:"domain_id"`
+	DomainName                 *string  `cty:"domain_name"`
+	AccessToken                *string  `cty:"access_token"`
+	AppCredentialID            *string  `cty:"app_credential_id"`
+	AppCredentialSecret        *string  `cty:"app_credential_secret"`
+	AllowReauth                *bool    `cty:"allow_reauth"`
+	TraceLevel                 *string  `cty:"trace_level"`
+	IdentityV3Microversion     *string  `cty:"identity_v3_microversion"`
+	ComputeV2Microversion      *string  `cty:"compute_v2_microversion"`
+	NetworkV2Microversion      *string  `cty:"network_v2_microversion"`
+	BlockStorageV3Microversion *string  `cty:"blockstorage_v3_microversion"`
+	ImageServiceV2Microversion *string  `cty:"imageservice_v2_microversion"`
+	Cloud                      *string  `cty:"cloud"`
+	CloudsFile                 *string  `cty:"clouds_file"`
+	CACert                     *string  `cty:"cacert"`
+	Cert                       *string  `cty:"cert"`
+	Key                        *string  `cty:"key"`
+	Insecure                   *bool    `cty:"insecure"`
+	Interface                  *string  `cty:"interface"`
+	EndpointOverrides          []string `cty:"endpoint_overrides"`
+	MaxErrorRetryAttempts      *int     `cty:"max_error_retry_attempts"`
+	MinErrorRetryDelay         *int     `cty:"min_error_retry_delay"`
+	MaxConcurrency             *int     `cty:"max_concurrency"`
+	RecordDir                  *string  `cty:"record_dir"`
+	ReplayDir                  *string  `cty:"replay_dir"`
+	Scope                      *string  `cty:"scope"`
+	NameCacheTTL               *int     `cty:"name_cache_ttl"`
 	// TODO: check
 	// AppCredentialName          *string `cty:"app_credential_name"`
 }
@@ -45,6 +61,10 @@ var ConfigSchema = map[string]*schema.Attribute{
 	"region": {
 		Type: schema.TypeString,
 	},
+	"regions": {
+		Type: schema.TypeList,
+		Elem: &schema.Attribute{Type: schema.TypeString},
+	},
 	"project_id": {
 		Type: schema.TypeString,
 	},
@@ -90,6 +110,52 @@ var ConfigSchema = map[string]*schema.Attribute{
 	"imageservice_v2_microversion": {
 		Type: schema.TypeString,
 	},
+	"cloud": {
+		Type: schema.TypeString,
+	},
+	"clouds_file": {
+		Type: schema.TypeString,
+	},
+	"cacert": {
+		Type: schema.TypeString,
+	},
+	"cert": {
+		Type: schema.TypeString,
+	},
+	"key": {
+		Type: schema.TypeString,
+	},
+	"insecure": {
+		Type: schema.TypeBool,
+	},
+	"interface": {
+		Type: schema.TypeString,
+	},
+	"endpoint_overrides": {
+		Type: schema.TypeList,
+		Elem: &schema.Attribute{Type: schema.TypeString},
+	},
+	"max_error_retry_attempts": {
+		Type: schema.TypeInt,
+	},
+	"min_error_retry_delay": {
+		Type: schema.TypeInt,
+	},
+	"max_concurrency": {
+		Type: schema.TypeInt,
+	},
+	"record_dir": {
+		Type: schema.TypeString,
+	},
+	"replay_dir": {
+		Type: schema.TypeString,
+	},
+	"scope": {
+		Type: schema.TypeString,
+	},
+	"name_cache_ttl": {
+		Type: schema.TypeInt,
+	},
 }
 
 func ConfigInstance() interface{} {
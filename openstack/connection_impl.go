@@ -2,11 +2,15 @@ package openstack
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/dihedron/steampipe-plugin-utils/utils"
 	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/tokens"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 )
 
@@ -16,13 +20,28 @@ const (
 	DefaultIdentityV3Microversion     = "3.13"
 	DefaultBlockStorageV3Microversion = "3.59"
 	DefaultImageServiceV2Microversion = "2.9"
+
+	// highest microversions the plugin knows how to handle; in particular,
+	// compute 2.88 drops most of the fields in the hypervisor table
+	MaxComputeV2Microversion      = "2.87"
+	MaxIdentityV3Microversion     = "3.14"
+	MaxBlockStorageV3Microversion = "3.66"
+	MaxImageServiceV2Microversion = "2.16"
 )
 
+// ErrCloudSelected signals that the auth info in the environment was skipped
+// because a cloud was selected from clouds.yaml.
+var ErrCloudSelected = errors.New("cloud selected from clouds.yaml")
+
 type ServiceType string
 
 const (
 	// AuthenticatedClient is the cache key for the openStack authenticated client.
 	AuthenticatedClient = "openstack_authenticated_client"
+	// Regions is the cache key for the list of regions to query.
+	Regions = "openstack_regions"
+	// RegionKey is the name of the matrix item (and column) holding the region.
+	RegionKey = "region"
 
 	// IdentityV3 identifies the OpenStack Identity V3 service (Keystone).
 	IdentityV3 ServiceType = "openstack_identity_v3"
@@ -39,72 +58,97 @@ const (
 )
 
 type serviceConfig struct {
+	// catalogType is the type of the service in the Keystone catalog, which is
+	// also the key for the service in the endpoint_overrides parameter.
+	catalogType string
+	// apiVersion is the major version of the API used by the plugin.
+	apiVersion string
+	// defaultMicroversion is the microversion used when none is configured, or
+	// when "auto" is configured and negotiation with the server fails.
+	defaultMicroversion string
+	// maxMicroversion is the highest microversion the plugin's structs (apiInstance,
+	// apiVolume etc.) understand; it is empty for services without microversions.
+	maxMicroversion string
 	newClient       func(client *gophercloud.ProviderClient, eo gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error)
-	getMicroversion func(config *openstackConfig) string
+	getMicroversion func(config *openstackConfig) *string
 }
 
 var serviceConfigMap = map[ServiceType]serviceConfig{
 	IdentityV3: {
-		newClient: openstack.NewIdentityV3,
-		getMicroversion: func(config *openstackConfig) string {
-			microversion := DefaultIdentityV3Microversion
-			if config.IdentityV3Microversion != nil {
-				microversion = *config.IdentityV3Microversion
-			}
-			return microversion
+		catalogType:         "identity",
+		apiVersion:          "3",
+		defaultMicroversion: DefaultIdentityV3Microversion,
+		maxMicroversion:     MaxIdentityV3Microversion,
+		newClient:           openstack.NewIdentityV3,
+		getMicroversion: func(config *openstackConfig) *string {
+			return config.IdentityV3Microversion
 		},
 	},
 	ComputeV2: {
-		newClient: openstack.NewComputeV2,
-		getMicroversion: func(config *openstackConfig) string {
-			microversion := DefaultComputeV2Microversion
-			if config.ComputeV2Microversion != nil {
-				microversion = *config.ComputeV2Microversion
-			}
-			return microversion
+		catalogType:         "compute",
+		apiVersion:          "2",
+		defaultMicroversion: DefaultComputeV2Microversion,
+		maxMicroversion:     MaxComputeV2Microversion,
+		newClient:           openstack.NewComputeV2,
+		getMicroversion: func(config *openstackConfig) *string {
+			return config.ComputeV2Microversion
 		},
 	},
 	NetworkV2: {
-		newClient: openstack.NewNetworkV2,
-		getMicroversion: func(config *openstackConfig) string {
+		catalogType: "network",
+		apiVersion:  "2",
+		newClient:   openstack.NewNetworkV2,
+		getMicroversion: func(config *openstackConfig) *string {
 			// TODO: check if we need to leverage/support micro-versions
-			return ""
+			return nil
 		},
 	},
 	LbaasV2: {
-		newClient: openstack.NewLoadBalancerV2,
-		getMicroversion: func(config *openstackConfig) string {
-			return ""
+		catalogType: "load-balancer",
+		apiVersion:  "2",
+		newClient:   openstack.NewLoadBalancerV2,
+		getMicroversion: func(config *openstackConfig) *string {
+			return nil
 		},
 	},
 	BlockStorageV3: {
-		newClient: openstack.NewBlockStorageV3,
-		getMicroversion: func(config *openstackConfig) string {
-			microversion := DefaultBlockStorageV3Microversion
-			if config.BlockStorageV3Microversion != nil {
-				microversion = *config.BlockStorageV3Microversion
-			}
-			return microversion
+		catalogType:         "volumev3",
+		apiVersion:          "3",
+		defaultMicroversion: DefaultBlockStorageV3Microversion,
+		maxMicroversion:     MaxBlockStorageV3Microversion,
+		newClient:           openstack.NewBlockStorageV3,
+		getMicroversion: func(config *openstackConfig) *string {
+			return config.BlockStorageV3Microversion
 		},
 	},
 	ImageServiceV2: {
-		newClient: openstack.NewImageServiceV2,
-		getMicroversion: func(config *openstackConfig) string {
-			microversion := DefaultImageServiceV2Microversion
-			if config.ImageServiceV2Microversion != nil {
-				microversion = *config.ImageServiceV2Microversion
-			}
-			return microversion
+		catalogType:         "image",
+		apiVersion:          "2",
+		defaultMicroversion: DefaultImageServiceV2Microversion,
+		maxMicroversion:     MaxImageServiceV2Microversion,
+		newClient:           openstack.NewImageServiceV2,
+		getMicroversion: func(config *openstackConfig) *string {
+			return config.ImageServiceV2Microversion
 		},
 	},
 }
 
 func getServiceClient(ctx context.Context, d *plugin.QueryData, key ServiceType) (*gophercloud.ServiceClient, error) {
-	plugin.Logger(ctx).Debug("returning service client", "type", key)
+	region := d.EqualsQualString(RegionKey)
+	projectID := getContextProjectID(ctx)
+	plugin.Logger(ctx).Debug("returning service client", "type", key, "region", region, "project", projectID)
+
+	// service clients are cached per (service, region) pair, so that queries
+	// fanning out across multiple regions reuse the same clients; clients
+	// using a token re-scoped to another project are cached per project too
+	cacheKey := fmt.Sprintf("%s/%s", key, region)
+	if projectID != "" {
+		cacheKey = fmt.Sprintf("%s/%s", cacheKey, projectID)
+	}
 
 	// load connection from cache, which preserves throttling protection etc
-	if cachedData, ok := d.ConnectionManager.Cache.Get(string(key)); ok {
-		plugin.Logger(ctx).Debug("returning service client from cache")
+	if cachedData, ok := d.ConnectionManager.Cache.Get(cacheKey); ok {
+		plugin.Logger(ctx).Debug("returning service client from cache", "type", key, "region", region)
 		return cachedData.(*gophercloud.ServiceClient), nil
 	}
 
@@ -113,34 +157,188 @@ func getServiceClient(ctx context.Context, d *plugin.QueryData, key ServiceType)
 		panic(fmt.Sprintf("invalid service type: %q", key))
 	}
 
-	plugin.Logger(ctx).Info("creating new service client", "type", key)
-	api, err := getAuthenticatedClient(ctx, d)
+	plugin.Logger(ctx).Info("creating new service client", "type", key, "region", region, "project", projectID)
+	var api *gophercloud.ProviderClient
+	var err error
+	if projectID != "" {
+		api, err = getProjectClient(ctx, d, projectID)
+	} else {
+		api, err = getAuthenticatedClient(ctx, d)
+	}
 	if err != nil {
 		plugin.Logger(ctx).Error("no valid authenticated provider client available", "error", err)
 		return nil, err
 	}
 
-	openstackConfig := GetConfig(d.Connection)
-	region := ""
-	if openstackConfig.Region != nil {
+	openstackConfig, err := getConnectionConfig(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving connection configuration", "error", err)
+		return nil, err
+	}
+	if region == "" && openstackConfig.Region != nil {
 		region = *openstackConfig.Region
 	}
+	availability, err := getAvailability(openstackConfig)
+	if err != nil {
+		plugin.Logger(ctx).Error("invalid endpoint interface", "error", err)
+		return nil, err
+	}
 
-	client, err := serviceConfigMap[key].newClient(api, gophercloud.EndpointOpts{Region: region})
+	client, err := serviceConfigMap[key].newClient(api, gophercloud.EndpointOpts{Region: region, Availability: availability})
 
 	if err != nil {
-		plugin.Logger(ctx).Error("error creating service client", "type", key, "error", err)
+		plugin.Logger(ctx).Error("error creating service client", "type", key, "region", region, "error", err)
+		return nil, err
+	}
+	client.Microversion, err = getClientMicroversion(ctx, d, key, client, openstackConfig)
+	if err != nil {
+		plugin.Logger(ctx).Error("error negotiating microversion", "type", key, "region", region, "error", err)
 		return nil, err
 	}
-	client.Microversion = serviceConfigMap[key].getMicroversion(&openstackConfig)
 
 	// save to cache
-	plugin.Logger(ctx).Debug("saving service client to cache", "type", key)
-	d.ConnectionManager.Cache.Set(string(key), client)
+	plugin.Logger(ctx).Debug("saving service client to cache", "type", key, "region", region)
+	d.ConnectionManager.Cache.Set(cacheKey, client)
 
 	return client, nil
 }
 
+// getAvailability returns the endpoint interface (public, internal or admin)
+// to pick from the service catalog; "publicURL" and the like are also accepted.
+func getAvailability(config *openstackConfig) (gophercloud.Availability, error) {
+	if config.Interface == nil {
+		return gophercloud.AvailabilityPublic, nil
+	}
+	availability := gophercloud.Availability(strings.TrimSuffix(strings.ToLower(*config.Interface), "url"))
+	switch availability {
+	case gophercloud.AvailabilityPublic, gophercloud.AvailabilityInternal, gophercloud.AvailabilityAdmin:
+		return availability, nil
+	}
+	return "", fmt.Errorf("invalid interface %q: must be one of public, internal or admin", *config.Interface)
+}
+
+// getEndpointOverrides parses the endpoint_overrides parameter, whose entries
+// are in the form "<service type>=<url>", e.g. "compute=https://nova:8774/v2.1/";
+// service types are those in the Keystone catalog, as listed in serviceConfigMap.
+func getEndpointOverrides(config *openstackConfig) (map[string]string, error) {
+	overrides := map[string]string{}
+	for _, entry := range config.EndpointOverrides {
+		catalogType, url, ok := strings.Cut(entry, "=")
+		catalogType, url = strings.TrimSpace(catalogType), strings.TrimSpace(url)
+		if !ok || catalogType == "" || url == "" {
+			return nil, fmt.Errorf("invalid endpoint override %q: must be in the form <service type>=<url>", entry)
+		}
+		valid := false
+		for _, service := range serviceConfigMap {
+			if service.catalogType == catalogType {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid endpoint override %q: unsupported service type %q", entry, catalogType)
+		}
+		overrides[catalogType] = gophercloud.NormalizeURL(url)
+	}
+	return overrides, nil
+}
+
+// getRegions returns the list of regions that queries should fan out across;
+// the list comes from the "regions" configuration parameter, where "*" stands
+// for all regions in the Keystone service catalog; if no list is provided, the
+// single "region" parameter is used instead.
+func getRegions(ctx context.Context, d *plugin.QueryData) ([]string, error) {
+
+	// load regions from cache
+	if cachedData, ok := d.ConnectionManager.Cache.Get(Regions); ok {
+		plugin.Logger(ctx).Debug("returning regions from cache")
+		return cachedData.([]string), nil
+	}
+
+	openstackConfig, err := getConnectionConfig(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving connection configuration", "error", err)
+		return nil, err
+	}
+
+	regions := []string{}
+	if len(openstackConfig.Regions) > 0 {
+		for _, region := range openstackConfig.Regions {
+			if region == "*" {
+				api, err := getAuthenticatedClient(ctx, d)
+				if err != nil {
+					plugin.Logger(ctx).Error("no valid authenticated provider client available", "error", err)
+					return nil, err
+				}
+				regions, err = getCatalogRegions(api)
+				if err != nil {
+					plugin.Logger(ctx).Error("error retrieving regions from service catalog", "error", err)
+					return nil, err
+				}
+				break
+			}
+			regions = append(regions, region)
+		}
+	} else if openstackConfig.Region != nil {
+		regions = append(regions, *openstackConfig.Region)
+	} else {
+		// no region specified: let the service catalog pick the endpoint
+		regions = append(regions, "")
+	}
+
+	plugin.Logger(ctx).Debug("saving regions to cache", "regions", regions)
+	d.ConnectionManager.Cache.Set(Regions, regions)
+
+	return regions, nil
+}
+
+// getCatalogRegions returns the sorted list of unique regions that appear in
+// the service catalog returned by Keystone at authentication time.
+func getCatalogRegions(api *gophercloud.ProviderClient) ([]string, error) {
+	result, ok := api.GetAuthResult().(tokens.CreateResult)
+	if !ok {
+		return nil, fmt.Errorf("no Identity V3 service catalog available")
+	}
+	catalog, err := result.ExtractServiceCatalog()
+	if err != nil {
+		return nil, err
+	}
+	unique := map[string]bool{}
+	for _, entry := range catalog.Entries {
+		for _, endpoint := range entry.Endpoints {
+			region := endpoint.RegionID
+			if region == "" {
+				region = endpoint.Region
+			}
+			if region != "" {
+				unique[region] = true
+			}
+		}
+	}
+	regions := make([]string, 0, len(unique))
+	for region := range unique {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return regions, nil
+}
+
+// regionMatrix is the matrix item function used by all tables whose data is
+// region-specific: it makes the SDK run the list and get hydrate functions
+// once per region; the "region" qual, if present, prunes the set of regions.
+func regionMatrix(ctx context.Context, d *plugin.QueryData) []map[string]interface{} {
+	regions, err := getRegions(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving regions", "error", err)
+		return nil
+	}
+	matrix := make([]map[string]interface{}, 0, len(regions))
+	for _, region := range regions {
+		matrix = append(matrix, map[string]interface{}{RegionKey: region})
+	}
+	return matrix
+}
+
 // Create the OpenStack REST API client.
 func getAuthenticatedClient(ctx context.Context, d *plugin.QueryData) (*gophercloud.ProviderClient, error) {
 
@@ -152,14 +350,25 @@ func getAuthenticatedClient(ctx context.Context, d *plugin.QueryData) (*gophercl
 
 	plugin.Logger(ctx).Info("creating new authenticated client")
 
-	// try with the environment first
+	openstackConfig, err := getConnectionConfig(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving connection configuration", "error", err)
+		return nil, err
+	}
+
+	// try with the environment first, unless a cloud was explicitly selected
+	// from clouds.yaml, in which case (like with openstacksdk) the OS_* auth
+	// variables are ignored
 	auth, err := openstack.AuthOptionsFromEnv()
+	if err == nil && getCloudName(openstackConfig) != "" {
+		plugin.Logger(ctx).Info("cloud selected, ignoring auth info in environment", "cloud", getCloudName(openstackConfig))
+		auth, err = gophercloud.AuthOptions{}, ErrCloudSelected
+	}
 	if err != nil {
 		plugin.Logger(ctx).Info("no auth info available in environment, filling with defaults", "error", err)
 
 		// fill the auth info from the configuration
 		auth.AllowReauth = true
-		openstackConfig := GetConfig(d.Connection)
 		plugin.Logger(ctx).Info("configuration", "info", utils.ToPrettyJSON(openstackConfig))
 		if openstackConfig.EndpointUrl != nil {
 			auth.IdentityEndpoint = *openstackConfig.EndpointUrl
@@ -204,15 +413,60 @@ func getAuthenticatedClient(ctx context.Context, d *plugin.QueryData) (*gophercl
 	// that all other fields except the endpoint URL be left blank!
 	//
 
-	client, err := openstack.AuthenticatedClient(auth)
+	client, err := openstack.NewClient(auth.IdentityEndpoint)
+	if err != nil {
+		plugin.Logger(ctx).Error("error creating provider client", "error", err)
+		return nil, err
+	}
+
+	// the HTTP client must be configured before authenticating, so that the
+	// TLS, retry and record/replay settings apply to Keystone too
+	httpClient, err := newHTTPClient(openstackConfig)
 	if err != nil {
+		plugin.Logger(ctx).Error("error creating HTTP client", "error", err)
+		return nil, err
+	}
+	transport, err := newRecordReplayTransport(openstackConfig, plugin.Logger(ctx), httpClient.Transport)
+	if err != nil {
+		plugin.Logger(ctx).Error("error setting up recording or replay", "error", err)
+		return nil, err
+	}
+	httpClient.Transport = newRetryTransport(openstackConfig, plugin.Logger(ctx), transport)
+	client.HTTPClient = *httpClient
+
+	if err = openstack.Authenticate(client, auth); err != nil {
 		plugin.Logger(ctx).Error("error creating authenticated client", "error", err)
 		return nil, err
 	}
 
+	// endpoint overrides replace the URLs in the service catalog
+	overrides, err := getEndpointOverrides(openstackConfig)
+	if err != nil {
+		plugin.Logger(ctx).Error("invalid endpoint overrides", "error", err)
+		return nil, err
+	}
+	applyEndpointOverrides(ctx, client, overrides)
+
 	// save to cache
 	plugin.Logger(ctx).Debug("saving authenticated client to cache")
 	d.ConnectionManager.Cache.Set(AuthenticatedClient, client)
 
 	return client, nil
 }
+
+// applyEndpointOverrides makes the client use the given URLs instead of those
+// in the service catalog; since the locator is only set at authentication and
+// not on re-authentication, it is safe to wrap it.
+func applyEndpointOverrides(ctx context.Context, client *gophercloud.ProviderClient, overrides map[string]string) {
+	if len(overrides) == 0 {
+		return
+	}
+	locator := client.EndpointLocator
+	client.EndpointLocator = func(eo gophercloud.EndpointOpts) (string, error) {
+		if url, ok := overrides[eo.Type]; ok {
+			plugin.Logger(ctx).Debug("overriding catalog endpoint", "type", eo.Type, "url", url)
+			return url, nil
+		}
+		return locator(eo)
+	}
+}
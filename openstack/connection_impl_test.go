@@ -0,0 +1,60 @@
+package openstack
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud"
+)
+
+func TestGetAvailability(t *testing.T) {
+	var tests = []struct {
+		value    *string
+		expected gophercloud.Availability
+		invalid  bool
+	}{
+		{value: nil, expected: gophercloud.AvailabilityPublic},
+		{value: utils.PointerTo("internal"), expected: gophercloud.AvailabilityInternal},
+		{value: utils.PointerTo("adminURL"), expected: gophercloud.AvailabilityAdmin},
+		{value: utils.PointerTo("private"), invalid: true},
+	}
+
+	for _, test := range tests {
+		actual, err := getAvailability(&openstackConfig{Interface: test.value})
+		if test.invalid {
+			if err == nil {
+				t.Errorf("%v: expected error", *test.value)
+			}
+			continue
+		}
+		if err != nil || actual != test.expected {
+			t.Errorf("expected %q, got %q (error: %v)", test.expected, actual, err)
+		}
+	}
+}
+
+func TestGetEndpointOverrides(t *testing.T) {
+	overrides, err := getEndpointOverrides(&openstackConfig{
+		EndpointOverrides: []string{
+			"compute=https://nova.example.com:8774/v2.1",
+			" load-balancer = https://octavia.example.com:9876/ ",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"compute":       "https://nova.example.com:8774/v2.1/",
+		"load-balancer": "https://octavia.example.com:9876/",
+	}
+	if !reflect.DeepEqual(overrides, expected) {
+		t.Errorf("expected %v, got %v", expected, overrides)
+	}
+
+	for _, entry := range []string{"compute", "=https://nova.example.com", "object-store=https://swift.example.com"} {
+		if _, err := getEndpointOverrides(&openstackConfig{EndpointOverrides: []string{entry}}); err == nil {
+			t.Errorf("%s: expected error", entry)
+		}
+	}
+}
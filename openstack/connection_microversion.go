@@ -0,0 +1,261 @@
+package openstack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+const (
+	// MicroversionAuto selects the highest microversion supported by both the
+	// server and the plugin.
+	MicroversionAuto = "auto"
+	// MicroversionLatest is accepted as a synonym of MicroversionAuto: going
+	// past the versions the plugin understands would only lose data.
+	MicroversionLatest = "latest"
+
+	// APIVersions is the prefix of the cache keys for the API versions
+	// discovered from the services' version documents.
+	APIVersions = "openstack_api_versions"
+)
+
+// apiVersion describes the API version supported by a service, as reported by
+// its version document.
+type apiVersion struct {
+	// ID is the version identifier, e.g. "v2.1".
+	ID string
+	// Status is the status of the version, e.g. "CURRENT".
+	Status string
+	// MinVersion is the lowest microversion supported by the server.
+	MinVersion string
+	// MaxVersion is the highest microversion supported by the server.
+	MaxVersion string
+}
+
+// versionDocument is an entry in the list of versions returned by the root
+// endpoint of an OpenStack service.
+type versionDocument struct {
+	ID         string `json:"id"`
+	Status     string `json:"status"`
+	Version    string `json:"version"`
+	MinVersion string `json:"min_version"`
+}
+
+// getClientMicroversion returns the microversion the service client should use:
+// the configured one, the default one if none is configured or, in auto mode,
+// the one negotiated with the server, falling back to the default one if the
+// server does not publish a usable version document.
+func getClientMicroversion(ctx context.Context, d *plugin.QueryData, key ServiceType, client *gophercloud.ServiceClient, config *openstackConfig) (string, error) {
+	service := serviceConfigMap[key]
+	configured := service.getMicroversion(config)
+	if configured == nil {
+		return service.defaultMicroversion, nil
+	}
+	if !isAutoMicroversion(*configured) {
+		return *configured, nil
+	}
+
+	version, err := getAPIVersion(ctx, d, key, client)
+	if err != nil {
+		plugin.Logger(ctx).Warn("error discovering API version, using default microversion", "type", key, "microversion", service.defaultMicroversion, "error", err)
+		return service.defaultMicroversion, nil
+	}
+	return negotiateMicroversion(version, service.maxMicroversion)
+}
+
+// isAutoMicroversion returns whether the microversion must be negotiated.
+func isAutoMicroversion(microversion string) bool {
+	microversion = strings.ToLower(strings.TrimSpace(microversion))
+	return microversion == MicroversionAuto || microversion == MicroversionLatest
+}
+
+// getAPIVersion retrieves the version document of the service from the root
+// of its endpoint and returns the entry for the major API version in use by
+// the plugin; the result is cached per (service, region) pair, like clients.
+func getAPIVersion(ctx context.Context, d *plugin.QueryData, key ServiceType, client *gophercloud.ServiceClient) (*apiVersion, error) {
+	region := d.EqualsQualString(RegionKey)
+	cacheKey := fmt.Sprintf("%s/%s/%s", APIVersions, key, region)
+	if cachedData, ok := d.ConnectionManager.Cache.Get(cacheKey); ok {
+		plugin.Logger(ctx).Debug("returning API version from cache", "type", key, "region", region)
+		return cachedData.(*apiVersion), nil
+	}
+
+	version, err := discoverAPIVersion(client, serviceConfigMap[key].apiVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	plugin.Logger(ctx).Debug("saving API version to cache", "type", key, "region", region, "version", version.ID)
+	d.ConnectionManager.Cache.Set(cacheKey, version)
+	return version, nil
+}
+
+// discoverAPIVersion reads the version document at the root of the service
+// endpoint and summarises the entries matching the given major version; some
+// services (e.g. Nova, Cinder) publish one entry per major version, with the
+// supported range of microversions, whereas others (e.g. Glance, Octavia)
+// publish one entry per minor version.
+func discoverAPIVersion(client *gophercloud.ServiceClient, major string) (*apiVersion, error) {
+	root, err := baseEndpoint(client.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]json.RawMessage{}
+	_, err = client.ProviderClient.Request("GET", root, &gophercloud.RequestOpts{
+		JSONResponse: &body,
+		// some services answer with 300 Multiple Choices
+		OkCodes: []int{200, 300},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving version document from %s: %w", root, err)
+	}
+	documents, err := parseVersionDocuments(body)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing version document from %s: %w", root, err)
+	}
+
+	var result *apiVersion
+	for _, document := range documents {
+		id := strings.TrimPrefix(document.ID, "v")
+		if id != major && !strings.HasPrefix(id, major+".") {
+			continue
+		}
+		max, min := document.Version, document.MinVersion
+		if max == "" {
+			max = id
+		}
+		if min == "" {
+			min = id
+		}
+		if result == nil {
+			result = &apiVersion{ID: document.ID, Status: document.Status, MinVersion: min, MaxVersion: max}
+			continue
+		}
+		if compareMicroversions(max, result.MaxVersion) > 0 {
+			result.ID, result.Status, result.MaxVersion = document.ID, document.Status, max
+		}
+		if compareMicroversions(min, result.MinVersion) < 0 {
+			result.MinVersion = min
+		}
+	}
+	if result == nil {
+		return nil, fmt.Errorf("no version %s in version document from %s", major, root)
+	}
+	return result, nil
+}
+
+// versionSegment matches the path segment holding the API version in an
+// endpoint URL, e.g. "v2.1" in "https://example.com/compute/v2.1/<project>".
+var versionSegment = regexp.MustCompile(`^v[0-9]+(\.[0-9]+)?$`)
+
+// baseEndpoint returns the endpoint URL stripped of the API version and of
+// anything following it; unlike utils.BaseEndpoint in gophercloud, it only
+// matches whole path segments, so that a path like "/volumev3/v3/" is handled.
+func baseEndpoint(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	u.RawQuery, u.Fragment = "", ""
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		if versionSegment.MatchString(segment) {
+			u.Path = strings.Join(segments[:i], "/") + "/"
+			break
+		}
+	}
+	return u.String(), nil
+}
+
+// parseVersionDocuments extracts the list of versions from a version document,
+// which is either in the {"versions": [...]} or in the {"versions": {"values":
+// [...]}} (Keystone) form; a single {"version": {...}} is accepted as well.
+func parseVersionDocuments(body map[string]json.RawMessage) ([]versionDocument, error) {
+	documents := []versionDocument{}
+	if raw, ok := body["versions"]; ok {
+		if err := json.Unmarshal(raw, &documents); err == nil {
+			return documents, nil
+		}
+		values := struct {
+			Values []versionDocument `json:"values"`
+		}{}
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return nil, err
+		}
+		return values.Values, nil
+	}
+	if raw, ok := body["version"]; ok {
+		document := versionDocument{}
+		if err := json.Unmarshal(raw, &document); err != nil {
+			return nil, err
+		}
+		return append(documents, document), nil
+	}
+	return nil, fmt.Errorf("no versions found")
+}
+
+// withMicroversion returns a copy of the client using the given microversion,
+// for calls that need a feature the configured microversion lacks, as long as
+// the server supports it; otherwise it returns false.
+func withMicroversion(ctx context.Context, d *plugin.QueryData, key ServiceType, client *gophercloud.ServiceClient, microversion string) (*gophercloud.ServiceClient, bool) {
+	if compareMicroversions(client.Microversion, microversion) >= 0 {
+		return client, true
+	}
+	version, err := getAPIVersion(ctx, d, key, client)
+	if err != nil {
+		plugin.Logger(ctx).Warn("error discovering API version", "type", key, "error", err)
+		return client, false
+	}
+	if compareMicroversions(version.MaxVersion, microversion) < 0 {
+		plugin.Logger(ctx).Debug("microversion not supported by the server", "type", key, "microversion", microversion, "max", version.MaxVersion)
+		return client, false
+	}
+	upgraded := *client
+	upgraded.Microversion = microversion
+	return &upgraded, true
+}
+
+// negotiateMicroversion returns the highest microversion supported by both the
+// server and the plugin; services without microversions get an empty one.
+func negotiateMicroversion(version *apiVersion, max string) (string, error) {
+	if max == "" {
+		return "", nil
+	}
+	microversion := max
+	if compareMicroversions(version.MaxVersion, microversion) < 0 {
+		microversion = version.MaxVersion
+	}
+	if compareMicroversions(microversion, version.MinVersion) < 0 {
+		return "", fmt.Errorf("server requires microversion %s or later, plugin supports up to %s", version.MinVersion, max)
+	}
+	return microversion, nil
+}
+
+// compareMicroversions compares two microversions in the "X.Y" form, returning
+// -1, 0 or +1 as a is lower than, equal to or greater than b; unparseable
+// components are treated as 0.
+func compareMicroversions(a, b string) int {
+	parse := func(version string) (int, int) {
+		major, minor, _ := strings.Cut(version, ".")
+		x, _ := strconv.Atoi(major)
+		y, _ := strconv.Atoi(minor)
+		return x, y
+	}
+	ax, ay := parse(a)
+	bx, by := parse(b)
+	switch {
+	case ax < bx || (ax == bx && ay < by):
+		return -1
+	case ax > bx || (ax == bx && ay > by):
+		return 1
+	}
+	return 0
+}
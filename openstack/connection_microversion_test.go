@@ -0,0 +1,132 @@
+package openstack
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gophercloud/gophercloud"
+)
+
+func TestDiscoverAPIVersion(t *testing.T) {
+	var tests = []struct {
+		service  string
+		path     string
+		status   int
+		major    string
+		expected apiVersion
+	}{
+		{
+			service:  "compute",
+			path:     "/compute/v2.1/",
+			status:   http.StatusOK,
+			major:    "2",
+			expected: apiVersion{ID: "v2.1", Status: "CURRENT", MinVersion: "2.0", MaxVersion: "2.95"},
+		},
+		{
+			service:  "volumev3",
+			path:     "/volumev3/v3/0123456789abcdef/",
+			status:   http.StatusMultipleChoices,
+			major:    "3",
+			expected: apiVersion{ID: "v3.0", Status: "CURRENT", MinVersion: "3.0", MaxVersion: "3.60"},
+		},
+		{
+			service:  "identity",
+			path:     "/identity/v3/",
+			status:   http.StatusOK,
+			major:    "3",
+			expected: apiVersion{ID: "v3.14", Status: "stable", MinVersion: "3.14", MaxVersion: "3.14"},
+		},
+		{
+			service:  "image",
+			path:     "/image/v2/",
+			status:   http.StatusMultipleChoices,
+			major:    "2",
+			expected: apiVersion{ID: "v2.16", Status: "CURRENT", MinVersion: "2.0", MaxVersion: "2.16"},
+		},
+	}
+
+	for _, test := range tests {
+		document, err := os.ReadFile(filepath.Join("testdata", "versions", test.service+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		status := test.status
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// the version document lives at the root of the service
+			if r.URL.Path != "/"+test.service+"/" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			w.Write(document)
+		}))
+
+		client := &gophercloud.ServiceClient{
+			ProviderClient: &gophercloud.ProviderClient{},
+			Endpoint:       server.URL + test.path,
+		}
+		actual, err := discoverAPIVersion(client, test.major)
+		server.Close()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.service, err)
+			continue
+		}
+		if *actual != test.expected {
+			t.Errorf("%s: expected %+v, got %+v", test.service, test.expected, *actual)
+		}
+	}
+}
+
+func TestNegotiateMicroversion(t *testing.T) {
+	var tests = []struct {
+		version  apiVersion
+		max      string
+		expected string
+		invalid  bool
+	}{
+		// newer servers are capped at what the plugin supports
+		{version: apiVersion{MinVersion: "2.1", MaxVersion: "2.95"}, max: "2.87", expected: "2.87"},
+		// older servers get their highest microversion
+		{version: apiVersion{MinVersion: "2.1", MaxVersion: "2.60"}, max: "2.87", expected: "2.60"},
+		{version: apiVersion{MinVersion: "3.0", MaxVersion: "3.9"}, max: "3.66", expected: "3.9"},
+		// services without microversions
+		{version: apiVersion{MinVersion: "2.0", MaxVersion: "2.0"}, max: "", expected: ""},
+		// servers dropping support for the plugin's versions
+		{version: apiVersion{MinVersion: "2.90", MaxVersion: "2.95"}, max: "2.87", invalid: true},
+	}
+
+	for _, test := range tests {
+		actual, err := negotiateMicroversion(&test.version, test.max)
+		if test.invalid {
+			if err == nil {
+				t.Errorf("%+v: expected error", test.version)
+			}
+			continue
+		}
+		if err != nil || actual != test.expected {
+			t.Errorf("%+v: expected %q, got %q (error: %v)", test.version, test.expected, actual, err)
+		}
+	}
+}
+
+func TestCompareMicroversions(t *testing.T) {
+	var tests = []struct {
+		a, b     string
+		expected int
+	}{
+		{"2.9", "2.10", -1},
+		{"2.87", "2.87", 0},
+		{"3.0", "2.95", 1},
+		{"2", "2.0", 0},
+	}
+
+	for _, test := range tests {
+		if actual := compareMicroversions(test.a, test.b); actual != test.expected {
+			t.Errorf("compare(%q, %q): expected %d, got %d", test.a, test.b, test.expected, actual)
+		}
+	}
+}
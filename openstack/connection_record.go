@@ -0,0 +1,348 @@
+package openstack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// Redacted replaces secrets (tokens, passwords etc.) in recorded exchanges.
+const Redacted = "REDACTED"
+
+// ErrNoRecording is wrapped by the errors returned in replay mode when there
+// is no recorded response for a request.
+var ErrNoRecording = errors.New("no recorded response")
+
+// redactedHeaders are the HTTP headers whose values are never written to disk.
+var redactedHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Auth-Token",
+	"X-Service-Token",
+	"X-Subject-Token",
+}
+
+// redactedFields are the keys of JSON objects (in request and response bodies)
+// whose string values are never written to disk: passwords and application
+// credential secrets in Keystone requests, and a few secrets that the APIs
+// may return (e.g. Ceph keyrings in volume attachments).
+var redactedFields = map[string]bool{
+	"adminPass":     true,
+	"auth_password": true,
+	"keyring":       true,
+	"password":      true,
+	"private_key":   true,
+	"secret":        true,
+}
+
+// recording is a request/response pair, as written to disk by recordTransport
+// and read back by replayTransport.
+type recording struct {
+	Request  recordedMessage `json:"request"`
+	Response recordedMessage `json:"response"`
+}
+
+type recordedMessage struct {
+	Method string      `json:"method,omitempty"`
+	URL    string      `json:"url,omitempty"`
+	Status int         `json:"status,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	// Body holds JSON bodies, Text any other body.
+	Body json.RawMessage `json:"body,omitempty"`
+	Text string          `json:"text,omitempty"`
+}
+
+// newRecordReplayTransport wraps the given transport so that exchanges are
+// recorded to record_dir, or replaces it so that they are served from the
+// recordings in replay_dir; the transport is returned unchanged otherwise.
+func newRecordReplayTransport(config *openstackConfig, logger hclog.Logger, next http.RoundTripper) (http.RoundTripper, error) {
+	switch {
+	case config.RecordDir != nil && config.ReplayDir != nil:
+		return nil, errors.New("record_dir and replay_dir cannot be used together")
+	case config.RecordDir != nil:
+		return newRecordTransport(expandHome(*config.RecordDir), logger, next)
+	case config.ReplayDir != nil:
+		return newReplayTransport(expandHome(*config.ReplayDir), logger)
+	}
+	return next, nil
+}
+
+//// RECORD
+
+// recordTransport writes every request and response to a file in a directory,
+// with secrets redacted; files are numbered in the order requests are sent,
+// after those already in the directory, so several sessions can be recorded
+// in the same place.
+type recordTransport struct {
+	next    http.RoundTripper
+	logger  hclog.Logger
+	dir     string
+	counter int64
+}
+
+// recordingName matches the names of recording files and captures the
+// sequence number.
+var recordingName = regexp.MustCompile(`^(\d+)-.*\.json$`)
+
+func newRecordTransport(dir string, logger hclog.Logger, next http.RoundTripper) (*recordTransport, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("error creating record directory: %w", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading record directory: %w", err)
+	}
+	t := &recordTransport{
+		next:   next,
+		logger: logger,
+		dir:    dir,
+	}
+	for _, entry := range entries {
+		if match := recordingName.FindStringSubmatch(entry.Name()); match != nil {
+			if n, err := strconv.ParseInt(match[1], 10, 64); err == nil && n > t.counter {
+				t.counter = n
+			}
+		}
+	}
+	return t, nil
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		requestBody = body
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	response, err := t.next.RoundTrip(req)
+	if err != nil {
+		return response, err
+	}
+
+	responseBody, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	r := &recording{
+		Request: recordedMessage{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: redactHeader(req.Header),
+		},
+		Response: recordedMessage{
+			Status: response.StatusCode,
+			Header: redactHeader(response.Header),
+		},
+	}
+	r.Request.Body, r.Request.Text = redactBody(requestBody)
+	r.Response.Body, r.Response.Text = redactBody(responseBody)
+
+	// failing to record must not fail the query
+	n := atomic.AddInt64(&t.counter, 1)
+	path := filepath.Join(t.dir, fmt.Sprintf("%06d-%s-%s.json", n, req.Method, sanitizePath(req.URL.Path)))
+	if data, err := json.MarshalIndent(r, "", "  "); err != nil {
+		t.logger.Warn("error encoding recording", "url", req.URL.String(), "error", err)
+	} else if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		t.logger.Warn("error writing recording", "path", path, "error", err)
+	} else {
+		t.logger.Debug("request recorded", "method", req.Method, "url", req.URL.String(), "path", path)
+	}
+	return response, nil
+}
+
+// sanitizePath turns a URL path into something suitable for a file name.
+func sanitizePath(path string) string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "root"
+	}
+	path = strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(path)
+	if len(path) > 100 {
+		path = path[:100]
+	}
+	return path
+}
+
+func redactHeader(header http.Header) http.Header {
+	header = header.Clone()
+	for _, name := range redactedHeaders {
+		if header.Get(name) != "" {
+			header.Set(name, Redacted)
+		}
+	}
+	return header
+}
+
+// redactBody returns the body, with secrets redacted, as JSON if it is valid
+// JSON and as text otherwise.
+func redactBody(body []byte) (json.RawMessage, string) {
+	if len(body) == 0 {
+		return nil, ""
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return nil, string(body)
+	}
+	data, err := json.Marshal(redactValue("", value))
+	if err != nil {
+		return nil, string(body)
+	}
+	return data, ""
+}
+
+// redactValue replaces the string values of sensitive fields; the key is the
+// one under which the value sits in its parent object, if any. Tokens are
+// redacted as well, both in "token" fields and as the id of "token" objects,
+// as in Keystone token authentication requests.
+func redactValue(key string, value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for k, v := range value {
+			if k == "id" && key == "token" {
+				if _, ok := v.(string); ok {
+					value[k] = Redacted
+				}
+				continue
+			}
+			value[k] = redactValue(k, v)
+		}
+	case []any:
+		for i, v := range value {
+			value[i] = redactValue("", v)
+		}
+	case string:
+		if redactedFields[key] || key == "token" {
+			return Redacted
+		}
+	}
+	return value
+}
+
+//// REPLAY
+
+// replayTransport serves responses from the recordings in a directory, without
+// ever touching the network. Requests are matched on method, path and query
+// (not on host, headers or body, so a replay works whatever the endpoint_url
+// and credentials); identical requests are served the matching recordings in
+// order, the last one being repeated when they run out.
+type replayTransport struct {
+	logger     hclog.Logger
+	lock       sync.Mutex
+	recordings map[string][]*recording
+}
+
+func newReplayTransport(dir string, logger hclog.Logger) (*replayTransport, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading replay directory: %w", err)
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && recordingName.MatchString(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	// sequence numbers may have more digits than the padding
+	sort.Slice(names, func(i, j int) bool {
+		a, _ := strconv.ParseInt(recordingName.FindStringSubmatch(names[i])[1], 10, 64)
+		b, _ := strconv.ParseInt(recordingName.FindStringSubmatch(names[j])[1], 10, 64)
+		return a < b
+	})
+
+	t := &replayTransport{
+		logger:     logger,
+		recordings: map[string][]*recording{},
+	}
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("error reading recording: %w", err)
+		}
+		r := &recording{}
+		if err := json.Unmarshal(data, r); err != nil {
+			return nil, fmt.Errorf("error parsing recording %s: %w", name, err)
+		}
+		u, err := url.Parse(r.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL in recording %s: %w", name, err)
+		}
+		key := replayKey(r.Request.Method, u)
+		t.recordings[key] = append(t.recordings[key], r)
+	}
+	logger.Debug("recordings loaded", "dir", dir, "count", len(names))
+	return t, nil
+}
+
+// replayKey identifies a request by method, path and (normalised) query.
+func replayKey(method string, u *url.URL) string {
+	return fmt.Sprintf("%s %s?%s", method, strings.TrimSuffix(u.Path, "/"), u.Query().Encode())
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	key := replayKey(req.Method, req.URL)
+	t.lock.Lock()
+	recordings := t.recordings[key]
+	if len(recordings) == 0 {
+		t.lock.Unlock()
+		return nil, fmt.Errorf("%w for %s %s", ErrNoRecording, req.Method, req.URL.String())
+	}
+	r := recordings[0]
+	if len(recordings) > 1 {
+		t.recordings[key] = recordings[1:]
+	}
+	t.lock.Unlock()
+
+	body := []byte(r.Response.Text)
+	if len(r.Response.Body) > 0 {
+		body = r.Response.Body
+	}
+	header := r.Response.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	// the body may have been re-encoded when recording
+	header.Del("Content-Length")
+	header.Del("Content-Encoding")
+
+	t.logger.Debug("replaying request", "method", req.Method, "url", req.URL.String(), "status", r.Response.Status)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Response.Status, http.StatusText(r.Response.Status)),
+		StatusCode:    r.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
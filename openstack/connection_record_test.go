@@ -0,0 +1,227 @@
+package openstack
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/hashicorp/go-hclog"
+)
+
+func TestRecordReplay(t *testing.T) {
+	dir := t.TempDir()
+
+	cloud := newFakeOpenStack(t)
+	cloud.config.RecordDir = utils.PointerTo(dir)
+	recorded, err := cloud.list(tableOpenStackInstance(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloud.Close()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, secret := range []string{TestToken, `"secret"`} {
+			if strings.Contains(string(data), secret) {
+				t.Errorf("recording %s contains secret %s", entry.Name(), secret)
+			}
+		}
+	}
+	// the admin rights are probed before listing the instances, and the
+	// *_name columns look up the user, the image and the project of the
+	// instances in another project
+	expected := []string{
+		"000001-POST-identity_v3_auth_tokens.json",
+		"000002-GET-compute_v2.1_servers.json",
+		"000003-GET-compute_v2.1_servers_detail.json",
+		"000004-GET-identity_v3_users_5d3c1a2b0e5f4e8b9c6a7d8e9f0a1b2c.json",
+		"000005-GET-image_v2_images_70a599e0-31e7-49b7-b260-868f441e862b.json",
+		"000006-GET-identity_v3_projects_a3b4c5d6e7f8091a2b3c4d5e6f708192.json",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected recordings %v, got %v", expected, names)
+	}
+
+	// the original cloud is gone, and the new one is never contacted
+	replay := newFakeOpenStack(t)
+	replay.config.ReplayDir = utils.PointerTo(dir)
+	replayed, err := replay.list(tableOpenStackInstance(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(replayed, recorded) {
+		t.Errorf("expected replayed rows %v, got %v", recorded, replayed)
+	}
+	if requests := replay.received("/identity/v3/auth/tokens"); len(requests) != 0 {
+		t.Errorf("expected no requests in replay mode, got %d", len(requests))
+	}
+
+	// a query that was not recorded fails
+	if _, err := replay.list(tableOpenStackFlavor(context.Background()), testQuery{}); !errors.Is(err, ErrNoRecording) {
+		t.Errorf("expected error %v, got %v", ErrNoRecording, err)
+	}
+}
+
+func TestRecordTransportNumbering(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "000041-GET-compute_v2.1_servers_detail.json"), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	transport, err := newRecordTransport(dir, hclog.NewNullLogger(), http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport.counter != 41 {
+		t.Errorf("expected recordings to continue after 41, got %d", transport.counter)
+	}
+}
+
+func TestReplayTransport(t *testing.T) {
+	dir := t.TempDir()
+	recordings := map[string]string{
+		"000001-GET-servers.json": `{"request": {"method": "GET", "url": "https://nova.example.com/v2.1/servers?limit=1&all_tenants=true"}, "response": {"status": 503}}`,
+		"000002-GET-servers.json": `{"request": {"method": "GET", "url": "https://nova.example.com/v2.1/servers?limit=1&all_tenants=true"}, "response": {"status": 200, "body": {"servers": []}}}`,
+		"000003-GET-root.json":    `{"request": {"method": "GET", "url": "https://nova.example.com/"}, "response": {"status": 300, "text": "multiple choices"}}`,
+	}
+	for name, data := range recordings {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	transport, err := newReplayTransport(dir, hclog.NewNullLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		url    string
+		status int
+		body   string
+	}{
+		// the host and the order of query parameters do not matter
+		{url: "http://localhost/v2.1/servers?all_tenants=true&limit=1", status: http.StatusServiceUnavailable},
+		{url: "http://localhost/v2.1/servers?all_tenants=true&limit=1", status: http.StatusOK, body: `{"servers": []}`},
+		// the last recording is repeated
+		{url: "http://localhost/v2.1/servers?all_tenants=true&limit=1", status: http.StatusOK, body: `{"servers": []}`},
+		{url: "http://localhost", status: http.StatusMultipleChoices, body: "multiple choices"},
+		{url: "http://localhost/v2.1/servers", status: 0},
+	}
+
+	for _, test := range tests {
+		u, _ := url.Parse(test.url)
+		response, err := transport.RoundTrip(&http.Request{Method: http.MethodGet, URL: u})
+		if test.status == 0 {
+			if !errors.Is(err, ErrNoRecording) {
+				t.Errorf("%s: expected error %v, got %v", test.url, ErrNoRecording, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.url, err)
+			continue
+		}
+		body, err := io.ReadAll(response.Body)
+		if err != nil {
+			t.Errorf("%s: unexpected error reading body: %v", test.url, err)
+		}
+		if response.StatusCode != test.status || string(body) != test.body {
+			t.Errorf("%s: expected %d %q, got %d %q", test.url, test.status, test.body, response.StatusCode, string(body))
+		}
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	var tests = []struct {
+		name     string
+		body     string
+		expected string
+		text     string
+	}{
+		{
+			name:     "password",
+			body:     `{"auth":{"identity":{"methods":["password"],"password":{"user":{"name":"admin","password":"s3cr3t"}}}}}`,
+			expected: `{"auth":{"identity":{"methods":["password"],"password":{"user":{"name":"admin","password":"REDACTED"}}}}}`,
+		},
+		{
+			name:     "token",
+			body:     `{"auth":{"identity":{"methods":["token"],"token":{"id":"gAAAAAB"}}}}`,
+			expected: `{"auth":{"identity":{"methods":["token"],"token":{"id":"REDACTED"}}}}`,
+		},
+		{
+			name:     "application credential",
+			body:     `{"auth":{"identity":{"application_credential":{"id":"abc","secret":"s3cr3t"}}}}`,
+			expected: `{"auth":{"identity":{"application_credential":{"id":"abc","secret":"REDACTED"}}}}`,
+		},
+		{
+			name:     "keyring",
+			body:     `{"attachments":[{"connection_info":{"keyring":"AQBx","secret_uuid":"7f1c"},"size":2361393152}]}`,
+			expected: `{"attachments":[{"connection_info":{"keyring":"REDACTED","secret_uuid":"7f1c"},"size":2361393152}]}`,
+		},
+		{
+			name: "text",
+			body: "<html>Bad Gateway</html>",
+			text: "<html>Bad Gateway</html>",
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	for _, test := range tests {
+		body, text := redactBody([]byte(test.body))
+		if string(body) != test.expected || text != test.text {
+			t.Errorf("%s: expected %q %q, got %q %q", test.name, test.expected, test.text, string(body), text)
+		}
+	}
+}
+
+func TestRedactHeader(t *testing.T) {
+	header := redactHeader(http.Header{
+		"X-Auth-Token":    {TestToken},
+		"X-Subject-Token": {TestToken},
+		"Content-Type":    {"application/json"},
+	})
+	expected := http.Header{
+		"X-Auth-Token":    {Redacted},
+		"X-Subject-Token": {Redacted},
+		"Content-Type":    {"application/json"},
+	}
+	if !reflect.DeepEqual(header, expected) {
+		t.Errorf("expected headers %v, got %v", expected, header)
+	}
+}
+
+func TestNewRecordReplayTransport(t *testing.T) {
+	_, err := newRecordReplayTransport(&openstackConfig{
+		RecordDir: utils.PointerTo(t.TempDir()),
+		ReplayDir: utils.PointerTo(t.TempDir()),
+	}, hclog.NewNullLogger(), http.DefaultTransport)
+	if err == nil {
+		t.Error("expected error with both record_dir and replay_dir")
+	}
+
+	_, err = newRecordReplayTransport(&openstackConfig{
+		ReplayDir: utils.PointerTo(filepath.Join(t.TempDir(), "missing")),
+	}, hclog.NewNullLogger(), http.DefaultTransport)
+	if err == nil {
+		t.Error("expected error with missing replay_dir")
+	}
+}
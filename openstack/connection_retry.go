@@ -0,0 +1,223 @@
+package openstack
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+const (
+	// DefaultMaxErrorRetryAttempts is the default number of times a request
+	// is retried when the server is throttling or temporarily unavailable.
+	DefaultMaxErrorRetryAttempts = 5
+	// DefaultMinErrorRetryDelay is the default delay before the first retry,
+	// in milliseconds; it is doubled at every attempt.
+	DefaultMinErrorRetryDelay = 250
+	// DefaultMaxErrorRetryDelay is the maximum delay between two attempts,
+	// in milliseconds; requests are not retried if the server asks for more
+	// via Retry-After.
+	DefaultMaxErrorRetryDelay = 30000
+	// DefaultMaxConcurrency is the default maximum number of requests in
+	// flight at any time towards the OpenStack APIs, per connection.
+	DefaultMaxConcurrency = 10
+)
+
+// retryTransport retries requests that fail with 429 (Too Many Requests) or 503
+// (Service Unavailable), and read-only ones that fail with 409 (Conflict), with
+// exponential backoff and jitter, honouring the Retry-After header if present
+// unless it asks to wait longer than the maximum delay; it also caps the
+// number of requests in flight, so that fanning out across regions, projects
+// or pools does not overwhelm the APIs. It sits below the ProviderClient, so all tables
+// (and Keystone authentication) go through it.
+type retryTransport struct {
+	next     http.RoundTripper
+	logger   hclog.Logger
+	attempts int
+	minDelay time.Duration
+	maxDelay time.Duration
+	// slots is the semaphore capping the requests in flight; nil means no cap.
+	slots chan struct{}
+}
+
+// newRetryTransport wraps the given transport according to the retry settings
+// in the connection configuration.
+func newRetryTransport(config *openstackConfig, logger hclog.Logger, next http.RoundTripper) *retryTransport {
+	t := &retryTransport{
+		next:     next,
+		logger:   logger,
+		attempts: DefaultMaxErrorRetryAttempts,
+		minDelay: DefaultMinErrorRetryDelay * time.Millisecond,
+		maxDelay: DefaultMaxErrorRetryDelay * time.Millisecond,
+	}
+	if config.MaxErrorRetryAttempts != nil && *config.MaxErrorRetryAttempts >= 0 {
+		t.attempts = *config.MaxErrorRetryAttempts
+	}
+	if config.MinErrorRetryDelay != nil && *config.MinErrorRetryDelay > 0 {
+		t.minDelay = time.Duration(*config.MinErrorRetryDelay) * time.Millisecond
+	}
+	if t.maxDelay < t.minDelay {
+		t.maxDelay = t.minDelay
+	}
+	if concurrency := getMaxConcurrency(config); concurrency > 0 {
+		t.slots = make(chan struct{}, concurrency)
+	}
+	return t
+}
+
+// getMaxConcurrency returns the maximum number of requests in flight towards
+// the OpenStack APIs, or 0 if there is no cap; fan-out loops within tables use
+// it to decide how many requests to run in parallel.
+func getMaxConcurrency(config *openstackConfig) int {
+	if config.MaxConcurrency != nil {
+		return *config.MaxConcurrency
+	}
+	return DefaultMaxConcurrency
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		response, err := t.send(req)
+		if err != nil || !isRetryable(req.Method, response.StatusCode) {
+			return response, err
+		}
+		// requests whose body cannot be replayed are not retried
+		if attempt >= t.attempts || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+			return response, nil
+		}
+
+		delay, ok := t.backoff(attempt, response.Header.Get("Retry-After"))
+		if !ok {
+			t.logger.Debug("not retrying request, the server asks to wait too long", "method", req.Method, "url", req.URL.String(), "status", response.StatusCode, "retry after", response.Header.Get("Retry-After"))
+			return response, nil
+		}
+		t.logger.Debug("retrying request", "method", req.Method, "url", req.URL.String(), "status", response.StatusCode, "attempt", attempt+1, "delay", delay)
+		response.Body.Close()
+
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// send performs a single attempt, waiting for a free slot if the number of
+// requests in flight is capped; slots are not held while backing off.
+func (t *retryTransport) send(req *http.Request) (*http.Response, error) {
+	if t.slots != nil {
+		select {
+		case t.slots <- struct{}{}:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		defer func() { <-t.slots }()
+	}
+	return t.next.RoundTrip(req)
+}
+
+// backoff returns the delay before the next attempt: the one requested by the
+// server via Retry-After if any, otherwise an exponentially growing delay with
+// "equal jitter", i.e. a random value between half and the whole of it; it
+// returns false if the server asks to wait longer than the maximum delay, so
+// that a query does not stall for that long.
+func (t *retryTransport) backoff(attempt int, retryAfter string) (time.Duration, bool) {
+	if retryAfter != "" {
+		delay := time.Duration(-1)
+		if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+			delay = time.Duration(seconds) * time.Second
+		} else if date, err := http.ParseTime(retryAfter); err == nil {
+			delay = 0
+			if until := time.Until(date); until > 0 {
+				delay = until
+			}
+		}
+		if delay >= 0 {
+			return delay, delay <= t.maxDelay
+		}
+	}
+	delay := t.maxDelay
+	if attempt < 32 && t.minDelay<<attempt < t.maxDelay {
+		delay = t.minDelay << attempt
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1)), true
+}
+
+// isRetryable returns whether the status code signals a transient condition
+// for a request with the given method: 409 (Conflict) only does for read-only
+// requests, since for the others it reports a conflict with the state of the
+// resource, e.g. an action on a server in the wrong state.
+func isRetryable(method string, status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusConflict:
+		return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+	}
+	return false
+}
+
+// sleep waits for the given delay, returning early if the context is done.
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// forEachConcurrently calls fn for each item, running at most concurrency
+// calls at a time (all of them if concurrency is 0), and returns the first
+// error encountered; items not yet started when the context is done or an
+// error occurs are skipped.
+func forEachConcurrently[T any](ctx context.Context, concurrency int, items []T, fn func(T) error) error {
+	if concurrency <= 0 || concurrency > len(items) {
+		concurrency = len(items)
+	}
+	var (
+		wg    sync.WaitGroup
+		lock  sync.Mutex
+		first error
+		slots = make(chan struct{}, concurrency)
+	)
+	failed := func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return first != nil
+	}
+	for _, item := range items {
+		slots <- struct{}{}
+		if ctx.Err() != nil || failed() {
+			break
+		}
+		wg.Add(1)
+		go func(item T) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			if err := fn(item); err != nil {
+				lock.Lock()
+				if first == nil {
+					first = err
+				}
+				lock.Unlock()
+			}
+		}(item)
+	}
+	wg.Wait()
+	return first
+}
@@ -0,0 +1,252 @@
+package openstack
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/hashicorp/go-hclog"
+)
+
+// newTestRetryClient returns an HTTP client going through a retryTransport with
+// short delays, so that tests do not take long.
+func newTestRetryClient(attempts int, concurrency int) *http.Client {
+	transport := newRetryTransport(&openstackConfig{
+		MaxErrorRetryAttempts: utils.PointerTo(attempts),
+		MinErrorRetryDelay:    utils.PointerTo(1),
+		MaxConcurrency:        utils.PointerTo(concurrency),
+	}, hclog.NewNullLogger(), http.DefaultTransport)
+	transport.maxDelay = 10 * time.Millisecond
+	return &http.Client{Transport: transport}
+}
+
+func TestRetryTransport(t *testing.T) {
+	var tests = []struct {
+		name     string
+		method   string
+		failures int
+		status   int
+		attempts int
+		expected int
+		requests int32
+	}{
+		{name: "conflict", failures: 2, status: http.StatusConflict, attempts: 3, expected: http.StatusOK, requests: 3},
+		{name: "too many requests", failures: 1, status: http.StatusTooManyRequests, attempts: 3, expected: http.StatusOK, requests: 2},
+		{name: "unavailable", failures: 5, status: http.StatusServiceUnavailable, attempts: 2, expected: http.StatusServiceUnavailable, requests: 3},
+		{name: "conflict on action", method: http.MethodPost, failures: 1, status: http.StatusConflict, attempts: 3, expected: http.StatusConflict, requests: 1},
+		{name: "not retryable", failures: 1, status: http.StatusInternalServerError, attempts: 3, expected: http.StatusInternalServerError, requests: 1},
+		{name: "no retries", failures: 1, status: http.StatusTooManyRequests, attempts: 0, expected: http.StatusTooManyRequests, requests: 1},
+	}
+
+	for _, test := range tests {
+		var requests int32
+		test := test
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&requests, 1) <= int32(test.failures) {
+				w.WriteHeader(test.status)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		if test.method == "" {
+			test.method = http.MethodGet
+		}
+		request, err := http.NewRequest(test.method, server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := newTestRetryClient(test.attempts, 0).Do(request)
+		server.Close()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		response.Body.Close()
+		if response.StatusCode != test.expected {
+			t.Errorf("%s: expected status %d, got %d", test.name, test.expected, response.StatusCode)
+		}
+		if requests != test.requests {
+			t.Errorf("%s: expected %d requests, got %d", test.name, test.requests, requests)
+		}
+	}
+}
+
+func TestRetryTransportReplaysBody(t *testing.T) {
+	var (
+		requests int32
+		bodies   []string
+		lock     sync.Mutex
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		lock.Lock()
+		bodies = append(bodies, string(body))
+		lock.Unlock()
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	response, err := newTestRetryClient(3, 0).Post(server.URL, "application/json", strings.NewReader(`{"auth":{}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if len(bodies) != 2 || bodies[0] != bodies[1] {
+		t.Errorf("expected the same body to be sent twice, got %q", bodies)
+	}
+}
+
+func TestRetryTransportBackoff(t *testing.T) {
+	transport := newRetryTransport(&openstackConfig{MinErrorRetryDelay: utils.PointerTo(100)}, hclog.NewNullLogger(), http.DefaultTransport)
+
+	// exponential backoff with jitter, within [delay/2, delay]
+	for attempt, expected := range []time.Duration{100, 200, 400, 800} {
+		expected *= time.Millisecond
+		delay, _ := transport.backoff(attempt, "")
+		if delay < expected/2 || delay > expected {
+			t.Errorf("attempt %d: expected delay in [%v, %v], got %v", attempt, expected/2, expected, delay)
+		}
+	}
+	// capped at the maximum delay
+	if delay, _ := transport.backoff(30, ""); delay > DefaultMaxErrorRetryDelay*time.Millisecond {
+		t.Errorf("expected delay capped at %dms, got %v", DefaultMaxErrorRetryDelay, delay)
+	}
+	// Retry-After wins, either in seconds or as an HTTP date
+	if delay, ok := transport.backoff(0, "7"); !ok || delay != 7*time.Second {
+		t.Errorf("expected delay of 7s from Retry-After, got %v", delay)
+	}
+	date := time.Now().Add(20 * time.Second).UTC().Format(http.TimeFormat)
+	if delay, ok := transport.backoff(0, date); !ok || delay < 18*time.Second || delay > 20*time.Second {
+		t.Errorf("expected delay of about 20s from Retry-After, got %v", delay)
+	}
+	// unless it exceeds the maximum delay
+	if delay, ok := transport.backoff(0, "3600"); ok {
+		t.Errorf("expected no retry with Retry-After of 1h, got delay %v", delay)
+	}
+	date = time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if delay, ok := transport.backoff(0, date); ok {
+		t.Errorf("expected no retry with Retry-After in 1h, got delay %v", delay)
+	}
+}
+
+func TestRetryTransportRetryAfterTooLong(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	response, err := newTestRetryClient(3, 0).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusTooManyRequests || requests != 1 {
+		t.Errorf("expected the 429 response after 1 request, got %d after %d", response.StatusCode, requests)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected no wait, waited %v", elapsed)
+	}
+}
+
+func TestRetryTransportCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newTestRetryClient(3, 0)
+	client.Transport.(*retryTransport).maxDelay = time.Hour
+	if _, err := client.Do(request); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded while waiting to retry, got %v", err)
+	}
+}
+
+func TestRetryTransportConcurrency(t *testing.T) {
+	var inflight, peak int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inflight, 1)
+		defer atomic.AddInt32(&inflight, -1)
+		for {
+			max := atomic.LoadInt32(&peak)
+			if current <= max || atomic.CompareAndSwapInt32(&peak, max, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := newTestRetryClient(0, 2)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := client.Get(server.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			response.Body.Close()
+		}()
+	}
+	wg.Wait()
+	if peak > 2 {
+		t.Errorf("expected at most 2 requests in flight, got %d", peak)
+	}
+}
+
+func TestForEachConcurrently(t *testing.T) {
+	var (
+		lock  sync.Mutex
+		items []int
+	)
+	err := forEachConcurrently(context.Background(), 3, []int{1, 2, 3, 4, 5, 6, 7}, func(item int) error {
+		lock.Lock()
+		defer lock.Unlock()
+		items = append(items, item)
+		return nil
+	})
+	if err != nil || len(items) != 7 {
+		t.Errorf("expected all 7 items processed without errors, got %v (error: %v)", items, err)
+	}
+
+	failure := errors.New("failure")
+	err = forEachConcurrently(context.Background(), 1, []int{1, 2, 3}, func(item int) error {
+		if item == 2 {
+			return failure
+		}
+		return nil
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("expected error from callback, got %v", err)
+	}
+}
@@ -0,0 +1,462 @@
+package openstack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/projects"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/tokens"
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+const (
+	// ScopeAllProjects makes tables list the resources of all projects, which
+	// requires admin rights; it is the default.
+	ScopeAllProjects = "all_projects"
+	// ScopeCurrentProject makes tables list only the resources of the project
+	// the connection is scoped to.
+	ScopeCurrentProject = "current_project"
+	// ScopeAccessibleProjects makes tables list the resources of all the
+	// projects the user has a role in, using a token re-scoped to each of
+	// them; it does not require admin rights.
+	ScopeAccessibleProjects = "accessible_projects"
+
+	// ProjectScope is the cache key for the project scope of the connection.
+	ProjectScope = "openstack_project_scope"
+	// AdminRights is the cache key for the result of the admin rights probe.
+	AdminRights = "openstack_admin_rights"
+)
+
+// projectScope describes the projects whose resources the tables list, as
+// resolved from the scope parameter and the rights of the user.
+type projectScope struct {
+	// AllTenants is whether the resources of other projects than the current
+	// one must be requested (the all_tenants or all_projects filters); it is
+	// only set for users with admin rights.
+	AllTenants bool
+	// ProjectIDs restricts the listing to the given projects; if nil, all the
+	// resources visible to the user are listed.
+	ProjectIDs []string
+	// Rescoped is whether the resources of each project are listed with a
+	// token scoped to it, as is the case without admin rights.
+	Rescoped bool
+	// CurrentProjectID is the project the connection is scoped to.
+	CurrentProjectID string
+	// ProjectNames maps the IDs of the projects known to the connection (the
+	// current one and, when re-scoping, the accessible ones) to their names.
+	ProjectNames map[string]string
+}
+
+// contextKey is the type of the keys of the values the plugin stores in the
+// context.
+type contextKey string
+
+// projectContextKey is the context key of the project whose scoped token the
+// service clients must use.
+const projectContextKey contextKey = "openstack_project_id"
+
+// getProjectScope returns the project scope of the connection; the rights of
+// the user are probed once, by asking Nova for the servers of all projects,
+// and without them the scope falls back to what the user can see or, for
+// lists of projects, to re-scoping the token to each of those the user has
+// access to.
+func getProjectScope(ctx context.Context, d *plugin.QueryData) (*projectScope, error) {
+
+	// load scope from cache
+	if cachedData, ok := d.ConnectionManager.Cache.Get(ProjectScope); ok {
+		return cachedData.(*projectScope), nil
+	}
+
+	openstackConfig, err := getConnectionConfig(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving connection configuration", "error", err)
+		return nil, err
+	}
+	api, err := getAuthenticatedClient(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("no valid authenticated provider client available", "error", err)
+		return nil, err
+	}
+	result, ok := api.GetAuthResult().(tokens.CreateResult)
+	if !ok {
+		return nil, fmt.Errorf("no Identity V3 token available")
+	}
+	project, err := result.ExtractProject()
+	if err != nil {
+		plugin.Logger(ctx).Error("error extracting project from token", "error", err)
+		return nil, err
+	}
+
+	scope := &projectScope{ProjectNames: map[string]string{}}
+	if project != nil {
+		scope.CurrentProjectID = project.ID
+		scope.ProjectNames[project.ID] = project.Name
+	}
+
+	switch projectIDs := parseScope(openstackConfig); {
+	case projectIDs == nil:
+		admin, err := hasAdminRights(ctx, d)
+		if err != nil {
+			return nil, err
+		}
+		scope.AllTenants = admin
+		if !admin {
+			plugin.Logger(ctx).Warn("no admin rights, listing only the resources visible to the current project", "project", scope.CurrentProjectID)
+		}
+	case len(projectIDs) == 1 && projectIDs[0] == ScopeCurrentProject:
+		scope.ProjectIDs = []string{scope.CurrentProjectID}
+	case len(projectIDs) == 1 && projectIDs[0] == ScopeAccessibleProjects:
+		accessible, err := getAccessibleProjects(ctx, d)
+		if err != nil {
+			plugin.Logger(ctx).Error("error retrieving accessible projects", "error", err)
+			return nil, err
+		}
+		scope.Rescoped = true
+		scope.ProjectIDs = []string{}
+		for _, project := range accessible {
+			scope.ProjectIDs = append(scope.ProjectIDs, project.ID)
+			scope.ProjectNames[project.ID] = project.Name
+		}
+	default:
+		admin, err := hasAdminRights(ctx, d)
+		if err != nil {
+			return nil, err
+		}
+		if admin {
+			scope.AllTenants = true
+			scope.ProjectIDs = projectIDs
+			break
+		}
+		plugin.Logger(ctx).Info("no admin rights, re-scoping the token to each project", "scope", projectIDs)
+		accessible, err := getAccessibleProjects(ctx, d)
+		if err != nil {
+			plugin.Logger(ctx).Error("error retrieving accessible projects", "error", err)
+			return nil, err
+		}
+		names := map[string]string{}
+		for _, project := range accessible {
+			names[project.ID] = project.Name
+		}
+		scope.Rescoped = true
+		scope.ProjectIDs = []string{}
+		for _, projectID := range projectIDs {
+			name, ok := names[projectID]
+			if !ok {
+				plugin.Logger(ctx).Warn("no access to project, skipping", "project", projectID)
+				continue
+			}
+			scope.ProjectIDs = append(scope.ProjectIDs, projectID)
+			scope.ProjectNames[projectID] = name
+		}
+	}
+
+	plugin.Logger(ctx).Debug("saving project scope to cache", "all tenants", scope.AllTenants, "projects", scope.ProjectIDs, "rescoped", scope.Rescoped)
+	d.ConnectionManager.Cache.Set(ProjectScope, scope)
+
+	return scope, nil
+}
+
+// hasAdminRights returns whether the user can list the resources of all
+// projects; since which roles grant it depends on the policies of the cloud,
+// it is probed once by listing a single server of all projects, which Nova
+// forbids to users without admin rights.
+func hasAdminRights(ctx context.Context, d *plugin.QueryData) (bool, error) {
+
+	// load result from cache
+	if cachedData, ok := d.ConnectionManager.Cache.Get(AdminRights); ok {
+		return cachedData.(bool), nil
+	}
+
+	client, err := getServiceClient(ctx, d, ComputeV2)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		return false, err
+	}
+	query, err := servers.ListOpts{AllTenants: true, Limit: 1}.ToServerListQuery()
+	if err != nil {
+		return false, err
+	}
+	admin := true
+	_, err = client.Get(client.ServiceURL("servers")+query, nil, nil)
+	if errors.As(err, &gophercloud.ErrDefault403{}) {
+		admin = false
+	} else if err != nil {
+		plugin.Logger(ctx).Error("error probing admin rights", "error", err)
+		return false, err
+	}
+
+	plugin.Logger(ctx).Debug("saving admin rights to cache", "admin", admin)
+	d.ConnectionManager.Cache.Set(AdminRights, admin)
+
+	return admin, nil
+}
+
+// parseScope parses the scope parameter, which is either "all_projects" (the
+// default, returned as nil), "current_project" or "accessible_projects"
+// (returned as is) or a comma-separated list of project IDs.
+func parseScope(config *openstackConfig) []string {
+	if config.Scope == nil {
+		return nil
+	}
+	switch value := strings.TrimSpace(*config.Scope); strings.ToLower(value) {
+	case "", ScopeAllProjects:
+		return nil
+	case ScopeCurrentProject:
+		return []string{ScopeCurrentProject}
+	case ScopeAccessibleProjects:
+		return []string{ScopeAccessibleProjects}
+	}
+	projectIDs := []string{}
+	for _, projectID := range strings.Split(*config.Scope, ",") {
+		if projectID = strings.TrimSpace(projectID); projectID != "" {
+			projectIDs = append(projectIDs, projectID)
+		}
+	}
+	return projectIDs
+}
+
+// Projects returns the projects to list resources for, given the value of
+// the project_id qual (if any); an empty project ID stands for no filter.
+func (s *projectScope) Projects(qual string) []string {
+	if s.ProjectIDs == nil {
+		return []string{qual}
+	}
+	if qual != "" {
+		if s.Includes(qual) {
+			return []string{qual}
+		}
+		return nil
+	}
+	return s.ProjectIDs
+}
+
+// Includes returns whether the resources of the given project are in scope.
+func (s *projectScope) Includes(projectID string) bool {
+	if s.ProjectIDs == nil {
+		return true
+	}
+	for _, id := range s.ProjectIDs {
+		if id == projectID {
+			return true
+		}
+	}
+	return false
+}
+
+// Context returns the context for requests concerning the resources of the
+// given project: when re-scoping, it makes getServiceClient return a client
+// using a token scoped to the project.
+func (s *projectScope) Context(ctx context.Context, projectID string) context.Context {
+	if !s.Rescoped || projectID == "" || projectID == s.CurrentProjectID {
+		return ctx
+	}
+	return context.WithValue(ctx, projectContextKey, projectID)
+}
+
+// getContextProjectID returns the project whose scoped token the service
+// clients must use, or an empty string for the connection's own token.
+func getContextProjectID(ctx context.Context) string {
+	projectID, _ := ctx.Value(projectContextKey).(string)
+	return projectID
+}
+
+// forEachProject calls list once for each of the projects in scope, given the
+// project_id qual, with a service client of the given type that can see the
+// project's resources; projects are listed in parallel but within the limit
+// on requests in flight.
+func forEachProject(ctx context.Context, d *plugin.QueryData, scope *projectScope, key ServiceType, list func(client *gophercloud.ServiceClient, projectID string) error) error {
+	openstackConfig, err := getConnectionConfig(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving connection configuration", "error", err)
+		return err
+	}
+	return forEachConcurrently(ctx, getMaxConcurrency(openstackConfig), scope.Projects(d.EqualsQualString("project_id")), func(projectID string) error {
+		client, err := getServiceClient(scope.Context(ctx, projectID), d, key)
+		if err != nil {
+			plugin.Logger(ctx).Error("error retrieving client", "project", projectID, "error", err)
+			return err
+		}
+		return list(client, projectID)
+	})
+}
+
+// getProjectIDs returns the projects to query one at a time, for resources
+// that can only be listed per project, given the project_id qual: the projects
+// in scope, all those in Keystone for an admin without an explicit scope, or
+// the current one otherwise.
+func getProjectIDs(ctx context.Context, d *plugin.QueryData, scope *projectScope) ([]string, error) {
+	projectIDs := scope.Projects(d.EqualsQualString("project_id"))
+	if len(projectIDs) != 1 || projectIDs[0] != "" {
+		return projectIDs, nil
+	}
+	if !scope.AllTenants {
+		return []string{scope.CurrentProjectID}, nil
+	}
+
+	client, err := getServiceClient(ctx, d, IdentityV3)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		return nil, err
+	}
+	allPages, err := projects.List(client, &projects.ListOpts{}).AllPages()
+	if err != nil {
+		plugin.Logger(ctx).Error("error listing projects", "error", err)
+		return nil, err
+	}
+	allProjects, err := projects.ExtractProjects(allPages)
+	if err != nil {
+		plugin.Logger(ctx).Error("error extracting projects", "error", err)
+		return nil, err
+	}
+	plugin.Logger(ctx).Debug("projects retrieved", "count", len(allProjects))
+
+	projectIDs = []string{}
+	for _, project := range allProjects {
+		projectIDs = append(projectIDs, project.ID)
+	}
+	return projectIDs, nil
+}
+
+// getInScope wraps a get hydrate function so that, when re-scoping, it is
+// tried with the token of each project in scope until the resource is found,
+// since the connection's own token cannot see the other projects' resources.
+func getInScope(get plugin.HydrateFunc) plugin.HydrateFunc {
+	return func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+		scope, err := getProjectScope(ctx, d)
+		if err != nil {
+			plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+			return nil, err
+		}
+		if !scope.Rescoped {
+			return get(ctx, d, h)
+		}
+		for _, projectID := range scope.Projects(d.EqualsQualString("project_id")) {
+			item, err := get(scope.Context(ctx, projectID), d, h)
+			if err != nil && !errors.As(err, &gophercloud.ErrDefault404{}) {
+				return nil, err
+			}
+			if err == nil && !helpers.IsNil(item) {
+				return item, nil
+			}
+		}
+		return nil, nil
+	}
+}
+
+// getAccessibleProjects returns the enabled projects the user has a role in.
+func getAccessibleProjects(ctx context.Context, d *plugin.QueryData) ([]projects.Project, error) {
+	client, err := getServiceClient(ctx, d, IdentityV3)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		return nil, err
+	}
+	allPages, err := projects.ListAvailable(client).AllPages()
+	if err != nil {
+		plugin.Logger(ctx).Error("error listing accessible projects", "error", err)
+		return nil, err
+	}
+	allProjects, err := projects.ExtractProjects(allPages)
+	if err != nil {
+		plugin.Logger(ctx).Error("error extracting accessible projects", "error", err)
+		return nil, err
+	}
+	enabled := []projects.Project{}
+	for _, project := range allProjects {
+		if project.Enabled {
+			enabled = append(enabled, project)
+		}
+	}
+	plugin.Logger(ctx).Debug("accessible projects retrieved", "count", len(allProjects), "enabled", len(enabled))
+	return enabled, nil
+}
+
+// getProjectClient returns a provider client using the connection's token
+// re-scoped to the given project; when the re-scoped token expires, it is
+// re-scoped again, after re-authenticating the connection if needed.
+func getProjectClient(ctx context.Context, d *plugin.QueryData, projectID string) (*gophercloud.ProviderClient, error) {
+
+	cacheKey := fmt.Sprintf("%s/%s", AuthenticatedClient, projectID)
+
+	// load connection from cache
+	if cachedData, ok := d.ConnectionManager.Cache.Get(cacheKey); ok {
+		plugin.Logger(ctx).Debug("returning the project client from cache", "project", projectID)
+		return cachedData.(*gophercloud.ProviderClient), nil
+	}
+
+	plugin.Logger(ctx).Info("re-scoping token to project", "project", projectID)
+
+	openstackConfig, err := getConnectionConfig(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving connection configuration", "error", err)
+		return nil, err
+	}
+	overrides, err := getEndpointOverrides(openstackConfig)
+	if err != nil {
+		plugin.Logger(ctx).Error("invalid endpoint overrides", "error", err)
+		return nil, err
+	}
+	api, err := getAuthenticatedClient(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("no valid authenticated provider client available", "error", err)
+		return nil, err
+	}
+
+	rescope := func() (*gophercloud.ProviderClient, error) {
+		client, err := rescopeClient(api, projectID)
+		if err != nil {
+			// the connection's token may have expired
+			plugin.Logger(ctx).Debug("error re-scoping token, re-authenticating", "project", projectID, "error", err)
+			if err := api.Reauthenticate(api.Token()); err != nil {
+				return nil, err
+			}
+			client, err = rescopeClient(api, projectID)
+		}
+		return client, err
+	}
+
+	client, err := rescope()
+	if err != nil {
+		plugin.Logger(ctx).Error("error re-scoping token", "project", projectID, "error", err)
+		return nil, err
+	}
+	client.ReauthFunc = func() error {
+		fresh, err := rescope()
+		if err != nil {
+			return err
+		}
+		client.CopyTokenFrom(fresh)
+		return nil
+	}
+	applyEndpointOverrides(ctx, client, overrides)
+
+	// save to cache
+	plugin.Logger(ctx).Debug("saving project client to cache", "project", projectID)
+	d.ConnectionManager.Cache.Set(cacheKey, client)
+
+	return client, nil
+}
+
+// rescopeClient returns a new provider client, sharing the HTTP client of the
+// given one, authenticated with its token re-scoped to the given project.
+func rescopeClient(api *gophercloud.ProviderClient, projectID string) (*gophercloud.ProviderClient, error) {
+	client, err := openstack.NewClient(api.IdentityEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	client.HTTPClient = api.HTTPClient
+	err = openstack.AuthenticateV3(client, &gophercloud.AuthOptions{
+		IdentityEndpoint: api.IdentityEndpoint,
+		TokenID:          api.Token(),
+		Scope:            &gophercloud.AuthScope{ProjectID: projectID},
+	}, gophercloud.EndpointOpts{})
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
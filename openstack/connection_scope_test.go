@@ -0,0 +1,409 @@
+package openstack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+)
+
+// OtherProjectID is the ID of a project other than the one the fake Keystone
+// scopes tokens to.
+const OtherProjectID = "a3b4c5d6e7f8091a2b3c4d5e6f708192"
+
+// AccessibleProjectID is the ID of an enabled project, other than the one the
+// fake Keystone scopes tokens to, among those the user has access to.
+const AccessibleProjectID = "b4c5d6e7f8091a2b3c4d5e6f70819203"
+
+// withoutAdminRights makes the fake Nova forbid the probe for admin rights and
+// the fake Keystone issue tokens with the member role only, scoped to the
+// requested project when re-scoping a token; it returns a function reporting
+// the projects tokens were re-scoped to.
+func withoutAdminRights(cloud *fakeOpenStack) func() []string {
+	cloud.fail("GET /compute/v2.1/servers", http.StatusForbidden)
+	var lock sync.Mutex
+	rescoped := []string{}
+	cloud.handle("POST /identity/v3/auth/tokens", func(w http.ResponseWriter, r *http.Request) {
+		request := struct {
+			Auth struct {
+				Identity struct {
+					Methods []string `json:"methods"`
+				} `json:"identity"`
+				Scope struct {
+					Project struct {
+						ID string `json:"id"`
+					} `json:"project"`
+				} `json:"scope"`
+			} `json:"auth"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			cloud.t.Fatal(err)
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", "fixtures", "identity", "auth_tokens.json"))
+		if err != nil {
+			cloud.t.Fatal(err)
+		}
+		body := map[string]map[string]any{}
+		if err := json.Unmarshal([]byte(strings.ReplaceAll(string(data), "{{endpoint}}", cloud.URL)), &body); err != nil {
+			cloud.t.Fatal(err)
+		}
+		body["token"]["roles"] = []any{map[string]any{"id": "6a9bb1a1e6d84e1c8f6f3b0a2c4d5e6f", "name": "member"}}
+		if len(request.Auth.Identity.Methods) == 1 && request.Auth.Identity.Methods[0] == "token" {
+			projectID := request.Auth.Scope.Project.ID
+			body["token"]["project"] = map[string]any{"id": projectID, "name": "", "domain": map[string]any{"id": "default", "name": "Default"}}
+			lock.Lock()
+			rescoped = append(rescoped, projectID)
+			lock.Unlock()
+		}
+		w.Header().Set("X-Subject-Token", TestToken)
+		writeJSON(w, http.StatusCreated, body)
+	})
+	return func() []string {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]string{}, rescoped...)
+	}
+}
+
+// withRole makes the fake Keystone issue tokens with the given role only.
+func withRole(cloud *fakeOpenStack, role string) {
+	cloud.handle("POST /identity/v3/auth/tokens", func(w http.ResponseWriter, r *http.Request) {
+		data, err := os.ReadFile(filepath.Join("testdata", "fixtures", "identity", "auth_tokens.json"))
+		if err != nil {
+			cloud.t.Fatal(err)
+		}
+		body := map[string]map[string]any{}
+		if err := json.Unmarshal([]byte(strings.ReplaceAll(string(data), "{{endpoint}}", cloud.URL)), &body); err != nil {
+			cloud.t.Fatal(err)
+		}
+		body["token"]["roles"] = []any{map[string]any{"id": "6a9bb1a1e6d84e1c8f6f3b0a2c4d5e6f", "name": role}}
+		w.Header().Set("X-Subject-Token", TestToken)
+		writeJSON(w, http.StatusCreated, body)
+	})
+}
+
+func TestParseScope(t *testing.T) {
+	var tests = []struct {
+		value    *string
+		expected []string
+	}{
+		{value: nil, expected: nil},
+		{value: utils.PointerTo(""), expected: nil},
+		{value: utils.PointerTo("all_projects"), expected: nil},
+		{value: utils.PointerTo(" Current_Project "), expected: []string{ScopeCurrentProject}},
+		{value: utils.PointerTo("accessible_projects"), expected: []string{ScopeAccessibleProjects}},
+		{value: utils.PointerTo(TestProjectID), expected: []string{TestProjectID}},
+		{value: utils.PointerTo(TestProjectID + ", " + OtherProjectID + ","), expected: []string{TestProjectID, OtherProjectID}},
+	}
+
+	for _, test := range tests {
+		actual := parseScope(&openstackConfig{Scope: test.value})
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%v: expected %v, got %v", utils.ToPrettyJSON(test.value), test.expected, actual)
+		}
+	}
+}
+
+func TestListOpenStackInstanceScope(t *testing.T) {
+	var tests = []struct {
+		name       string
+		scope      *string
+		admin      bool
+		projectID  string
+		allTenants []string
+		tenantIDs  []string
+		rescoped   []string
+		rows       []any
+	}{
+		{
+			name:       "admin, all projects",
+			admin:      true,
+			allTenants: []string{"true"},
+			tenantIDs:  []string{""},
+			rows:       []any{"web-01", "db-01"},
+		},
+		{
+			name:      "admin, current project",
+			scope:     utils.PointerTo("current_project"),
+			admin:     true,
+			tenantIDs: []string{TestProjectID},
+			rows:      []any{"web-01", "db-01"},
+		},
+		{
+			name:       "admin, list of projects",
+			scope:      utils.PointerTo(TestProjectID + "," + OtherProjectID),
+			admin:      true,
+			allTenants: []string{"true", "true"},
+			tenantIDs:  []string{TestProjectID, OtherProjectID},
+			rows:       []any{"web-01", "db-01", "web-01", "db-01"},
+		},
+		{
+			name:       "admin, list of projects and project_id qual",
+			scope:      utils.PointerTo(TestProjectID + "," + OtherProjectID),
+			admin:      true,
+			projectID:  OtherProjectID,
+			allTenants: []string{"true"},
+			tenantIDs:  []string{OtherProjectID},
+			rows:       []any{"web-01", "db-01"},
+		},
+		{
+			name:      "admin, project_id qual out of scope",
+			scope:     utils.PointerTo(TestProjectID),
+			admin:     true,
+			projectID: OtherProjectID,
+		},
+		{
+			name:      "member, all projects",
+			tenantIDs: []string{""},
+			rows:      []any{"web-01", "db-01"},
+		},
+		{
+			name:      "member, list of projects",
+			scope:     utils.PointerTo(TestProjectID + "," + OtherProjectID),
+			tenantIDs: []string{TestProjectID},
+			rows:      []any{"web-01", "db-01"},
+		},
+		{
+			name:      "member, list of accessible projects",
+			scope:     utils.PointerTo(TestProjectID + "," + AccessibleProjectID),
+			tenantIDs: []string{TestProjectID, AccessibleProjectID},
+			rescoped:  []string{AccessibleProjectID},
+			rows:      []any{"web-01", "db-01", "web-01", "db-01"},
+		},
+		{
+			name:  "member, other projects",
+			scope: utils.PointerTo(OtherProjectID),
+		},
+		{
+			name:      "member, accessible projects",
+			scope:     utils.PointerTo("accessible_projects"),
+			tenantIDs: []string{TestProjectID, AccessibleProjectID},
+			rescoped:  []string{AccessibleProjectID},
+			rows:      []any{"web-01", "db-01", "web-01", "db-01"},
+		},
+		{
+			name:      "member, accessible projects and project_id qual",
+			scope:     utils.PointerTo("accessible_projects"),
+			projectID: AccessibleProjectID,
+			tenantIDs: []string{AccessibleProjectID},
+			rescoped:  []string{AccessibleProjectID},
+			rows:      []any{"web-01", "db-01"},
+		},
+	}
+
+	for _, test := range tests {
+		cloud := newFakeOpenStack(t)
+		cloud.config.Scope = test.scope
+		rescoped := func() []string { return nil }
+		if !test.admin {
+			rescoped = withoutAdminRights(cloud)
+		}
+		query := testQuery{}
+		if test.projectID != "" {
+			query.quals = map[string]any{"project_id": test.projectID}
+		}
+
+		rows, err := cloud.list(tableOpenStackInstance(context.Background()), query)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		names := []any{}
+		for _, row := range rows {
+			names = append(names, row["name"])
+		}
+		if len(names) != len(test.rows) {
+			t.Errorf("%s: expected rows %v, got %v", test.name, test.rows, names)
+		}
+		allTenants, tenantIDs := []string{}, []string{}
+		for _, request := range cloud.received("/compute/v2.1/servers/detail") {
+			if value := request.Query.Get("all_tenants"); value != "" {
+				allTenants = append(allTenants, value)
+			}
+			tenantIDs = append(tenantIDs, request.Query.Get("tenant_id"))
+		}
+		if len(test.allTenants) == 0 {
+			test.allTenants = []string{}
+		}
+		if len(test.tenantIDs) == 0 {
+			test.tenantIDs = []string{}
+		}
+		if !reflect.DeepEqual(allTenants, test.allTenants) {
+			t.Errorf("%s: expected all_tenants %v, got %v", test.name, test.allTenants, allTenants)
+		}
+		// projects are listed in parallel
+		if !sameElements(tenantIDs, test.tenantIDs) {
+			t.Errorf("%s: expected tenant_id %v, got %v", test.name, test.tenantIDs, tenantIDs)
+		}
+		// tokens are re-scoped once per project, and only for those other
+		// than the current one
+		if actual := rescoped(); !sameElements(actual, test.rescoped) {
+			t.Errorf("%s: expected tokens re-scoped to %v, got %v", test.name, test.rescoped, actual)
+		}
+	}
+}
+
+func TestListOpenStackInstanceAdminProbe(t *testing.T) {
+	var tests = []struct {
+		name       string
+		role       string
+		status     int
+		allTenants []string
+	}{
+		{name: "custom admin role", role: "cloud_admin", allTenants: []string{"true"}},
+		{name: "admin role not honoured by policy", role: "admin", status: http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		cloud := newFakeOpenStack(t)
+		withRole(cloud, test.role)
+		if test.status != 0 {
+			cloud.fail("GET /compute/v2.1/servers", test.status)
+		}
+		// the rights are probed only once per connection
+		for i := 0; i < 2; i++ {
+			if _, err := cloud.list(tableOpenStackInstance(context.Background()), testQuery{}); err != nil {
+				t.Fatalf("%s: unexpected error: %v", test.name, err)
+			}
+		}
+		probes := cloud.received("/compute/v2.1/servers")
+		if len(probes) != 1 {
+			t.Fatalf("%s: expected one probe, got %d", test.name, len(probes))
+		}
+		if probes[0].Query.Get("all_tenants") == "" || probes[0].Query.Get("limit") != "1" {
+			t.Errorf("%s: unexpected probe %v", test.name, probes[0].Query)
+		}
+		cloud.assertQuery("/compute/v2.1/servers/detail", url.Values{"all_tenants": test.allTenants})
+	}
+
+	// errors other than 403 are not mistaken for the lack of admin rights
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /compute/v2.1/servers", http.StatusInternalServerError)
+	if _, err := cloud.list(tableOpenStackInstance(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGetOpenStackInstanceScope(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.config.Scope = utils.PointerTo(OtherProjectID)
+
+	// the instance belongs to the current project, which is not in scope
+	row, err := cloud.get(tableOpenStackInstance(context.Background()), testQuery{quals: map[string]any{"id": "9168b536-cd40-4630-b43f-b259807c6e87"}})
+	if err != nil || row != nil {
+		t.Errorf("expected no row and no error, got %v (error: %v)", row, err)
+	}
+}
+
+func TestListOpenStackInstanceProjectName(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.config.Scope = utils.PointerTo("accessible_projects")
+	withoutAdminRights(cloud)
+	// Nova returns the instances of the project the token is scoped to
+	cloud.handle("GET /compute/v2.1/servers/detail", func(w http.ResponseWriter, r *http.Request) {
+		data, err := os.ReadFile(filepath.Join("testdata", "fixtures", "compute", "servers_detail.json"))
+		if err != nil {
+			cloud.t.Fatal(err)
+		}
+		body := map[string]any{}
+		if err := json.Unmarshal(data, &body); err != nil {
+			cloud.t.Fatal(err)
+		}
+		for _, server := range body["servers"].([]any) {
+			server.(map[string]any)["tenant_id"] = r.URL.Query().Get("tenant_id")
+		}
+		writeJSON(w, http.StatusOK, body)
+	})
+
+	rows, err := cloud.list(tableOpenStackInstance(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[any]any{}
+	for _, row := range rows {
+		names[row["project_id"]] = row["project_name"]
+	}
+	expected := map[any]any{TestProjectID: "admin", AccessibleProjectID: "web"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected project names %v, got %v", expected, names)
+	}
+}
+
+func TestGetOpenStackInstanceRescoped(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.config.Scope = utils.PointerTo("accessible_projects")
+	rescoped := withoutAdminRights(cloud)
+	// the instance is not visible to the current project, only to the next
+	// one in scope
+	path := "/compute/v2.1/servers/9168b536-cd40-4630-b43f-b259807c6e87"
+	var lock sync.Mutex
+	calls := 0
+	cloud.handle("GET "+path, func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		calls++
+		first := calls == 1
+		lock.Unlock()
+		if first {
+			writeJSON(w, http.StatusNotFound, map[string]any{
+				"itemNotFound": map[string]any{"code": http.StatusNotFound, "message": "Instance could not be found."},
+			})
+			return
+		}
+		cloud.serveFixture(w, http.StatusOK, fixturePath(path))
+	})
+
+	row, err := cloud.get(tableOpenStackInstance(context.Background()), testQuery{quals: map[string]any{"id": "9168b536-cd40-4630-b43f-b259807c6e87"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row == nil || row["name"] != "web-01" {
+		t.Errorf("expected web-01, got %v", row)
+	}
+	if actual := rescoped(); !reflect.DeepEqual(actual, []string{AccessibleProjectID}) {
+		t.Errorf("expected token re-scoped to %v, got %v", AccessibleProjectID, actual)
+	}
+}
+
+func TestListOpenStackAttachmentScope(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	withoutAdminRights(cloud)
+
+	if _, err := cloud.list(tableOpenStackAttachment(context.Background()), testQuery{}); err != nil {
+		t.Fatal(err)
+	}
+	// members cannot list projects, nor see the attachments of other projects
+	if requests := cloud.received("/identity/v3/projects"); len(requests) != 0 {
+		t.Errorf("expected no requests for projects, got %d", len(requests))
+	}
+	requests := cloud.received("/volumev3/v3/attachments/detail")
+	if len(requests) != 1 || requests[0].Query.Get("project_id") != TestProjectID || requests[0].Query.Get("all_tenants") != "" {
+		t.Errorf("expected one request for the current project, got %v", requests)
+	}
+}
+
+// sameElements returns whether the two slices have the same elements, in any
+// order.
+func sameElements(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := map[string]int{}
+	for _, item := range a {
+		counts[item]++
+	}
+	for _, item := range b {
+		counts[item]--
+		if counts[item] < 0 {
+			return false
+		}
+	}
+	return true
+}
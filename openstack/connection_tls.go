@@ -0,0 +1,88 @@
+package openstack
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// newHTTPClient returns the HTTP client to be used by the ProviderClient,
+// configured according to the TLS settings in the connection configuration:
+// a custom CA bundle (cacert), a client certificate and key (cert and key)
+// and whether server certificates should be verified at all (insecure).
+func newHTTPClient(config *openstackConfig) (*http.Client, error) {
+	tlsConfig := &tls.Config{}
+
+	if config.CACert != nil {
+		path := expandHome(*config.CACert)
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA bundle: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid PEM certificates in CA bundle %s", path)
+		}
+	}
+
+	if config.Cert != nil || config.Key != nil {
+		if config.Cert == nil || config.Key == nil {
+			return nil, errors.New("both cert and key must be provided for client certificate authentication")
+		}
+		certificate, err := tls.LoadX509KeyPair(expandHome(*config.Cert), expandHome(*config.Key))
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	if config.Insecure != nil {
+		tlsConfig.InsecureSkipVerify = *config.Insecure
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{
+		Transport: &tlsErrorTransport{next: transport},
+	}, nil
+}
+
+// tlsErrorTransport turns certificate verification failures into errors that
+// explain how to fix the connection configuration, instead of the terse
+// messages coming from the crypto/x509 package.
+type tlsErrorTransport struct {
+	next http.RoundTripper
+}
+
+func (t *tlsErrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	response, err := t.next.RoundTrip(req)
+	if err != nil {
+		return response, explainTLSError(req.URL.Host, err)
+	}
+	return response, nil
+}
+
+// ErrTLSVerification is wrapped by all errors due to the server certificate
+// failing verification.
+var ErrTLSVerification = errors.New("TLS certificate verification failed")
+
+func explainTLSError(host string, err error) error {
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		hostname         x509.HostnameError
+		invalid          x509.CertificateInvalidError
+	)
+	switch {
+	case errors.As(err, &unknownAuthority):
+		return fmt.Errorf("%w for %s: the certificate is signed by an unknown authority; set cacert to the CA bundle that issued it, or insecure = true to skip verification (not recommended): %v", ErrTLSVerification, host, err)
+	case errors.As(err, &hostname):
+		return fmt.Errorf("%w for %s: the certificate is not valid for this host name; check the endpoint URL, or set insecure = true to skip verification (not recommended): %v", ErrTLSVerification, host, err)
+	case errors.As(err, &invalid):
+		return fmt.Errorf("%w for %s: the certificate is invalid (e.g. expired or not yet valid): %v", ErrTLSVerification, host, err)
+	}
+	return err
+}
@@ -0,0 +1,164 @@
+package openstack
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+)
+
+// writePEM writes a PEM block of the given type to a new file in dir.
+func writePEM(t *testing.T, dir string, name string, blockType string, der []byte) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewHTTPClientServerVerification(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cacert := writePEM(t, t.TempDir(), "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+
+	var tests = []struct {
+		name    string
+		config  openstackConfig
+		invalid bool
+	}{
+		{
+			name:    "default trust store",
+			config:  openstackConfig{},
+			invalid: true,
+		},
+		{
+			name:   "custom CA bundle",
+			config: openstackConfig{CACert: utils.PointerTo(cacert)},
+		},
+		{
+			name:   "insecure",
+			config: openstackConfig{Insecure: utils.PointerTo(true)},
+		},
+	}
+
+	for _, test := range tests {
+		client, err := newHTTPClient(&test.config)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		response, err := client.Get(server.URL)
+		if test.invalid {
+			if !errors.Is(err, ErrTLSVerification) {
+				t.Errorf("%s: expected certificate verification error, got %v", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		response.Body.Close()
+	}
+}
+
+func TestNewHTTPClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+
+	// generate a self-signed client certificate
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "steampipe"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := writePEM(t, dir, "client.pem", "CERTIFICATE", der)
+	keyFile := writePEM(t, dir, "client-key.pem", "EC PRIVATE KEY", keyDER)
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(certificate)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	// without a client certificate the handshake fails
+	client, err := newHTTPClient(&openstackConfig{Insecure: utils.PointerTo(true)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response, err := client.Get(server.URL); err == nil {
+		response.Body.Close()
+		t.Errorf("expected handshake failure without client certificate")
+	}
+
+	client, err = newHTTPClient(&openstackConfig{
+		Insecure: utils.PointerTo(true),
+		Cert:     utils.PointerTo(cert),
+		Key:      utils.PointerTo(keyFile),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error with client certificate: %v", err)
+	}
+	response.Body.Close()
+
+	// cert and key must be provided together
+	if _, err := newHTTPClient(&openstackConfig{Cert: utils.PointerTo(cert)}); err == nil {
+		t.Errorf("expected error with cert but no key")
+	}
+}
+
+func TestNewHTTPClientInvalidBundle(t *testing.T) {
+	bundle := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(bundle, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newHTTPClient(&openstackConfig{CACert: utils.PointerTo(bundle)}); err == nil {
+		t.Errorf("expected error with invalid CA bundle")
+	}
+	if _, err := newHTTPClient(&openstackConfig{CACert: utils.PointerTo(bundle + ".missing")}); err == nil {
+		t.Errorf("expected error with missing CA bundle")
+	}
+}
@@ -0,0 +1,622 @@
+package openstack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/eko/gocache/v3/cache"
+	"github.com/eko/gocache/v3/store"
+	"github.com/hashicorp/go-hclog"
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/go-kit/types"
+	"github.com/turbot/steampipe-plugin-sdk/v5/connection"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/context_key"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/quals"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// This file contains a test harness that runs the plugin's tables offline,
+// against a fake OpenStack cloud (Keystone, Nova, Neutron, Cinder, Glance and
+// Octavia) served by an httptest server out of the JSON fixtures under
+// testdata/fixtures. The SDK does not expose a way to execute a query outside
+// of the plugin server, so the harness builds the QueryData itself, calls the
+// table's list and get hydrates and applies the column transforms the same
+// way the SDK would, returning one map of column values per row.
+
+const (
+	// TestProjectID is the ID of the project the fake Keystone scopes tokens to.
+	TestProjectID = "f1c0a1a7e1e14b4a9d2e0c7d5e3b6a01"
+	// TestUserID is the ID of the user the fake Keystone issues tokens to.
+	TestUserID = "5d3c1a2b0e5f4e8b9c6a7d8e9f0a1b2c"
+	// TestToken is the token issued by the fake Keystone.
+	TestToken = "gAAAAABtesttoken"
+)
+
+// fixtureVersion matches the version segment in the fake endpoints' paths,
+// which is not part of the fixture file names.
+var fixtureVersion = regexp.MustCompile(`^v[0-9]+(\.[0-9]+)?$`)
+
+// fakeRequest is a request received by the fake cloud.
+type fakeRequest struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+}
+
+// fakeOpenStack is a fake OpenStack cloud; requests are answered by handlers
+// registered for the specific method and path if any, or by the fixture file
+// corresponding to the path otherwise, e.g. GET /network/v2.0/ports is served
+// from testdata/fixtures/network/ports.json; missing fixtures result in 404.
+type fakeOpenStack struct {
+	*httptest.Server
+	t        *testing.T
+	lock     sync.Mutex
+	requests []fakeRequest
+	handlers map[string]http.HandlerFunc
+	cache    *connection.Manager
+	config   openstackConfig
+}
+
+// newFakeOpenStack starts a fake cloud and returns it, along with a connection
+// configuration pointing to it; the server is shut down when the test ends.
+func newFakeOpenStack(t *testing.T) *fakeOpenStack {
+	t.Helper()
+
+	// make sure the environment of the machine running the tests is not used
+	for _, variable := range []string{"OS_AUTH_URL", "OS_CLOUD", "OS_USERNAME", "OS_PASSWORD", "OS_TOKEN"} {
+		t.Setenv(variable, "")
+	}
+
+	f := &fakeOpenStack{
+		t:        t,
+		handlers: map[string]http.HandlerFunc{},
+		cache:    connection.NewManager(connection.NewConnectionCache("openstack", cache.New[any](newMapStore()))),
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Server.Close)
+
+	f.config = openstackConfig{
+		EndpointUrl:           utils.PointerTo(f.URL + "/identity/v3"),
+		Username:              utils.PointerTo("admin"),
+		Password:              utils.PointerTo("secret"),
+		ProjectName:           utils.PointerTo("admin"),
+		DomainName:            utils.PointerTo("Default"),
+		Region:                utils.PointerTo("RegionOne"),
+		MaxErrorRetryAttempts: utils.PointerTo(0),
+	}
+	return f
+}
+
+// handle registers a handler for requests with the given method and path,
+// e.g. "GET /compute/v2.1/servers/detail", overriding the fixtures.
+func (f *fakeOpenStack) handle(pattern string, handler http.HandlerFunc) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.handlers[pattern] = handler
+}
+
+// fail makes requests with the given method and path fail with the given
+// status code and an OpenStack-like error message.
+func (f *fakeOpenStack) fail(pattern string, status int) {
+	f.handle(pattern, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, status, map[string]any{
+			"error": map[string]any{"code": status, "message": http.StatusText(status)},
+		})
+	})
+}
+
+// withoutService makes the fake Keystone issue tokens whose catalog lacks the
+// service of the given type, e.g. "load-balancer".
+func (f *fakeOpenStack) withoutService(serviceType string) {
+	f.handle("POST /identity/v3/auth/tokens", func(w http.ResponseWriter, r *http.Request) {
+		data, err := os.ReadFile(filepath.Join("testdata", "fixtures", "identity", "auth_tokens.json"))
+		if err != nil {
+			f.t.Fatal(err)
+		}
+		body := map[string]map[string]any{}
+		if err := json.Unmarshal(bytes.ReplaceAll(data, []byte("{{endpoint}}"), []byte(f.URL)), &body); err != nil {
+			f.t.Fatal(err)
+		}
+		catalog := []any{}
+		for _, service := range body["token"]["catalog"].([]any) {
+			if service.(map[string]any)["type"] != serviceType {
+				catalog = append(catalog, service)
+			}
+		}
+		body["token"]["catalog"] = catalog
+		w.Header().Set("X-Subject-Token", TestToken)
+		writeJSON(w, http.StatusCreated, body)
+	})
+}
+
+// received returns the requests received on the given path.
+func (f *fakeOpenStack) received(path string) []fakeRequest {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	result := []fakeRequest{}
+	for _, request := range f.requests {
+		if request.Path == path {
+			result = append(result, request)
+		}
+	}
+	return result
+}
+
+func (f *fakeOpenStack) serve(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	f.requests = append(f.requests, fakeRequest{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query(), Header: r.Header.Clone()})
+	handler, ok := f.handlers[r.Method+" "+r.URL.Path]
+	f.lock.Unlock()
+
+	if ok {
+		handler(w, r)
+		return
+	}
+
+	if r.Method == http.MethodPost && r.URL.Path == "/identity/v3/auth/tokens" {
+		w.Header().Set("X-Subject-Token", TestToken)
+		f.serveFixture(w, http.StatusCreated, "identity/auth_tokens.json")
+		return
+	}
+	if r.Header.Get("X-Auth-Token") != TestToken {
+		writeJSON(w, http.StatusUnauthorized, map[string]any{
+			"error": map[string]any{"code": http.StatusUnauthorized, "message": "The request you have made requires authentication."},
+		})
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{})
+		return
+	}
+
+	f.serveFixture(w, http.StatusOK, fixturePath(r.URL.Path))
+}
+
+// fixturePath returns the fixture file for the given path, relative to
+// testdata/fixtures: the first segment is the service, the version segment
+// is dropped and the remaining ones are joined with underscores.
+func fixturePath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	service, segments := segments[0], segments[1:]
+	if len(segments) > 0 && fixtureVersion.MatchString(segments[0]) {
+		segments = segments[1:]
+	}
+	return filepath.Join(service, strings.Join(segments, "_")+".json")
+}
+
+// serveFixture writes the given fixture, replacing the {{endpoint}} placeholder
+// with the URL of the fake cloud.
+func (f *fakeOpenStack) serveFixture(w http.ResponseWriter, status int, name string) {
+	data, err := os.ReadFile(filepath.Join("testdata", "fixtures", name))
+	if errors.Is(err, os.ErrNotExist) {
+		writeJSON(w, http.StatusNotFound, map[string]any{
+			"itemNotFound": map[string]any{"code": http.StatusNotFound, "message": "Resource could not be found."},
+		})
+		return
+	} else if err != nil {
+		f.t.Errorf("error reading fixture %s: %v", name, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(bytes.ReplaceAll(data, []byte("{{endpoint}}"), []byte(f.URL)))
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// testQuery describes a query against a table: the quals on its key columns
+// (strings, booleans, integers or times) and the optional SQL LIMIT; quals are
+// keyed by column name for equality, or by column name and operator otherwise,
+// e.g. "updated_at >=".
+type testQuery struct {
+	quals map[string]any
+	limit int64
+}
+
+// testRow is a row returned by a table, as a map of column names to values;
+// values are converted according to the column type as the SDK would do, so
+// they are strings, booleans, int64s, float64s, JSON strings or nil.
+type testRow map[string]any
+
+// list runs the table's list hydrate in every matrix item (i.e. region) and
+// returns the resulting rows.
+func (f *fakeOpenStack) list(table *plugin.Table, query testQuery) ([]testRow, error) {
+	f.t.Helper()
+	f.checkKeyColumns(table, table.List.KeyColumns, query)
+	ctx := newTestContext()
+
+	rows := []testRow{}
+	for _, matrixItem := range f.matrix(ctx, table) {
+		ctx := context.WithValue(ctx, context_key.MatrixItem, matrixItem)
+		d, streamed := f.newQueryData(table, query, matrixItem)
+		items := []any{}
+		var lock sync.Mutex
+		d.StreamListItem = func(ctx context.Context, values ...any) {
+			lock.Lock()
+			defer lock.Unlock()
+			items = append(items, values...)
+			atomic.AddInt64(streamed, int64(len(values)))
+		}
+		if _, err := table.List.Hydrate(ctx, d, &plugin.HydrateData{}); err != nil {
+			return rows, err
+		}
+		for _, item := range items {
+			rows = append(rows, f.row(ctx, table, d, item))
+		}
+	}
+	// the SDK stops streaming rows once the limit is reached
+	if query.limit > 0 && int64(len(rows)) > query.limit {
+		rows = rows[:query.limit]
+	}
+	return rows, nil
+}
+
+// get runs the table's get hydrate in the first matrix item (i.e. region) and
+// returns the resulting row, or nil if the item was not found.
+func (f *fakeOpenStack) get(table *plugin.Table, query testQuery) (testRow, error) {
+	f.t.Helper()
+	f.checkKeyColumns(table, table.Get.KeyColumns, query)
+	ctx := newTestContext()
+
+	matrix := f.matrix(ctx, table)
+	if len(matrix) == 0 {
+		f.t.Fatalf("%s: no matrix items", table.Name)
+	}
+	ctx = context.WithValue(ctx, context_key.MatrixItem, matrix[0])
+	d, _ := f.newQueryData(table, query, matrix[0])
+	item, err := table.Get.Hydrate(ctx, d, &plugin.HydrateData{})
+	if err != nil {
+		// errors such as "not found" are ignored by the SDK, like in the plugin
+		if Plugin(ctx).DefaultIgnoreConfig.ShouldIgnoreErrorFunc(ctx, d, nil, err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if helpers.IsNil(item) {
+		return nil, nil
+	}
+	return f.row(ctx, table, d, item), nil
+}
+
+// checkKeyColumns fails the test if the query has quals on columns that are
+// not key columns, since the SDK would not pass them to the hydrate.
+func (f *fakeOpenStack) checkKeyColumns(table *plugin.Table, keyColumns plugin.KeyColumnSlice, query testQuery) {
+	f.t.Helper()
+	names := []string{}
+	for _, column := range keyColumns {
+		names = append(names, column.Name)
+	}
+	for key := range query.quals {
+		name, operator := splitQual(key)
+		if !helpers.StringSliceContains(names, name) {
+			f.t.Fatalf("%s: %q is not a key column", table.Name, name)
+		}
+		for _, column := range keyColumns {
+			operators := column.Operators
+			if len(operators) == 0 {
+				operators = []string{"="}
+			}
+			if column.Name == name && !helpers.StringSliceContains(operators, operator) {
+				f.t.Fatalf("%s: operator %q is not supported on key column %q", table.Name, operator, name)
+			}
+		}
+	}
+}
+
+// splitQual splits a qual key (e.g. "updated_at >=") into the column name and
+// the operator, which defaults to "=".
+func splitQual(key string) (string, string) {
+	if name, operator, ok := strings.Cut(key, " "); ok {
+		return name, operator
+	}
+	return key, "="
+}
+
+// matrix returns the table's matrix items, or a single empty one if the table
+// has no matrix.
+func (f *fakeOpenStack) matrix(ctx context.Context, table *plugin.Table) []map[string]any {
+	if table.GetMatrixItemFunc == nil {
+		return []map[string]any{{}}
+	}
+	d, _ := f.newQueryData(table, testQuery{}, nil)
+	return table.GetMatrixItemFunc(ctx, d)
+}
+
+// newQueryData returns the QueryData for a query in the given matrix item,
+// along with its counter of streamed rows.
+func (f *fakeOpenStack) newQueryData(table *plugin.Table, query testQuery, matrixItem map[string]any) (*plugin.QueryData, *int64) {
+	equalsQuals := plugin.KeyColumnEqualsQualMap{}
+	allQuals := plugin.KeyColumnQualMap{}
+	add := func(key string, value any) {
+		name, operator := splitQual(key)
+		qual := &quals.Qual{Column: name, Operator: operator, Value: toQualValue(f.t, value)}
+		if operator == "=" {
+			equalsQuals[name] = qual.Value
+		}
+		if allQuals[name] == nil {
+			allQuals[name] = &plugin.KeyColumnQuals{Name: name}
+		}
+		allQuals[name].Quals = append(allQuals[name].Quals, qual)
+	}
+	for key, value := range query.quals {
+		add(key, value)
+	}
+	for name, value := range matrixItem {
+		add(name, value)
+	}
+
+	d := &plugin.QueryData{
+		Table:             table,
+		EqualsQuals:       equalsQuals,
+		Quals:             allQuals,
+		QueryContext:      &plugin.QueryContext{},
+		Connection:        &plugin.Connection{Name: "openstack", Config: f.config},
+		ConnectionManager: f.cache,
+		StreamListItem:    func(context.Context, ...any) {},
+	}
+	if query.limit > 0 {
+		d.QueryContext.Limit = &query.limit
+	}
+	return d, setQueryStatus(d)
+}
+
+// setQueryStatus sets up the query status that QueryData.RowsRemaining relies
+// on, like the SDK does when it runs a query: the SDK only does so for queries
+// served over gRPC, hence the reflection. It returns the counter of streamed
+// rows, which the harness updates as rows are streamed.
+func setQueryStatus(d *plugin.QueryData) *int64 {
+	field := reflect.ValueOf(d).Elem().FieldByName("queryStatus")
+	status := reflect.New(field.Type().Elem())
+	required := int64(math.MaxInt32)
+	if d.QueryContext.Limit != nil {
+		required = *d.QueryContext.Limit
+	}
+	settable(status.Elem().FieldByName("rowsRequired")).SetInt(required)
+	settable(field).Set(status)
+	return settable(status.Elem().FieldByName("rowsStreamed")).Addr().Interface().(*int64)
+}
+
+// settable returns a settable version of an unexported struct field.
+func settable(field reflect.Value) reflect.Value {
+	return reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
+}
+
+func toQualValue(t *testing.T, value any) *proto.QualValue {
+	switch value := value.(type) {
+	case string:
+		return &proto.QualValue{Value: &proto.QualValue_StringValue{StringValue: value}}
+	case bool:
+		return &proto.QualValue{Value: &proto.QualValue_BoolValue{BoolValue: value}}
+	case int:
+		return &proto.QualValue{Value: &proto.QualValue_Int64Value{Int64Value: int64(value)}}
+	case int64:
+		return &proto.QualValue{Value: &proto.QualValue_Int64Value{Int64Value: value}}
+	case time.Time:
+		return &proto.QualValue{Value: &proto.QualValue_TimestampValue{TimestampValue: timestamppb.New(value)}}
+	case net.IP:
+		// Postgres passes addresses as single-host networks
+		inet := &proto.Inet{Addr: value.String(), Mask: 32, ProtocolVersion: "IPv4"}
+		if value.To4() == nil {
+			inet.Mask, inet.ProtocolVersion = 128, "IPv6"
+		}
+		inet.Cidr = fmt.Sprintf("%s/%d", inet.Addr, inet.Mask)
+		return &proto.QualValue{Value: &proto.QualValue_InetValue{InetValue: inet}}
+	}
+	t.Fatalf("unsupported qual value type %T", value)
+	return nil
+}
+
+// row applies the column transforms to the given item (the matrix item is
+// taken from the context, as in the SDK) and converts the
+// resulting values according to the column types; columns with their own
+// hydrate functions are hydrated first.
+func (f *fakeOpenStack) row(ctx context.Context, table *plugin.Table, d *plugin.QueryData, item any) testRow {
+	f.t.Helper()
+	row := testRow{}
+	for _, column := range table.Columns {
+		hydrateItem := item
+		if column.Hydrate != nil {
+			var err error
+			if hydrateItem, err = column.Hydrate(ctx, d, &plugin.HydrateData{Item: item}); err != nil {
+				f.t.Fatalf("%s: error hydrating column %q: %v", table.Name, column.Name, err)
+			}
+		}
+		transforms := column.Transform
+		if transforms == nil {
+			transforms = table.DefaultTransform
+		}
+		if transforms == nil {
+			transforms = Plugin(ctx).DefaultTransform
+		}
+		var value any
+		if !helpers.IsNil(hydrateItem) {
+			var err error
+			value, err = transforms.Execute(ctx, &transform.TransformData{
+				HydrateItem:    hydrateItem,
+				ColumnName:     column.Name,
+				KeyColumnQuals: d.Quals.ToQualMap(),
+			})
+			if err != nil {
+				f.t.Fatalf("%s: error transforming column %q: %v", table.Name, column.Name, err)
+			}
+		}
+		converted, err := toColumnValue(column, value)
+		if err != nil {
+			f.t.Fatalf("%s: error converting column %q: %v", table.Name, column.Name, err)
+		}
+		row[column.Name] = converted
+	}
+	return row
+}
+
+// toColumnValue converts the value according to the column type, like the SDK
+// does before sending it to Postgres.
+func toColumnValue(column *plugin.Column, value any) (any, error) {
+	value = helpers.DereferencePointer(value)
+	if value == nil {
+		return nil, nil
+	}
+	switch column.Type {
+	case proto.ColumnType_STRING:
+		return types.ToString(value), nil
+	case proto.ColumnType_BOOL:
+		return types.ToBool(value)
+	case proto.ColumnType_INT:
+		return types.ToInt64(value)
+	case proto.ColumnType_DOUBLE:
+		return types.ToFloat64(value)
+	case proto.ColumnType_JSON:
+		if value, ok := value.(string); ok {
+			return value, nil
+		}
+		data, err := json.Marshal(value)
+		return string(data), err
+	case proto.ColumnType_DATETIME, proto.ColumnType_TIMESTAMP:
+		value, err := types.ToTime(value)
+		if err != nil {
+			return nil, err
+		}
+		return value.UTC().Format(time.RFC3339), nil
+	case proto.ColumnType_IPADDR, proto.ColumnType_CIDR, proto.ColumnType_INET:
+		if value := types.SafeString(value); value != "" {
+			return value, nil
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported column type %v", column.Type)
+}
+
+// newTestContext returns a context carrying the (null) logger expected by the
+// plugin.
+func newTestContext() context.Context {
+	return context.WithValue(context.Background(), context_key.Logger, hclog.NewNullLogger())
+}
+
+// mapStore is an in-memory store for the connection cache which, unlike the
+// Ristretto-backed one used by the SDK, makes values available immediately,
+// so that tests are deterministic.
+type mapStore struct {
+	lock   sync.Mutex
+	values map[any]any
+}
+
+func newMapStore() *mapStore {
+	return &mapStore{values: map[any]any{}}
+}
+
+func (s *mapStore) Get(_ context.Context, key any) (any, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if value, ok := s.values[key]; ok {
+		return value, nil
+	}
+	return nil, store.NotFoundWithCause(errors.New("value not found in store"))
+}
+
+func (s *mapStore) GetWithTTL(ctx context.Context, key any) (any, time.Duration, error) {
+	value, err := s.Get(ctx, key)
+	return value, 0, err
+}
+
+func (s *mapStore) Set(_ context.Context, key any, value any, _ ...store.Option) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.values[key] = value
+	return nil
+}
+
+func (s *mapStore) Delete(_ context.Context, key any) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.values, key)
+	return nil
+}
+
+func (s *mapStore) Invalidate(context.Context, ...store.InvalidateOption) error {
+	return nil
+}
+
+func (s *mapStore) Clear(context.Context) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.values = map[any]any{}
+	return nil
+}
+
+func (s *mapStore) GetType() string {
+	return "map"
+}
+
+// assertRows checks that the rows have the expected values in the given column,
+// in order.
+func assertRows(t *testing.T, rows []testRow, column string, expected ...any) {
+	t.Helper()
+	actual := make([]any, 0, len(rows))
+	for _, row := range rows {
+		actual = append(actual, row[column])
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %s %v, got %v", column, expected, actual)
+	}
+}
+
+// assertRow checks the values of the given columns in a row.
+func assertRow(t *testing.T, row testRow, expected testRow) {
+	t.Helper()
+	if row == nil {
+		t.Errorf("expected row, got none")
+		return
+	}
+	for column, value := range expected {
+		if actual, ok := row[column]; !ok {
+			t.Errorf("unknown column %q", column)
+		} else if !reflect.DeepEqual(actual, value) {
+			t.Errorf("expected %s %#v, got %#v", column, value, actual)
+		}
+	}
+}
+
+// assertQuery checks that the last request received on the given path had the
+// expected query parameters (among others).
+func (f *fakeOpenStack) assertQuery(path string, expected url.Values) {
+	f.t.Helper()
+	requests := f.received(path)
+	if len(requests) == 0 {
+		f.t.Errorf("expected request to %s, got none", path)
+		return
+	}
+	query := requests[len(requests)-1].Query
+	for key, values := range expected {
+		if !reflect.DeepEqual(query[key], values) {
+			f.t.Errorf("%s: expected %s=%v, got %v", path, key, values, query[key])
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package openstack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/domains"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/projects"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/users"
+	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/images"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/networks"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+const (
+	// NameCache is the cache key for the ID to name lookup cache of the
+	// connection.
+	NameCache = "openstack_name_cache"
+
+	// DefaultNameCacheTTL is how long (in seconds) resolved names are cached
+	// unless the name_cache_ttl parameter says otherwise.
+	DefaultNameCacheTTL = 300
+
+	// NameCacheMissTTL is how long (in seconds) at most IDs whose resources
+	// cannot be found or seen are cached without a name, so that they are not
+	// looked up again for every row while access may soon be granted.
+	NameCacheMissTTL = 30
+)
+
+// nameKind describes a kind of resource whose IDs the *_name columns resolve
+// to names.
+type nameKind struct {
+	// Name identifies the kind in the cache keys and logs.
+	Name string
+	// Service is the type of the service client used to look names up.
+	Service ServiceType
+	// Global is true for the kinds whose IDs are the same in all regions, such
+	// as Keystone's, so that their names are cached once for all of them.
+	Global bool
+	// Lookup retrieves the name of the resource with the given ID.
+	Lookup func(client *gophercloud.ServiceClient, id string) (string, error)
+}
+
+var (
+	projectNames = nameKind{
+		Name:    "project",
+		Service: IdentityV3,
+		Global:  true,
+		Lookup: func(client *gophercloud.ServiceClient, id string) (string, error) {
+			project, err := projects.Get(client, id).Extract()
+			if err != nil {
+				return "", err
+			}
+			return project.Name, nil
+		},
+	}
+	userNames = nameKind{
+		Name:    "user",
+		Service: IdentityV3,
+		Global:  true,
+		Lookup: func(client *gophercloud.ServiceClient, id string) (string, error) {
+			user, err := users.Get(client, id).Extract()
+			if err != nil {
+				return "", err
+			}
+			return user.Name, nil
+		},
+	}
+	domainNames = nameKind{
+		Name:    "domain",
+		Service: IdentityV3,
+		Global:  true,
+		Lookup: func(client *gophercloud.ServiceClient, id string) (string, error) {
+			domain, err := domains.Get(client, id).Extract()
+			if err != nil {
+				return "", err
+			}
+			return domain.Name, nil
+		},
+	}
+	networkNames = nameKind{
+		Name:    "network",
+		Service: NetworkV2,
+		Lookup: func(client *gophercloud.ServiceClient, id string) (string, error) {
+			network, err := networks.Get(client, id).Extract()
+			if err != nil {
+				return "", err
+			}
+			return network.Name, nil
+		},
+	}
+	imageNames = nameKind{
+		Name:    "image",
+		Service: ImageServiceV2,
+		Lookup: func(client *gophercloud.ServiceClient, id string) (string, error) {
+			image, err := images.Get(client, id).Extract()
+			if err != nil {
+				return "", err
+			}
+			return image.Name, nil
+		},
+	}
+)
+
+// nameCache caches the names resolved from IDs for a while, so that each ID
+// is looked up at most once even when many rows refer to it at the same time.
+type nameCache struct {
+	lock    sync.Mutex
+	ttl     time.Duration
+	missTTL time.Duration
+	entries map[string]*nameEntry
+}
+
+// nameEntry is a name being looked up or already resolved; done is closed
+// once the lookup is over.
+type nameEntry struct {
+	done    chan struct{}
+	name    string
+	err     error
+	expires time.Time
+}
+
+// nameCacheLock serialises the creation of the name caches of connections.
+var nameCacheLock sync.Mutex
+
+// getNameCache returns the ID to name lookup cache of the connection.
+func getNameCache(ctx context.Context, d *plugin.QueryData) (*nameCache, error) {
+	nameCacheLock.Lock()
+	defer nameCacheLock.Unlock()
+
+	// load cache from cache
+	if cachedData, ok := d.ConnectionManager.Cache.Get(NameCache); ok {
+		return cachedData.(*nameCache), nil
+	}
+
+	openstackConfig, err := getConnectionConfig(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving connection configuration", "error", err)
+		return nil, err
+	}
+	ttl := DefaultNameCacheTTL
+	if openstackConfig.NameCacheTTL != nil {
+		ttl = *openstackConfig.NameCacheTTL
+	}
+
+	missTTL := NameCacheMissTTL
+	if ttl < missTTL {
+		missTTL = ttl
+	}
+
+	cache := &nameCache{
+		ttl:     time.Duration(ttl) * time.Second,
+		missTTL: time.Duration(missTTL) * time.Second,
+		entries: map[string]*nameEntry{},
+	}
+	plugin.Logger(ctx).Debug("saving name cache to cache", "ttl", cache.ttl)
+	d.ConnectionManager.Cache.Set(NameCache, cache)
+	return cache, nil
+}
+
+// resolve returns the name cached under the given key, calling lookup if it
+// is missing or expired; lookup also returns whether the resource was found,
+// since those that were not are only cached for a short while, and failed
+// lookups are not cached at all.
+func (c *nameCache) resolve(key string, lookup func() (string, bool, error)) (string, error) {
+	c.lock.Lock()
+	entry, ok := c.entries[key]
+	if ok && (entry.expires.IsZero() || time.Now().Before(entry.expires)) {
+		c.lock.Unlock()
+		<-entry.done
+		return entry.name, entry.err
+	}
+	entry = &nameEntry{done: make(chan struct{})}
+	c.entries[key] = entry
+	c.lock.Unlock()
+
+	name, found, err := lookup()
+
+	c.lock.Lock()
+	entry.name, entry.err = name, err
+	switch {
+	case err != nil:
+		delete(c.entries, key)
+	case !found:
+		entry.expires = time.Now().Add(c.missTTL)
+	default:
+		entry.expires = time.Now().Add(c.ttl)
+	}
+	c.lock.Unlock()
+	close(entry.done)
+	return name, err
+}
+
+// resolveName returns the name of the resource of the given kind and ID, or
+// nil if there is no ID or the resource cannot be found or seen; ctx selects
+// the project whose token is used for the lookup, if re-scoping.
+func resolveName(ctx context.Context, d *plugin.QueryData, kind nameKind, id string) (interface{}, error) {
+	if id == "" {
+		return nil, nil
+	}
+	cache, err := getNameCache(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	region := d.EqualsQualString(RegionKey)
+	key := fmt.Sprintf("%s/%s/%s", kind.Name, region, id)
+	if kind.Global {
+		key = fmt.Sprintf("%s/%s", kind.Name, id)
+	}
+	name, err := cache.resolve(key, func() (string, bool, error) {
+		plugin.Logger(ctx).Debug("looking up name", "kind", kind.Name, "id", id, "region", region)
+		client, err := getServiceClient(ctx, d, kind.Service)
+		if err != nil {
+			plugin.Logger(ctx).Error("error retrieving client", "error", err)
+			return "", false, err
+		}
+		name, err := kind.Lookup(client, id)
+		// resources that were deleted or that the user is not allowed to see
+		// are left without a name, rather than failing the query
+		if errors.As(err, &gophercloud.ErrDefault404{}) || errors.As(err, &gophercloud.ErrDefault403{}) {
+			plugin.Logger(ctx).Debug("name not available", "kind", kind.Name, "id", id, "error", err)
+			return "", false, nil
+		}
+		return name, err == nil, err
+	})
+	if err != nil {
+		plugin.Logger(ctx).Error("error looking up name", "kind", kind.Name, "id", id, "error", err)
+		return nil, err
+	}
+	if name == "" {
+		return nil, nil
+	}
+	return name, nil
+}
+
+//// HYDRATE FUNCTIONS
+
+// projectIDFields are the fields holding the ID of the project that owns the
+// items of the different tables, in order of preference.
+var projectIDFields = []string{"ProjectID", "TenantID", "OsVolTenantAttrTenantID", "Owner"}
+
+// getProjectName returns the name of the project that owns the item; the
+// names of the projects in the connection's scope are known already, the
+// others are looked up in Keystone.
+func getProjectName(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+	projectID := getItemID(h.Item, projectIDFields...)
+	if name, ok := scope.ProjectNames[projectID]; ok && projectID != "" && name != "" {
+		return name, nil
+	}
+	return resolveName(ctx, d, projectNames, projectID)
+}
+
+// getUserName returns the name of the user the item refers to.
+func getUserName(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	return resolveName(ctx, d, userNames, getItemID(h.Item, "UserID"))
+}
+
+// getDomainName returns the name of the domain the item belongs to.
+func getDomainName(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	return resolveName(ctx, d, domainNames, getItemID(h.Item, "DomainID"))
+}
+
+// getNetworkName returns the name of the network the item is attached to; it
+// is looked up with the token of the item's project, since private networks
+// are only visible within it.
+func getNetworkName(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+	ctx = scope.Context(ctx, getItemID(h.Item, projectIDFields...))
+	return resolveName(ctx, d, networkNames, getItemID(h.Item, "NetworkID"))
+}
+
+// getImageName returns the name of the image the item was created from; it
+// is looked up with the token of the item's project, since private images
+// are only visible within it.
+func getImageName(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+	ctx = scope.Context(ctx, getItemID(h.Item, projectIDFields...))
+	return resolveName(ctx, d, imageNames, getItemID(h.Item, "ImageID", "Image"))
+}
+
+// getItemID returns the first non-empty ID among the given fields of the item;
+// fields can hold the ID itself or, as Nova does for images, an object with an
+// "id" attribute.
+func getItemID(item interface{}, fields ...string) string {
+	value := reflect.Indirect(reflect.ValueOf(item))
+	if value.Kind() != reflect.Struct {
+		return ""
+	}
+	for _, name := range fields {
+		field := value.FieldByName(name)
+		if !field.IsValid() || !field.CanInterface() {
+			continue
+		}
+		switch id := field.Interface().(type) {
+		case string:
+			if id != "" {
+				return id
+			}
+		case map[string]interface{}:
+			if id, ok := id["id"].(string); ok && id != "" {
+				return id
+			}
+		}
+	}
+	return ""
+}
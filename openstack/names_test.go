@@ -0,0 +1,126 @@
+package openstack
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/context_key"
+)
+
+func TestNameCache(t *testing.T) {
+	cache := &nameCache{ttl: time.Minute, entries: map[string]*nameEntry{}}
+
+	// concurrent lookups of the same ID result in a single request
+	var calls int32
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name, err := cache.resolve("project/RegionOne/1", func() (string, bool, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return "admin", true, nil
+			})
+			if err != nil || name != "admin" {
+				t.Errorf("expected admin, got %q (error: %v)", name, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("expected 1 lookup, got %d", calls)
+	}
+
+	// failed lookups are not cached
+	failure := errors.New("service unavailable")
+	if _, err := cache.resolve("project/RegionOne/2", func() (string, bool, error) { return "", false, failure }); err != failure {
+		t.Errorf("expected %v, got %v", failure, err)
+	}
+	if name, err := cache.resolve("project/RegionOne/2", func() (string, bool, error) { return "databases", true, nil }); err != nil || name != "databases" {
+		t.Errorf("expected databases, got %q (error: %v)", name, err)
+	}
+
+	// resources that cannot be seen are cached for a shorter while
+	cache.missTTL = -time.Second
+	cache.resolve("project/RegionOne/4", func() (string, bool, error) { return "", false, nil })
+	if name, _ := cache.resolve("project/RegionOne/4", func() (string, bool, error) { return "granted", true, nil }); name != "granted" {
+		t.Errorf("expected granted, got %q", name)
+	}
+
+	// expired names are looked up again
+	cache.ttl = -time.Second
+	cache.resolve("project/RegionOne/3", func() (string, bool, error) { return "old", true, nil })
+	if name, _ := cache.resolve("project/RegionOne/3", func() (string, bool, error) { return "new", true, nil }); name != "new" {
+		t.Errorf("expected new, got %q", name)
+	}
+}
+
+func TestListOpenStackInstanceNames(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackInstance(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "user_name", "admin", "admin")
+	assertRows(t, rows, "image_name", "ubuntu-22.04", nil)
+	assertRows(t, rows, "project_name", "admin", "databases")
+
+	// each ID is looked up once, and the current project not at all
+	for path, count := range map[string]int{
+		"/identity/v3/users/5d3c1a2b0e5f4e8b9c6a7d8e9f0a1b2c":    1,
+		"/image/v2/images/70a599e0-31e7-49b7-b260-868f441e862b":  1,
+		"/identity/v3/projects/a3b4c5d6e7f8091a2b3c4d5e6f708192": 1,
+		"/identity/v3/projects/" + TestProjectID:                 0,
+	} {
+		if requests := cloud.received(path); len(requests) != count {
+			t.Errorf("expected %d requests for %s, got %d", count, path, len(requests))
+		}
+	}
+}
+
+func TestResolveNameRegions(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.config.Regions = []string{"RegionOne", "RegionTwo"}
+	ctx := newTestContext()
+	table := tableOpenStackInstance(ctx)
+
+	// Keystone names are the same in all regions, so they are looked up once
+	for _, region := range cloud.config.Regions {
+		matrixItem := map[string]any{RegionKey: region}
+		d, _ := cloud.newQueryData(table, testQuery{}, matrixItem)
+		name, err := resolveName(context.WithValue(ctx, context_key.MatrixItem, matrixItem), d, userNames, TestUserID)
+		if err != nil || name != "admin" {
+			t.Errorf("%s: expected admin, got %v (error: %v)", region, name, err)
+		}
+	}
+	if requests := cloud.received("/identity/v3/users/" + TestUserID); len(requests) != 1 {
+		t.Errorf("expected 1 request for the user, got %d", len(requests))
+	}
+}
+
+func TestListOpenStackInstanceNamesNotAvailable(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	// users cannot be seen and the image is gone
+	cloud.fail("GET /identity/v3/users/5d3c1a2b0e5f4e8b9c6a7d8e9f0a1b2c", http.StatusForbidden)
+	cloud.fail("GET /image/v2/images/70a599e0-31e7-49b7-b260-868f441e862b", http.StatusNotFound)
+
+	rows, err := cloud.list(tableOpenStackInstance(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "user_name", nil, nil)
+	assertRows(t, rows, "image_name", nil, nil)
+	if requests := cloud.received("/identity/v3/users/5d3c1a2b0e5f4e8b9c6a7d8e9f0a1b2c"); len(requests) != 1 {
+		t.Errorf("expected 1 request for the user, got %d", len(requests))
+	}
+}
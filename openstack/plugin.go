@@ -2,7 +2,11 @@ package openstack
 
 import (
 	"context"
+	"fmt"
+	"sort"
 
+	"github.com/iancoleman/strcase"
+	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
 )
@@ -15,30 +19,149 @@ func Plugin(ctx context.Context) *plugin.Plugin {
 			ShouldIgnoreErrorFunc: shouldIgnoreErrors([]string{"404", "ErrDefault404", "itemNotFound"}),
 		},
 		TableMap: map[string]*plugin.Table{
-			"openstack_instance":            tableOpenStackInstance(ctx),
-			"openstack_project":             tableOpenStackProject(ctx),
-			"openstack_user":                tableOpenStackUser(ctx),
-			"openstack_port":                tableOpenStackPort(ctx),
-			"openstack_volume":              tableOpenStackVolume(ctx),
-			"openstack_attachment":          tableOpenStackAttachment(ctx),
-			"openstack_image":               tableOpenStackImage(ctx),
-			"openstack_security_group":      tableOpenStackSecurityGroup(ctx),
-			"openstack_security_group_rule": tableOpenStackSecurityGroupRule(ctx),
-			"security_group_rule":           tableSecurityGroupRule(ctx),
-			"openstack_network":             tableOpenStackNetwork(ctx),
-			"openstack_subnet":              tableOpenStackSubnet(ctx),
-			"openstack_hypervisor":          tableOpenStackHypervisor(ctx),
-			"openstack_aggregate":           tableOpenStackAggregate(ctx),
-			"openstack_flavor":              tableOpenStackFlavor(ctx),
-			"openstack_loadbalancer":        tableOpenStackLoadBalancer(ctx),
-			"openstack_listener":            tableOpenStackListener(ctx),
-			"openstack_pool":                tableOpenStackPool(ctx),
-			"openstack_pool_member":         tableOpenStackPoolMember(ctx),
+			"openstack_instance":                tableOpenStackInstance(ctx),
+			"openstack_instance_address":        tableOpenStackInstanceAddress(ctx),
+			"openstack_instance_action":         tableOpenStackInstanceAction(ctx),
+			"openstack_instance_console_log":    tableOpenStackInstanceConsoleLog(ctx),
+			"openstack_project":                 tableOpenStackProject(ctx),
+			"openstack_user":                    tableOpenStackUser(ctx),
+			"openstack_port":                    tableOpenStackPort(ctx),
+			"openstack_volume":                  tableOpenStackVolume(ctx),
+			"openstack_attachment":              tableOpenStackAttachment(ctx),
+			"openstack_image":                   tableOpenStackImage(ctx),
+			"openstack_keypair":                 tableOpenStackKeypair(ctx),
+			"openstack_server_group":            tableOpenStackServerGroup(ctx),
+			"openstack_security_group":          tableOpenStackSecurityGroup(ctx),
+			"openstack_security_group_rule":     tableOpenStackSecurityGroupRule(ctx),
+			"openstack_security_group_exposure": tableOpenStackSecurityGroupExposure(ctx),
+			"openstack_network":                 tableOpenStackNetwork(ctx),
+			"openstack_subnet":                  tableOpenStackSubnet(ctx),
+			"openstack_network_agent":           tableOpenStackNetworkAgent(ctx),
+			"openstack_hypervisor":              tableOpenStackHypervisor(ctx),
+			"openstack_compute_service":         tableOpenStackComputeService(ctx),
+			"openstack_compute_usage":           tableOpenStackComputeUsage(ctx),
+			"openstack_aggregate":               tableOpenStackAggregate(ctx),
+			"openstack_flavor":                  tableOpenStackFlavor(ctx),
+			"openstack_loadbalancer":            tableOpenStackLoadBalancer(ctx),
+			"openstack_listener":                tableOpenStackListener(ctx),
+			"openstack_pool":                    tableOpenStackPool(ctx),
+			"openstack_pool_member":             tableOpenStackPoolMember(ctx),
+			"openstack_quota":                   tableOpenStackQuota(ctx),
+			"openstack_api_version":             tableOpenStackAPIVersion(ctx),
 		},
 		ConnectionConfigSchema: &plugin.ConnectionConfigSchema{
 			NewInstance: ConfigInstance,
 			Schema:      ConfigSchema,
 		},
 	}
+	for name, alias := range deprecatedTables {
+		p.TableMap[name] = deprecatedTable(name, alias, p.TableMap[alias.Table])
+	}
 	return p
 }
+
+// tableAlias describes a deprecated name under which a table is still exposed
+// for a transition period; Columns maps the names of renamed columns to their
+// current names, so that they can be queried under both.
+type tableAlias struct {
+	Table   string
+	Columns map[string]string
+}
+
+// deprecatedTables maps the deprecated table names to the tables replacing
+// them.
+var deprecatedTables = map[string]tableAlias{
+	"security_group_rule": {Table: "openstack_security_group_rule"},
+}
+
+// deprecatedTable returns a copy of the given table under its deprecated name;
+// it shares the table's columns and hydrate functions, re-exposes renamed
+// columns, and their key columns, under their old names and logs a deprecation
+// warning whenever it is queried.
+func deprecatedTable(name string, alias tableAlias, table *plugin.Table) *plugin.Table {
+	deprecated := *table
+	deprecated.Name = name
+	deprecated.Description = fmt.Sprintf("%s (deprecated, use %s)", table.Description, table.Name)
+
+	columns := map[string]*plugin.Column{}
+	deprecated.Columns = make([]*plugin.Column, 0, len(table.Columns)+len(alias.Columns))
+	for _, column := range table.Columns {
+		column := *column
+		columns[column.Name] = &column
+		deprecated.Columns = append(deprecated.Columns, &column)
+	}
+	renamed := make([]string, 0, len(alias.Columns))
+	for column := range alias.Columns {
+		renamed = append(renamed, column)
+	}
+	sort.Strings(renamed)
+	for _, old := range renamed {
+		current, ok := columns[alias.Columns[old]]
+		if !ok {
+			panic(fmt.Sprintf("table %s has no column %s to alias as %s", table.Name, alias.Columns[old], old))
+		}
+		column := *current
+		column.Name = old
+		column.Description = fmt.Sprintf("%s (deprecated, use %s)", current.Description, current.Name)
+		// the plugin's default transform finds the Go field by column name
+		if column.Transform == nil {
+			column.Transform = transform.FromField(helpers.LintName(strcase.ToCamel(current.Name))).NullIfZero()
+		}
+		deprecated.Columns = append(deprecated.Columns, &column)
+	}
+
+	// quals on the old names are passed on to the hydrate functions under the
+	// current ones, which is how they look them up
+	renameQuals := func(d *plugin.QueryData) {
+		for _, old := range renamed {
+			current := alias.Columns[old]
+			if value, ok := d.EqualsQuals[old]; ok && d.EqualsQuals[current] == nil {
+				d.EqualsQuals[current] = value
+			}
+			if quals, ok := d.Quals[old]; ok && d.Quals[current] == nil {
+				d.Quals[current] = &plugin.KeyColumnQuals{Name: current, Quals: quals.Quals}
+			}
+		}
+	}
+
+	if table.List != nil {
+		list := *table.List
+		list.KeyColumns = renameKeyColumns(table.List.KeyColumns, alias.Columns, renamed)
+		list.Hydrate = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+			plugin.Logger(ctx).Warn("table is deprecated", "table", name, "use", table.Name)
+			renameQuals(d)
+			return table.List.Hydrate(ctx, d, h)
+		}
+		deprecated.List = &list
+	}
+	if table.Get != nil {
+		get := *table.Get
+		get.KeyColumns = renameKeyColumns(table.Get.KeyColumns, alias.Columns, renamed)
+		get.Hydrate = func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+			plugin.Logger(ctx).Warn("table is deprecated", "table", name, "use", table.Name)
+			renameQuals(d)
+			return table.Get.Hydrate(ctx, d, h)
+		}
+		deprecated.Get = &get
+	}
+	return &deprecated
+}
+
+// renameKeyColumns returns the key columns followed by copies of those of the
+// renamed columns under their old names; the copies are optional, so that
+// queries on the current names need not repeat the quals on the old ones.
+func renameKeyColumns(keyColumns plugin.KeyColumnSlice, columns map[string]string, renamed []string) plugin.KeyColumnSlice {
+	result := append(plugin.KeyColumnSlice{}, keyColumns...)
+	for _, old := range renamed {
+		for _, keyColumn := range keyColumns {
+			if keyColumn.Name != columns[old] {
+				continue
+			}
+			keyColumn := *keyColumn
+			keyColumn.Name = old
+			keyColumn.Require = plugin.Optional
+			result = append(result, &keyColumn)
+		}
+	}
+	return result
+}
@@ -0,0 +1,91 @@
+package openstack
+
+import (
+	"context"
+	"net/url"
+	"testing"
+)
+
+func TestPluginDeprecatedTables(t *testing.T) {
+	p := Plugin(context.Background())
+
+	for name, alias := range deprecatedTables {
+		table, ok := p.TableMap[name]
+		if !ok {
+			t.Errorf("deprecated table %s is not registered", name)
+			continue
+		}
+		current := p.TableMap[alias.Table]
+		if table.Name != name || len(table.Columns) != len(current.Columns)+len(alias.Columns) {
+			t.Errorf("%s: expected a copy of %s with %d more columns", name, alias.Table, len(alias.Columns))
+		}
+	}
+}
+
+func TestListDeprecatedTableRenamedColumns(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	current := tableOpenStackSecurityGroupRule(context.Background())
+	table := deprecatedTable("security_group_rule", tableAlias{
+		Table:   current.Name,
+		Columns: map[string]string{"min_port": "port_range_min", "group_id": "security_group_id"},
+	}, current)
+
+	rows, err := cloud.list(table, testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "min_port", int64(22), int64(0))
+	assertRows(t, rows, "port_range_min", int64(22), int64(0))
+	assertRows(t, rows, "group_id", "85cc3048-abc3-43cc-89b3-377341426ac5", "85cc3048-abc3-43cc-89b3-377341426ac5")
+
+	// the current table is left untouched
+	if len(current.Columns) != len(table.Columns)-2 || current.Name != "openstack_security_group_rule" {
+		t.Errorf("the current table was modified")
+	}
+	if table.Columns[len(table.Columns)-1].Name != "min_port" {
+		t.Errorf("expected the renamed columns last, in order")
+	}
+}
+
+func TestListDeprecatedTableRenamedKeyColumns(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	current := tableOpenStackSecurityGroupRule(context.Background())
+	for _, column := range current.Columns {
+		if column.Name == "direction" {
+			// relying on the default transform
+			column.Transform = nil
+		}
+	}
+	table := deprecatedTable("security_group_rule", tableAlias{
+		Table:   current.Name,
+		Columns: map[string]string{"way": "direction", "group_id": "security_group_id"},
+	}, current)
+
+	rows, err := cloud.list(table, testQuery{quals: map[string]any{
+		"way":      "ingress",
+		"group_id": "85cc3048-abc3-43cc-89b3-377341426ac5",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "way", "ingress", "egress")
+	assertRows(t, rows, "direction", "ingress", "egress")
+
+	// quals on the old names are passed on to Neutron
+	cloud.assertQuery("/network/v2.0/security-group-rules", url.Values{
+		"direction":         {"ingress"},
+		"security_group_id": {"85cc3048-abc3-43cc-89b3-377341426ac5"},
+	})
+}
+
+func TestDeprecatedTableUnknownColumn(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for an unknown column")
+		}
+	}()
+	deprecatedTable("security_group_rule", tableAlias{
+		Table:   "openstack_security_group_rule",
+		Columns: map[string]string{"old": "missing"},
+	}, tableOpenStackSecurityGroupRule(context.Background()))
+}
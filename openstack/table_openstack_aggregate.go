@@ -2,9 +2,11 @@ package openstack
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/dihedron/steampipe-plugin-utils/utils"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/aggregates"
+	"github.com/gophercloud/gophercloud/pagination"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -14,8 +16,9 @@ import (
 
 func tableOpenStackAggregate(_ context.Context) *plugin.Table {
 	return &plugin.Table{
-		Name:        "openstack_aggregate",
-		Description: "OpenStack Aggregate",
+		Name:              "openstack_aggregate",
+		Description:       "OpenStack Aggregate",
+		GetMatrixItemFunc: regionMatrix,
 		Columns: []*plugin.Column{
 			{
 				Name:        "id",
@@ -49,15 +52,21 @@ func tableOpenStackAggregate(_ context.Context) *plugin.Table {
 			},
 			{
 				Name:        "created_at",
-				Type:        proto.ColumnType_JSON,
+				Type:        proto.ColumnType_TIMESTAMP,
 				Description: "The date and time when the resource was created",
-				Transform:   transform.FromField("CreatedAt"),
+				Transform:   transform.FromField("CreatedAt").Transform(ToTime),
 			},
 			{
 				Name:        "updated_at",
-				Type:        proto.ColumnType_JSON,
+				Type:        proto.ColumnType_TIMESTAMP,
 				Description: "The date and time when the resource was updated",
-				Transform:   transform.FromField("UpdatedAt"),
+				Transform:   transform.FromField("UpdatedAt").Transform(ToTime),
+			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the aggregate belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
 			},
 		},
 		List: &plugin.ListConfig{
@@ -90,28 +99,30 @@ func listOpenStackAggregate(ctx context.Context, d *plugin.QueryData, h *plugin.
 		return nil, err
 	}
 
-	allPages, err := aggregates.List(client).AllPages()
-	if err != nil {
-		plugin.Logger(ctx).Error("error listing aggregates with options", "options", utils.ToPrettyJSON(allPages), "error", err)
-		return nil, err
-	}
-	allAggregates, err := aggregates.ExtractAggregates(allPages)
-	plugin.Logger(ctx).Debug("all aggregate", "all_aggregate", utils.ToPrettyJSON(allAggregates))
+	err = aggregates.List(client).EachPage(func(page pagination.Page) (bool, error) {
+		allAggregates, err := aggregates.ExtractAggregates(page)
+		plugin.Logger(ctx).Debug("all aggregate", "all_aggregate", utils.ToPrettyJSON(allAggregates))
+		if err != nil {
+			plugin.Logger(ctx).Error("error extracting aggregates", "error", err)
+			return false, err
+		}
+		plugin.Logger(ctx).Debug("aggregates retrieved", "count", len(allAggregates))
+
+		for _, aggregate := range allAggregates {
+			aggregate := aggregate
+			plugin.Logger(ctx).Debug("aggregate", "aggregate", utils.ToPrettyJSON(aggregate))
+			d.StreamListItem(ctx, &aggregate)
+			if rowsRemaining(ctx, d) == 0 {
+				plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+				return false, nil
+			}
+		}
+		return true, nil
+	})
 	if err != nil {
-		plugin.Logger(ctx).Error("error extracting aggregates", "error", err)
+		plugin.Logger(ctx).Error("error listing aggregates", "error", err)
 		return nil, err
 	}
-	plugin.Logger(ctx).Debug("aggregates retrieved", "count", len(allAggregates))
-
-	for _, aggregate := range allAggregates {
-		if ctx.Err() != nil {
-			plugin.Logger(ctx).Debug("context done, exit")
-			break
-		}
-		aggregate := aggregate
-		plugin.Logger(ctx).Debug("aggregate", "aggregate", utils.ToPrettyJSON(aggregate))
-		d.StreamListItem(ctx, &aggregate)
-	}
 	return nil, nil
 }
 
@@ -121,7 +132,12 @@ func getOpenStackAggregate(ctx context.Context, d *plugin.QueryData, h *plugin.H
 
 	setLogLevel(ctx, d)
 
-	id := d.EqualsQuals["id"].GetInt64Value()
+	// the id column is a string, but aggregates have numeric IDs
+	id, err := strconv.Atoi(d.EqualsQualString("id"))
+	if err != nil {
+		plugin.Logger(ctx).Debug("no aggregate with non-numeric id", "id", d.EqualsQualString("id"), "error", err)
+		return nil, nil
+	}
 	plugin.Logger(ctx).Debug("retrieving openstack aggregate", "id", id)
 
 	client, err := getServiceClient(ctx, d, ComputeV2)
@@ -130,7 +146,7 @@ func getOpenStackAggregate(ctx context.Context, d *plugin.QueryData, h *plugin.H
 		return nil, err
 	}
 
-	result := aggregates.Get(client, int(id))
+	result := aggregates.Get(client, id)
 	var aggregate *aggregates.Aggregate
 	aggregate, err = result.Extract()
 	if err != nil {
@@ -0,0 +1,54 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestListOpenStackAggregate(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackAggregate(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "gpu", "ssd")
+	assertRow(t, rows[0], testRow{
+		"id":                "1",
+		"availability_zone": "nova",
+		"hosts":             `["compute-01","compute-02"]`,
+		"metadata":          `{"gpu":"true"}`,
+		"created_at":        "2022-09-01T10:00:00Z",
+		"region":            "RegionOne",
+	})
+	assertRow(t, rows[1], testRow{"availability_zone": "", "updated_at": "2022-09-03T12:00:00Z"})
+}
+
+func TestListOpenStackAggregateError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /compute/v2.1/os-aggregates", http.StatusForbidden)
+
+	if _, err := cloud.list(tableOpenStackAggregate(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGetOpenStackAggregate(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	table := tableOpenStackAggregate(context.Background())
+
+	row, err := cloud.get(table, testQuery{quals: map[string]any{"id": "1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, row, testRow{"id": "1", "name": "gpu"})
+
+	// aggregates have numeric IDs, other values match no aggregate
+	for _, id := range []string{"42", "gpu"} {
+		row, err = cloud.get(table, testQuery{quals: map[string]any{"id": id}})
+		if err != nil || row != nil {
+			t.Errorf("%s: expected no row and no error, got %v (error: %v)", id, row, err)
+		}
+	}
+}
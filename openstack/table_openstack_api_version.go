@@ -0,0 +1,160 @@
+package openstack
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableOpenStackAPIVersion(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:              "openstack_api_version",
+		Description:       "OpenStack API versions and negotiated microversions",
+		GetMatrixItemFunc: regionMatrix,
+		Columns: []*plugin.Column{
+			{
+				Name:        "service",
+				Type:        proto.ColumnType_STRING,
+				Description: "The type of the service in the service catalog (e.g. compute).",
+				Transform:   transform.FromField("Service"),
+			},
+			{
+				Name:        "endpoint",
+				Type:        proto.ColumnType_STRING,
+				Description: "The endpoint of the service.",
+				Transform:   transform.FromField("Endpoint"),
+			},
+			{
+				Name:        "version",
+				Type:        proto.ColumnType_STRING,
+				Description: "The identifier of the API version, as per the version document (e.g. v2.1).",
+				Transform:   transform.FromField("Version.ID"),
+			},
+			{
+				Name:        "status",
+				Type:        proto.ColumnType_STRING,
+				Description: "The status of the API version (e.g. CURRENT).",
+				Transform:   transform.FromField("Version.Status"),
+			},
+			{
+				Name:        "min_version",
+				Type:        proto.ColumnType_STRING,
+				Description: "The lowest microversion supported by the server.",
+				Transform:   transform.FromField("Version.MinVersion"),
+			},
+			{
+				Name:        "max_version",
+				Type:        proto.ColumnType_STRING,
+				Description: "The highest microversion supported by the server.",
+				Transform:   transform.FromField("Version.MaxVersion"),
+			},
+			{
+				Name:        "plugin_max_version",
+				Type:        proto.ColumnType_STRING,
+				Description: "The highest microversion supported by the plugin.",
+				Transform:   transform.FromField("PluginMaxVersion"),
+			},
+			{
+				Name:        "configured_microversion",
+				Type:        proto.ColumnType_STRING,
+				Description: "The microversion in the connection configuration (e.g. auto), if any.",
+				Transform:   transform.FromField("ConfiguredMicroversion"),
+			},
+			{
+				Name:        "microversion",
+				Type:        proto.ColumnType_STRING,
+				Description: "The microversion used by the plugin to talk to the service.",
+				Transform:   transform.FromField("Microversion"),
+			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the service belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
+		},
+		List: &plugin.ListConfig{
+			Hydrate: listOpenStackAPIVersion,
+			KeyColumns: plugin.KeyColumnSlice{
+				&plugin.KeyColumn{
+					Name:    "service",
+					Require: plugin.Optional,
+				},
+			},
+		},
+	}
+}
+
+type apiServiceVersion struct {
+	Service                string
+	Endpoint               string
+	Version                *apiVersion
+	PluginMaxVersion       string
+	ConfiguredMicroversion *string
+	Microversion           string
+}
+
+//// LIST FUNCTION
+
+func listOpenStackAPIVersion(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	openstackConfig, err := getConnectionConfig(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving connection configuration", "error", err)
+		return nil, err
+	}
+
+	keys := []ServiceType{}
+	for key, service := range serviceConfigMap {
+		if value := d.EqualsQualString("service"); value != "" && value != service.catalogType {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return serviceConfigMap[keys[i]].catalogType < serviceConfigMap[keys[j]].catalogType
+	})
+
+	for _, key := range keys {
+		if ctx.Err() != nil {
+			plugin.Logger(ctx).Debug("context done, exit")
+			break
+		}
+		service := serviceConfigMap[key]
+
+		client, err := getServiceClient(ctx, d, key)
+		if err != nil {
+			var notFound *gophercloud.ErrEndpointNotFound
+			if errors.As(err, &notFound) {
+				plugin.Logger(ctx).Debug("service not in catalog", "type", key)
+				continue
+			}
+			plugin.Logger(ctx).Error("error retrieving client", "error", err)
+			return nil, err
+		}
+
+		row := &apiServiceVersion{
+			Service:                service.catalogType,
+			Endpoint:               client.Endpoint,
+			PluginMaxVersion:       service.maxMicroversion,
+			ConfiguredMicroversion: service.getMicroversion(openstackConfig),
+			Microversion:           client.Microversion,
+		}
+		if row.Version, err = getAPIVersion(ctx, d, key, client); err != nil {
+			plugin.Logger(ctx).Warn("error discovering API version", "type", key, "error", err)
+		}
+		plugin.Logger(ctx).Debug("API version", "version", utils.ToPrettyJSON(row))
+		d.StreamListItem(ctx, row)
+	}
+	return nil, nil
+}
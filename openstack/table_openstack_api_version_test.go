@@ -0,0 +1,78 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// serveVersions answers requests for the root of a service endpoint with the
+// sample version document of the given service.
+func serveVersions(cloud *fakeOpenStack, path string, service string) {
+	cloud.handle("GET "+path, func(w http.ResponseWriter, r *http.Request) {
+		document, err := os.ReadFile(filepath.Join("testdata", "versions", service+".json"))
+		if err != nil {
+			cloud.t.Errorf("error reading version document for %s: %v", service, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMultipleChoices)
+		w.Write(document)
+	})
+}
+
+func TestListOpenStackAPIVersion(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	serveVersions(cloud, "/compute/", "compute")
+	serveVersions(cloud, "/volumev3/", "volumev3")
+	serveVersions(cloud, "/image/", "image")
+	serveVersions(cloud, "/identity/", "identity")
+
+	rows, err := cloud.list(tableOpenStackAPIVersion(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "service", "compute", "identity", "image", "load-balancer", "network", "volumev3")
+	assertRow(t, rows[0], testRow{
+		"endpoint":                cloud.URL + "/compute/v2.1/",
+		"version":                 "v2.1",
+		"status":                  "CURRENT",
+		"min_version":             "2.0",
+		"max_version":             "2.95",
+		"plugin_max_version":      MaxComputeV2Microversion,
+		"configured_microversion": nil,
+		"microversion":            DefaultComputeV2Microversion,
+		"region":                  "RegionOne",
+	})
+	// services without a version document are still listed
+	assertRow(t, rows[3], testRow{"version": nil, "microversion": ""})
+}
+
+func TestListOpenStackAPIVersionFilters(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	serveVersions(cloud, "/compute/", "compute")
+
+	rows, err := cloud.list(tableOpenStackAPIVersion(context.Background()), testQuery{
+		quals: map[string]any{"service": "compute"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "service", "compute")
+	// other services are not queried
+	if requests := cloud.received("/volumev3/"); len(requests) != 0 {
+		t.Errorf("expected no requests for other services, got %d", len(requests))
+	}
+}
+
+func TestListOpenStackAPIVersionError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("POST /identity/v3/auth/tokens", http.StatusUnauthorized)
+
+	if _, err := cloud.list(tableOpenStackAPIVersion(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
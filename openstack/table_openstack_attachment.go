@@ -5,7 +5,7 @@ import (
 
 	"github.com/dihedron/steampipe-plugin-utils/utils"
 	"github.com/gophercloud/gophercloud/openstack/blockstorage/v3/attachments"
-	"github.com/gophercloud/gophercloud/openstack/identity/v3/projects"
+	"github.com/gophercloud/gophercloud/pagination"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -15,8 +15,9 @@ import (
 
 func tableOpenStackAttachment(_ context.Context) *plugin.Table {
 	return &plugin.Table{
-		Name:        "openstack_attachment",
-		Description: "OpenStack Disk Volume Attachment",
+		Name:              "openstack_attachment",
+		Description:       "OpenStack Disk Volume Attachment",
+		GetMatrixItemFunc: regionMatrix,
 		Columns: []*plugin.Column{
 			{
 				Name:        "id",
@@ -26,13 +27,13 @@ func tableOpenStackAttachment(_ context.Context) *plugin.Table {
 			},
 			{
 				Name:        "attached_at",
-				Type:        proto.ColumnType_STRING,
+				Type:        proto.ColumnType_TIMESTAMP,
 				Description: "When the attachment was created.",
 				Transform:   transform.FromField("AttachedAt").Transform(ToTime),
 			},
 			{
 				Name:        "detached_at",
-				Type:        proto.ColumnType_STRING,
+				Type:        proto.ColumnType_TIMESTAMP,
 				Description: "When the attachment was destroyed.",
 				Transform:   transform.FromField("DetachedAt").Transform(ToTime),
 			},
@@ -72,12 +73,25 @@ func tableOpenStackAttachment(_ context.Context) *plugin.Table {
 				Description: "The id of the project the attachment belongs to.",
 				Transform:   transform.FromField("ProjectID"), //FromField("OsVolTenantAttrTenantID"),
 			},
+			{
+				Name:        "project_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the project the attachment belongs to.",
+				Hydrate:     getProjectName,
+				Transform:   transform.FromValue(),
+			},
 			{
 				Name:        "connection_info",
 				Type:        proto.ColumnType_JSON,
 				Description: "The connection info used for server to connect the volume.",
 				Transform:   transform.FromField("ConnectionInfo"),
 			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the disk volume attachment belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
 		},
 		List: &plugin.ListConfig{
 			Hydrate: listOpenStackAttachment,
@@ -104,7 +118,7 @@ func tableOpenStackAttachment(_ context.Context) *plugin.Table {
 			},
 		},
 		Get: &plugin.GetConfig{
-			Hydrate: getOpenStackAttachment,
+			Hydrate: getInScope(getOpenStackAttachment),
 			KeyColumns: plugin.KeyColumnSlice{
 				&plugin.KeyColumn{
 					Name:    "id",
@@ -127,77 +141,73 @@ func listOpenStackAttachment(ctx context.Context, d *plugin.QueryData, h *plugin
 
 	plugin.Logger(ctx).Debug("retrieving openstack attachment list", "query data", utils.ToPrettyJSON(d))
 
-	client, err := getServiceClient(ctx, d, BlockStorageV3)
-	if err != nil {
-		plugin.Logger(ctx).Error("error retrieving client", "error", err)
-		return nil, err
-	}
-
 	// the OpenStack Cinder v2 API required that the project_id be specified in
 	// the request path; this can be cumbersome when working with SQL, so if the
 	// user did NOT specify the project_id filter, we get a list of all project
 	// IDs and then loop over them all, one by one. Therefore, the filter function
-	// will NOT handle the project_id filter because we set it ourselves.
-	projectIDs := []string{}
+	// will NOT handle the project_id filter because we set it ourselves; the
+	// projects are those in the connection's scope, and without admin rights
+	// only the current one (unless re-scoping the token to each project).
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+	projectIDs, err := getProjectIDs(ctx, d, scope)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving projects", "error", err)
+		return nil, err
+	}
 
 	opts := buildOpenStackAttachmentFilter(ctx, d.EqualsQuals)
-	if projectID, ok := d.EqualsQuals["id"]; ok {
-		projectIDs = append(projectIDs, projectID.GetStringValue())
-	} else {
+	opts.Limit = getPageSize(d)
+	opts.AllTenants = scope.AllTenants
 
-		client, err := getServiceClient(ctx, d, IdentityV3)
-		if err != nil {
-			plugin.Logger(ctx).Error("error retrieving client", "error", err)
-			return nil, err
-		}
-
-		allPages, err := projects.List(client, &projects.ListOpts{}).AllPages()
-		if err != nil {
-			plugin.Logger(ctx).Error("error listing projects", "error", err)
-			return nil, err
-		}
-		allProjects, err := projects.ExtractProjects(allPages)
-		if err != nil {
-			plugin.Logger(ctx).Error("error extracting projects", "error", err)
-			return nil, err
-		}
-		plugin.Logger(ctx).Debug("projects retrieved", "count", len(allProjects))
-
-		for _, project := range allProjects {
-			if ctx.Err() != nil {
-				plugin.Logger(ctx).Debug("context done, exit")
-				break
-			}
-			project := project
-			projectIDs = append(projectIDs, project.ID)
-		}
+	openstackConfig, err := getConnectionConfig(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving connection configuration", "error", err)
+		return nil, err
 	}
 
-	for _, projectID := range projectIDs {
+	// projects are queried in parallel, but within the same limit on requests
+	// in flight as the rest of the connection
+	err = forEachConcurrently(ctx, getMaxConcurrency(openstackConfig), projectIDs, func(projectID string) error {
+		opts := opts
 		opts.ProjectID = projectID
 
-		allPages, err := attachments.List(client, opts).AllPages()
+		client, err := getServiceClient(scope.Context(ctx, projectID), d, BlockStorageV3)
 		if err != nil {
-			plugin.Logger(ctx).Error("error listing attachments with options", "options", utils.ToPrettyJSON(opts), "error", err)
-			return nil, err
-		}
-		allAttachments := []*apiAttachment{}
-		err = attachments.ExtractAttachmentsInto(allPages, &allAttachments)
-		if err != nil {
-			plugin.Logger(ctx).Error("error extracting attachment", "error", err)
-			return nil, err
+			plugin.Logger(ctx).Error("error retrieving client", "error", err)
+			return err
 		}
-		plugin.Logger(ctx).Debug("attachment retrieved", "count", len(allAttachments))
 
-		for _, attachment := range allAttachments {
-			if ctx.Err() != nil {
-				plugin.Logger(ctx).Debug("context done, exit")
-				break
+		err = attachments.List(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+			allAttachments := []*apiAttachment{}
+			if err := attachments.ExtractAttachmentsInto(page, &allAttachments); err != nil {
+				plugin.Logger(ctx).Error("error extracting attachment", "error", err)
+				return false, err
+			}
+			plugin.Logger(ctx).Debug("attachment retrieved", "count", len(allAttachments))
+
+			for _, attachment := range allAttachments {
+				attachment := attachment
+				attachment.ProjectID = projectID
+				d.StreamListItem(ctx, attachment)
+				if rowsRemaining(ctx, d) == 0 {
+					plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+					return false, nil
+				}
 			}
-			attachment := attachment
-			attachment.ProjectID = projectID
-			d.StreamListItem(ctx, attachment)
+			return true, nil
+		})
+		if err != nil {
+			plugin.Logger(ctx).Error("error listing attachments with options", "options", utils.ToPrettyJSON(opts), "error", err)
+			return err
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return nil, nil
 }
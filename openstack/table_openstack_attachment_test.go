@@ -0,0 +1,104 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestListOpenStackAttachment(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	// only the admin project has attachments
+	cloud.handle("GET /volumev3/v3/attachments/detail", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("project_id") != TestProjectID {
+			writeJSON(w, http.StatusOK, map[string]any{"attachments": []any{}})
+			return
+		}
+		cloud.serveFixture(w, http.StatusOK, "volumev3/attachments_detail.json")
+	})
+
+	rows, err := cloud.list(tableOpenStackAttachment(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "id", "a1b2c3d4-e5f6-4a7b-8c9d-0e1f2a3b4c5d")
+	assertRow(t, rows[0], testRow{
+		"attached_at": "2022-09-24T13:53:59Z",
+		"detached_at": nil,
+		"volume_id":   "521752a6-acf6-4b2d-bc7a-119f9148cd8c",
+		"instance_id": "9168b536-cd40-4630-b43f-b259807c6e87",
+		"status":      "attached",
+		"attach_mode": "rw",
+		"project_id":  TestProjectID,
+		"region":      "RegionOne",
+	})
+
+	// without a project_id qual, attachments are listed project by project
+	requests := cloud.received("/volumev3/v3/attachments/detail")
+	projects := map[string]bool{}
+	for _, request := range requests {
+		projects[request.Query.Get("project_id")] = true
+	}
+	if len(requests) != 2 || !projects[TestProjectID] || !projects["a3b4c5d6e7f8091a2b3c4d5e6f708192"] {
+		t.Errorf("expected one request per project, got %v", requests)
+	}
+}
+
+func TestListOpenStackAttachmentFilters(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackAttachment(context.Background()), testQuery{
+		quals: map[string]any{
+			"instance_id": "9168b536-cd40-4630-b43f-b259807c6e87",
+			"volume_id":   "521752a6-acf6-4b2d-bc7a-119f9148cd8c",
+			"project_id":  TestProjectID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "project_id", TestProjectID)
+	cloud.assertQuery("/volumev3/v3/attachments/detail", url.Values{
+		"instance_id": {"9168b536-cd40-4630-b43f-b259807c6e87"},
+		"volume_id":   {"521752a6-acf6-4b2d-bc7a-119f9148cd8c"},
+		"project_id":  {TestProjectID},
+		"all_tenants": {"true"},
+	})
+	// projects are not listed when the project is known
+	if requests := cloud.received("/identity/v3/projects"); len(requests) != 0 {
+		t.Errorf("expected no requests to list projects, got %d", len(requests))
+	}
+}
+
+func TestListOpenStackAttachmentError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /identity/v3/projects", http.StatusForbidden)
+
+	if _, err := cloud.list(tableOpenStackAttachment(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error listing projects")
+	}
+
+	cloud = newFakeOpenStack(t)
+	cloud.fail("GET /volumev3/v3/attachments/detail", http.StatusInternalServerError)
+
+	if _, err := cloud.list(tableOpenStackAttachment(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error listing attachments")
+	}
+}
+
+func TestGetOpenStackAttachment(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	table := tableOpenStackAttachment(context.Background())
+
+	row, err := cloud.get(table, testQuery{quals: map[string]any{"id": "a1b2c3d4-e5f6-4a7b-8c9d-0e1f2a3b4c5d", "project_id": TestProjectID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, row, testRow{"status": "attached", "project_id": TestProjectID})
+
+	row, err = cloud.get(table, testQuery{quals: map[string]any{"id": "00000000-0000-0000-0000-000000000000"}})
+	if err != nil || row != nil {
+		t.Errorf("expected no row and no error, got %v (error: %v)", row, err)
+	}
+}
@@ -0,0 +1,152 @@
+package openstack
+
+import (
+	"context"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/services"
+	"github.com/gophercloud/gophercloud/pagination"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableOpenStackComputeService(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:              "openstack_compute_service",
+		Description:       "OpenStack Compute Service",
+		GetMatrixItemFunc: regionMatrix,
+		Columns: []*plugin.Column{
+			{
+				Name:        "id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the service (a UUID since microversion 2.53).",
+				Transform:   transform.FromField("ID"),
+			},
+			{
+				Name:        "binary",
+				Type:        proto.ColumnType_STRING,
+				Description: "The binary of the service, e.g. nova-compute or nova-scheduler.",
+				Transform:   transform.FromField("Binary"),
+			},
+			{
+				Name:        "host",
+				Type:        proto.ColumnType_STRING,
+				Description: "The host the service runs on, as in openstack_hypervisor.service_host for nova-compute services.",
+				Transform:   transform.FromField("Host"),
+			},
+			{
+				Name:        "zone",
+				Type:        proto.ColumnType_STRING,
+				Description: "The availability zone of the service.",
+				Transform:   transform.FromField("Zone"),
+			},
+			{
+				Name:        "status",
+				Type:        proto.ColumnType_STRING,
+				Description: "The administrative status of the service: enabled or disabled.",
+				Transform:   transform.FromField("Status"),
+			},
+			{
+				Name:        "state",
+				Type:        proto.ColumnType_STRING,
+				Description: "The state of the service, based on its last report: up or down.",
+				Transform:   transform.FromField("State"),
+			},
+			{
+				Name:        "forced_down",
+				Type:        proto.ColumnType_BOOL,
+				Description: "Whether the service was forced down by an operator.",
+				Transform:   transform.FromField("ForcedDown"),
+			},
+			{
+				Name:        "disabled_reason",
+				Type:        proto.ColumnType_STRING,
+				Description: "The reason the service was disabled, if any.",
+				Transform:   transform.FromField("DisabledReason").Transform(transform.NullIfZeroValue),
+			},
+			{
+				Name:        "updated_at",
+				Type:        proto.ColumnType_TIMESTAMP,
+				Description: "The time the service last reported its state.",
+				Transform:   transform.FromField("UpdatedAt").Transform(ToTime),
+			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the service belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
+		},
+		List: &plugin.ListConfig{
+			Hydrate: listOpenStackComputeService,
+			KeyColumns: plugin.KeyColumnSlice{
+				&plugin.KeyColumn{
+					Name:    "binary",
+					Require: plugin.Optional,
+				},
+				&plugin.KeyColumn{
+					Name:    "host",
+					Require: plugin.Optional,
+				},
+			},
+		},
+	}
+}
+
+//// LIST FUNCTION
+
+func listOpenStackComputeService(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	plugin.Logger(ctx).Debug("retrieving openstack compute service list", "query data", utils.ToPrettyJSON(d))
+
+	client, err := getServiceClient(ctx, d, ComputeV2)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		return nil, err
+	}
+
+	opts := buildOpenStackComputeServiceFilter(ctx, d.EqualsQuals)
+
+	err = services.List(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+		allServices, err := services.ExtractServices(page)
+		if err != nil {
+			plugin.Logger(ctx).Error("error extracting compute services", "error", err)
+			return false, err
+		}
+		plugin.Logger(ctx).Debug("compute services retrieved", "count", len(allServices))
+
+		for _, service := range allServices {
+			service := service
+			d.StreamListItem(ctx, &service)
+			if rowsRemaining(ctx, d) == 0 {
+				plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		plugin.Logger(ctx).Error("error listing compute services with options", "options", utils.ToPrettyJSON(opts), "error", err)
+		return nil, err
+	}
+	return nil, nil
+}
+
+//// UTILITY FUNCTIONS
+
+func buildOpenStackComputeServiceFilter(ctx context.Context, quals plugin.KeyColumnEqualsQualMap) services.ListOpts {
+	opts := services.ListOpts{}
+	if value, ok := quals["binary"]; ok {
+		opts.Binary = value.GetStringValue()
+	}
+	if value, ok := quals["host"]; ok {
+		opts.Host = value.GetStringValue()
+	}
+	plugin.Logger(ctx).Debug("returning", "filter", utils.ToPrettyJSON(opts))
+	return opts
+}
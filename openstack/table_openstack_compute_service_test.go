@@ -0,0 +1,59 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestListOpenStackComputeService(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackComputeService(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "host", "compute-01", "compute-02", "controller-01")
+	assertRows(t, rows, "disabled_reason", nil, "maintenance", nil)
+	assertRow(t, rows[1], testRow{
+		"id":          "9a8b7c6d-5e4f-4a3b-2c1d-0e9f8a7b6c5d",
+		"binary":      "nova-compute",
+		"zone":        "nova",
+		"status":      "disabled",
+		"state":       "down",
+		"forced_down": true,
+		"updated_at":  "2022-10-11T22:01:07Z",
+		"region":      "RegionOne",
+	})
+
+	// compute services join hypervisors on their host
+	hypervisors, err := cloud.list(tableOpenStackHypervisor(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, hypervisor := range hypervisors {
+		assertRow(t, rows[i], testRow{"host": hypervisor["service_host"], "id": hypervisor["service_id"]})
+	}
+}
+
+func TestListOpenStackComputeServiceFilters(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	_, err := cloud.list(tableOpenStackComputeService(context.Background()), testQuery{
+		quals: map[string]any{"binary": "nova-compute", "host": "compute-01"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloud.assertQuery("/compute/v2.1/os-services", url.Values{"binary": {"nova-compute"}, "host": {"compute-01"}})
+}
+
+func TestListOpenStackComputeServiceError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /compute/v2.1/os-services", http.StatusForbidden)
+
+	if _, err := cloud.list(tableOpenStackComputeService(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
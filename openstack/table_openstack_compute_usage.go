@@ -0,0 +1,368 @@
+package openstack
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/usage"
+	"github.com/gophercloud/gophercloud/pagination"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableOpenStackComputeUsage(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:              "openstack_compute_usage",
+		Description:       "OpenStack Compute Usage, with a row for the totals of each project (where instance_id is null) followed by a row for each of its instances",
+		GetMatrixItemFunc: regionMatrix,
+		Columns: []*plugin.Column{
+			{
+				Name:        "project_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the project the usage is accounted to.",
+				Transform:   transform.FromField("ProjectID"),
+			},
+			{
+				Name:        "project_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the project the usage is accounted to.",
+				Hydrate:     getProjectName,
+				Transform:   transform.FromValue(),
+			},
+			{
+				Name:        "instance_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the instance, as in openstack_instance.id; null on the rows with the totals of the project.",
+				Transform:   transform.FromField("Server.InstanceID").Transform(transform.NullIfZeroValue),
+			},
+			{
+				Name:        "instance_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the instance.",
+				Transform:   transform.FromField("Server.Name").Transform(transform.NullIfZeroValue),
+			},
+			{
+				Name:        "flavor",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the flavor of the instance.",
+				Transform:   transform.FromField("Server.Flavor").Transform(transform.NullIfZeroValue),
+			},
+			{
+				Name:        "state",
+				Type:        proto.ColumnType_STRING,
+				Description: "The state of the instance, e.g. active or terminated.",
+				Transform:   transform.FromField("Server.State").Transform(transform.NullIfZeroValue),
+			},
+			{
+				Name:        "vcpus",
+				Type:        proto.ColumnType_INT,
+				Description: "The number of virtual CPUs of the instance.",
+				Transform:   transform.FromField("Server.VCPUs"),
+			},
+			{
+				Name:        "memory_mb",
+				Type:        proto.ColumnType_INT,
+				Description: "The memory of the instance, in MiB.",
+				Transform:   transform.FromField("Server.MemoryMB"),
+			},
+			{
+				Name:        "local_gb",
+				Type:        proto.ColumnType_INT,
+				Description: "The size of the root and ephemeral disks of the instance, in GiB.",
+				Transform:   transform.FromField("Server.LocalGB"),
+			},
+			{
+				Name:        "started_at",
+				Type:        proto.ColumnType_TIMESTAMP,
+				Description: "The time the instance was created.",
+				Transform:   transform.FromField("Server.StartedAt").Transform(ToTime),
+			},
+			{
+				Name:        "ended_at",
+				Type:        proto.ColumnType_TIMESTAMP,
+				Description: "The time the instance was deleted, if it was.",
+				Transform:   transform.FromField("Server.EndedAt").Transform(ToTime),
+			},
+			{
+				Name:        "uptime",
+				Type:        proto.ColumnType_INT,
+				Description: "The time the instance has existed for, in seconds, as of the end of the window or its deletion.",
+				Transform:   transform.FromField("Server.Uptime"),
+			},
+			{
+				Name:        "total_hours",
+				Type:        proto.ColumnType_DOUBLE,
+				Description: "The hours the instance (or, on totals, the instances of the project) existed for within the window.",
+				Transform:   transform.FromField("TotalHours"),
+			},
+			{
+				Name:        "vcpu_hours",
+				Type:        proto.ColumnType_DOUBLE,
+				Description: "The virtual CPUs multiplied by the hours they existed for within the window.",
+				Transform:   transform.FromField("VCPUHours"),
+			},
+			{
+				Name:        "memory_mb_hours",
+				Type:        proto.ColumnType_DOUBLE,
+				Description: "The memory, in MiB, multiplied by the hours it existed for within the window.",
+				Transform:   transform.FromField("MemoryMBHours"),
+			},
+			{
+				Name:        "local_gb_usage",
+				Type:        proto.ColumnType_DOUBLE,
+				Description: "The disk size, in GiB, multiplied by the hours it existed for within the window.",
+				Transform:   transform.FromField("LocalGBUsage"),
+			},
+			{
+				Name:        "start",
+				Type:        proto.ColumnType_TIMESTAMP,
+				Description: "The beginning of the window, by default the beginning of the current month (UTC).",
+				Transform:   transform.FromField("Start"),
+			},
+			{
+				Name:        "end",
+				Type:        proto.ColumnType_TIMESTAMP,
+				Description: "The end of the window, by default the current time.",
+				Transform:   transform.FromField("End"),
+			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the usage was recorded in.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
+		},
+		List: &plugin.ListConfig{
+			Hydrate: listOpenStackComputeUsage,
+			KeyColumns: plugin.KeyColumnSlice{
+				&plugin.KeyColumn{
+					Name:    "project_id",
+					Require: plugin.Optional,
+				},
+				&plugin.KeyColumn{
+					Name:    "start",
+					Require: plugin.Optional,
+				},
+				&plugin.KeyColumn{
+					Name:    "end",
+					Require: plugin.Optional,
+				},
+			},
+		},
+	}
+}
+
+//// LIST FUNCTION
+
+func listOpenStackComputeUsage(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	plugin.Logger(ctx).Debug("retrieving openstack compute usage list", "query data", utils.ToPrettyJSON(d))
+
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+
+	start, end := getComputeUsageWindow(d)
+	plugin.Logger(ctx).Debug("retrieving compute usage in window", "start", start, "end", end)
+
+	allUsages := []*apiTenantUsage{}
+	if scope.AllTenants && d.EqualsQualString("project_id") == "" {
+		// the usage of all projects is retrieved at once and filtered here
+		client, err := getServiceClient(ctx, d, ComputeV2)
+		if err != nil {
+			plugin.Logger(ctx).Error("error retrieving client", "error", err)
+			return nil, err
+		}
+		opts := usage.AllTenantsOpts{
+			Detailed: true,
+			Start:    &start,
+			End:      &end,
+		}
+		err = usage.AllTenants(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+			usages := []*apiTenantUsage{}
+			if err := (page.(usage.AllTenantsPage)).ExtractIntoSlicePtr(&usages, "tenant_usages"); err != nil {
+				plugin.Logger(ctx).Error("error extracting compute usage", "error", err)
+				return false, err
+			}
+			plugin.Logger(ctx).Debug("compute usage retrieved", "count", len(usages))
+			for _, u := range usages {
+				if scope.Includes(u.TenantID) {
+					allUsages = append(allUsages, u)
+				}
+			}
+			return true, nil
+		})
+		if err != nil {
+			plugin.Logger(ctx).Error("error listing compute usage with options", "options", utils.ToPrettyJSON(opts), "error", err)
+			return nil, err
+		}
+	} else {
+		// the usage is retrieved one project at a time, like quotas
+		projectIDs, err := getProjectIDs(ctx, d, scope)
+		if err != nil {
+			plugin.Logger(ctx).Error("error retrieving projects", "error", err)
+			return nil, err
+		}
+		openstackConfig, err := getConnectionConfig(ctx, d)
+		if err != nil {
+			plugin.Logger(ctx).Error("error retrieving connection configuration", "error", err)
+			return nil, err
+		}
+		var lock sync.Mutex
+		err = forEachConcurrently(ctx, getMaxConcurrency(openstackConfig), projectIDs, func(projectID string) error {
+			client, err := getServiceClient(scope.Context(ctx, projectID), d, ComputeV2)
+			if err != nil {
+				plugin.Logger(ctx).Error("error retrieving client", "error", err)
+				return err
+			}
+			opts := usage.SingleTenantOpts{
+				Start: &start,
+				End:   &end,
+			}
+			err = usage.SingleTenant(client, projectID, opts).EachPage(func(page pagination.Page) (bool, error) {
+				u := &apiTenantUsage{}
+				if err := (page.(usage.SingleTenantPage)).ExtractIntoStructPtr(u, "tenant_usage"); err != nil {
+					plugin.Logger(ctx).Error("error extracting compute usage", "project", projectID, "error", err)
+					return false, err
+				}
+				// projects without usage in the window have an empty one
+				if u.TenantID == "" {
+					return false, nil
+				}
+				lock.Lock()
+				defer lock.Unlock()
+				allUsages = append(allUsages, u)
+				return true, nil
+			})
+			if err != nil {
+				plugin.Logger(ctx).Error("error retrieving compute usage with options", "project", projectID, "options", utils.ToPrettyJSON(opts), "error", err)
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, u := range mergeTenantUsages(allUsages) {
+		rows := []*computeUsage{{
+			ProjectID:     u.TenantID,
+			Start:         start,
+			End:           end,
+			TotalHours:    u.TotalHours,
+			VCPUHours:     u.TotalVCPUsUsage,
+			MemoryMBHours: u.TotalMemoryMBUsage,
+			LocalGBUsage:  u.TotalLocalGBUsage,
+		}}
+		for _, server := range u.ServerUsages {
+			server := server
+			rows = append(rows, &computeUsage{
+				ProjectID:     u.TenantID,
+				Start:         start,
+				End:           end,
+				Server:        &server,
+				TotalHours:    server.Hours,
+				VCPUHours:     float64(server.VCPUs) * server.Hours,
+				MemoryMBHours: float64(server.MemoryMB) * server.Hours,
+				LocalGBUsage:  float64(server.LocalGB) * server.Hours,
+			})
+		}
+		for _, row := range rows {
+			d.StreamListItem(ctx, row)
+			if rowsRemaining(ctx, d) == 0 {
+				plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+				return nil, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+//// UTILITY FUNCTIONS
+
+// apiTenantUsage is the usage of a project as returned by Nova, with the
+// timestamps of its servers in any of the formats Nova emits.
+type apiTenantUsage struct {
+	TenantID           string           `json:"tenant_id"`
+	TotalHours         float64          `json:"total_hours"`
+	TotalLocalGBUsage  float64          `json:"total_local_gb_usage"`
+	TotalMemoryMBUsage float64          `json:"total_memory_mb_usage"`
+	TotalVCPUsUsage    float64          `json:"total_vcpus_usage"`
+	ServerUsages       []apiServerUsage `json:"server_usages"`
+}
+
+// apiServerUsage is the usage of a server as returned by Nova.
+type apiServerUsage struct {
+	InstanceID string  `json:"instance_id"`
+	Name       string  `json:"name"`
+	Flavor     string  `json:"flavor"`
+	State      string  `json:"state"`
+	Hours      float64 `json:"hours"`
+	VCPUs      int     `json:"vcpus"`
+	MemoryMB   int     `json:"memory_mb"`
+	LocalGB    int     `json:"local_gb"`
+	Uptime     int     `json:"uptime"`
+	StartedAt  Time    `json:"started_at"`
+	EndedAt    Time    `json:"ended_at"`
+}
+
+// computeUsage is a row of the table: the totals of a project if Server is
+// nil, the usage of one of its servers otherwise.
+type computeUsage struct {
+	ProjectID     string
+	Start         time.Time
+	End           time.Time
+	Server        *apiServerUsage
+	TotalHours    float64
+	VCPUHours     float64
+	MemoryMBHours float64
+	LocalGBUsage  float64
+}
+
+// getComputeUsageWindow returns the window from the start and end quals,
+// defaulting to the current month (UTC) up to now; Nova takes times without
+// a time zone, in UTC. With only an end, the window starts at the beginning of
+// its month.
+func getComputeUsageWindow(d *plugin.QueryData) (time.Time, time.Time) {
+	end := time.Now().UTC()
+	if value, ok := d.EqualsQuals["end"]; ok {
+		end = value.GetTimestampValue().AsTime().UTC()
+	}
+	start := time.Date(end.Year(), end.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if value, ok := d.EqualsQuals["start"]; ok {
+		start = value.GetTimestampValue().AsTime().UTC()
+	}
+	return start, end
+}
+
+// mergeTenantUsages merges the usages of the same project, which Nova splits
+// across pages since microversion 2.40, each with the totals of the servers
+// on that page; the order of the projects is preserved.
+func mergeTenantUsages(usages []*apiTenantUsage) []*apiTenantUsage {
+	merged := []*apiTenantUsage{}
+	byProject := map[string]*apiTenantUsage{}
+	for _, u := range usages {
+		m, ok := byProject[u.TenantID]
+		if !ok {
+			m = &apiTenantUsage{TenantID: u.TenantID}
+			byProject[u.TenantID] = m
+			merged = append(merged, m)
+		}
+		m.TotalHours += u.TotalHours
+		m.TotalLocalGBUsage += u.TotalLocalGBUsage
+		m.TotalMemoryMBUsage += u.TotalMemoryMBUsage
+		m.TotalVCPUsUsage += u.TotalVCPUsUsage
+		m.ServerUsages = append(m.ServerUsages, u.ServerUsages...)
+	}
+	return merged
+}
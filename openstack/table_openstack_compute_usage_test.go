@@ -0,0 +1,173 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+)
+
+func TestListOpenStackComputeUsage(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackComputeUsage(context.Background()), testQuery{quals: map[string]any{
+		"start": time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC),
+		"end":   time.Date(2022, 10, 5, 0, 0, 0, 0, time.UTC),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the totals of each project come before its instances
+	assertRows(t, rows, "instance_id",
+		nil, "9168b536-cd40-4630-b43f-b259807c6e87", "0e1f2a3b-4c5d-4e6f-8a7b-9c0d1e2f3a4b",
+		nil, "c5c7f1b4-4c8e-4e5b-9d8e-2b1d3c4e5f60")
+	assertRows(t, rows, "project_id", TestProjectID, TestProjectID, TestProjectID, OtherProjectID, OtherProjectID)
+	assertRows(t, rows, "vcpu_hours", 204.0, 192.0, 12.0, 96.0, 96.0)
+	assertRows(t, rows, "memory_mb_hours", 208896.0, 196608.0, 12288.0, 98304.0, 98304.0)
+	assertRows(t, rows, "local_gb_usage", 2040.0, 1920.0, 120.0, 960.0, 960.0)
+	assertRows(t, rows, "total_hours", 108.0, 96.0, 12.0, 24.0, 24.0)
+	assertRow(t, rows[0], testRow{
+		"project_name":  "admin",
+		"instance_name": nil,
+		"vcpus":         nil,
+		"started_at":    nil,
+		"start":         "2022-10-01T00:00:00Z",
+		"end":           "2022-10-05T00:00:00Z",
+	})
+	assertRow(t, rows[2], testRow{
+		"instance_name": "web-00",
+		"flavor":        "m1.tiny",
+		"state":         "terminated",
+		"vcpus":         int64(1),
+		"memory_mb":     int64(1024),
+		"local_gb":      int64(10),
+		"uptime":        int64(43200),
+		"started_at":    "2022-09-30T12:00:00Z",
+		"ended_at":      "2022-10-01T12:00:00Z",
+		"start":         "2022-10-01T00:00:00Z",
+		"end":           "2022-10-05T00:00:00Z",
+		"region":        "RegionOne",
+	})
+	assertRow(t, rows[1], testRow{"ended_at": nil})
+	cloud.assertQuery("/compute/v2.1/os-simple-tenant-usage", url.Values{
+		"start":    {"2022-10-01T00:00:00"},
+		"end":      {"2022-10-05T00:00:00"},
+		"detailed": {"1"},
+	})
+
+	// instances join openstack_instance on their ID
+	instances, err := cloud.list(tableOpenStackInstance(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, rows[1], testRow{"instance_id": instances[0]["id"], "instance_name": instances[0]["name"]})
+}
+
+func TestListOpenStackComputeUsageDefaultWindow(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	before := time.Now().UTC()
+	rows, err := cloud.list(tableOpenStackComputeUsage(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := time.Now().UTC()
+
+	// the window defaults to the current month, up to now
+	start := time.Date(before.Year(), before.Month(), 1, 0, 0, 0, 0, time.UTC)
+	assertRow(t, rows[0], testRow{"start": start.Format(time.RFC3339)})
+	end, err := time.Parse(time.RFC3339, rows[0]["end"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if end.Before(before.Truncate(time.Second)) || end.After(after) {
+		t.Errorf("expected end between %s and %s, got %s", before, after, end)
+	}
+	cloud.assertQuery("/compute/v2.1/os-simple-tenant-usage", url.Values{"start": {start.Format("2006-01-02T15:04:05")}})
+
+	// with only an end, the window starts at the beginning of its month
+	if _, err = cloud.list(tableOpenStackComputeUsage(context.Background()), testQuery{quals: map[string]any{
+		"end": time.Date(2022, 10, 5, 12, 30, 0, 0, time.UTC),
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	cloud.assertQuery("/compute/v2.1/os-simple-tenant-usage", url.Values{
+		"start": {"2022-10-01T00:00:00"},
+		"end":   {"2022-10-05T12:30:00"},
+	})
+}
+
+func TestListOpenStackComputeUsageProjects(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.config.MaxConcurrency = utils.PointerTo(1)
+	// projects without usage in the window have an empty one
+	cloud.handle("GET /compute/v2.1/os-simple-tenant-usage/"+OtherProjectID, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"tenant_usage": map[string]any{}})
+	})
+	table := tableOpenStackComputeUsage(context.Background())
+
+	// with a project_id qual, the usage of the project alone is retrieved
+	rows, err := cloud.list(table, testQuery{quals: map[string]any{"project_id": TestProjectID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "vcpu_hours", 204.0, 192.0, 12.0)
+	if requests := cloud.received("/compute/v2.1/os-simple-tenant-usage"); len(requests) != 0 {
+		t.Errorf("expected no requests for the usage of all projects, got %d", len(requests))
+	}
+
+	rows, err = cloud.list(table, testQuery{quals: map[string]any{"project_id": OtherProjectID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 0 {
+		t.Errorf("expected no rows, got %v", rows)
+	}
+}
+
+// TestListOpenStackComputeUsagePages checks that the usage of a project split
+// across pages is merged, with the totals of each page added up.
+func TestListOpenStackComputeUsagePages(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	server := func(id string, hours float64) map[string]any {
+		return map[string]any{"instance_id": id, "hours": hours, "vcpus": 2, "memory_mb": 2048, "local_gb": 20}
+	}
+	cloud.handle("GET /compute/v2.1/os-simple-tenant-usage", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("marker") == "" {
+			writeJSON(w, http.StatusOK, map[string]any{
+				"tenant_usages": []map[string]any{{
+					"tenant_id": TestProjectID, "total_hours": 10.0, "total_vcpus_usage": 20.0,
+					"server_usages": []map[string]any{server("server-1", 10.0)},
+				}},
+				"tenant_usages_links": []map[string]any{{"rel": "next", "href": cloud.URL + "/compute/v2.1/os-simple-tenant-usage?detailed=1&marker=server-1"}},
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"tenant_usages": []map[string]any{{
+				"tenant_id": TestProjectID, "total_hours": 5.0, "total_vcpus_usage": 10.0,
+				"server_usages": []map[string]any{server("server-2", 5.0)},
+			}},
+		})
+	})
+
+	rows, err := cloud.list(tableOpenStackComputeUsage(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "instance_id", nil, "server-1", "server-2")
+	assertRows(t, rows, "total_hours", 15.0, 10.0, 5.0)
+	assertRows(t, rows, "vcpu_hours", 30.0, 20.0, 10.0)
+}
+
+func TestListOpenStackComputeUsageError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /compute/v2.1/os-simple-tenant-usage", http.StatusForbidden)
+
+	if _, err := cloud.list(tableOpenStackComputeUsage(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/dihedron/steampipe-plugin-utils/utils"
 	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/flavors"
+	"github.com/gophercloud/gophercloud/pagination"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -15,8 +16,9 @@ import (
 
 func tableOpenStackFlavor(_ context.Context) *plugin.Table {
 	return &plugin.Table{
-		Name:        "openstack_flavor",
-		Description: "OpenStack Flavors",
+		Name:              "openstack_flavor",
+		Description:       "OpenStack Flavors",
+		GetMatrixItemFunc: regionMatrix,
 		Columns: []*plugin.Column{
 			{
 				Name:        "id",
@@ -60,6 +62,12 @@ func tableOpenStackFlavor(_ context.Context) *plugin.Table {
 				Description: "Description is a free form description of the flavor",
 				Transform:   transform.FromField("Description"),
 			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the flavor belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
 		},
 		List: &plugin.ListConfig{
 			Hydrate: listOpenStackFlavor,
@@ -84,6 +92,7 @@ func listOpenStackFlavor(ctx context.Context, d *plugin.QueryData, h *plugin.Hyd
 	setLogLevel(ctx, d)
 
 	opts := buildOpenStackFlavorFilter(ctx, d.EqualsQuals)
+	opts.Limit = getPageSize(d)
 
 	plugin.Logger(ctx).Debug("retrieving openstack flavor list", "query data", utils.ToPrettyJSON(d))
 
@@ -93,33 +102,30 @@ func listOpenStackFlavor(ctx context.Context, d *plugin.QueryData, h *plugin.Hyd
 		return nil, err
 	}
 
-	allPages, err := flavors.ListDetail(client, opts).AllPages()
-
-	if err != nil {
-		return nil, err
-	}
-
-	if err != nil {
-		plugin.Logger(ctx).Error("error listing flavors with options", "options", utils.ToPrettyJSON(allPages), "error", err)
-		return nil, err
-	}
-	allFlavors, err := flavors.ExtractFlavors(allPages)
-	plugin.Logger(ctx).Debug("all flavor", "all_flavor", utils.ToPrettyJSON(allFlavors))
+	err = flavors.ListDetail(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+		allFlavors, err := flavors.ExtractFlavors(page)
+		plugin.Logger(ctx).Debug("all flavor", "all_flavor", utils.ToPrettyJSON(allFlavors))
+		if err != nil {
+			plugin.Logger(ctx).Error("error extracting flavors", "error", err)
+			return false, err
+		}
+		plugin.Logger(ctx).Debug("flavors retrieved", "count", len(allFlavors))
+
+		for _, flavor := range allFlavors {
+			flavor := flavor
+			plugin.Logger(ctx).Debug("flavor", "flavor", utils.ToPrettyJSON(flavor))
+			d.StreamListItem(ctx, &flavor)
+			if rowsRemaining(ctx, d) == 0 {
+				plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+				return false, nil
+			}
+		}
+		return true, nil
+	})
 	if err != nil {
-		plugin.Logger(ctx).Error("error extracting flavors", "error", err)
+		plugin.Logger(ctx).Error("error listing flavors with options", "options", utils.ToPrettyJSON(opts), "error", err)
 		return nil, err
 	}
-	plugin.Logger(ctx).Debug("flavors retrieved", "count", len(allFlavors))
-
-	for _, flavor := range allFlavors {
-		if ctx.Err() != nil {
-			plugin.Logger(ctx).Debug("context done, exit")
-			break
-		}
-		flavor := flavor
-		plugin.Logger(ctx).Debug("flavor", "flavor", utils.ToPrettyJSON(flavor))
-		d.StreamListItem(ctx, &flavor)
-	}
 	return nil, nil
 }
 
@@ -0,0 +1,76 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestListOpenStackFlavor(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackFlavor(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "m1.small", "m1.medium")
+	assertRow(t, rows[0], testRow{
+		"id":          "1",
+		"disk":        int64(20),
+		"ram":         int64(2048),
+		"vcpus":       int64(2),
+		"is_public":   true,
+		"Description": "small general purpose flavor",
+		"region":      "RegionOne",
+	})
+	assertRow(t, rows[1], testRow{"is_public": false, "Description": ""})
+	cloud.assertQuery("/compute/v2.1/flavors/detail", url.Values{"is_public": {"None"}})
+}
+
+func TestListOpenStackFlavorRegions(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.config.Regions = []string{"RegionOne", "RegionTwo"}
+
+	rows, err := cloud.list(tableOpenStackFlavor(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "region", "RegionOne", "RegionOne", "RegionTwo", "RegionTwo")
+}
+
+func TestListOpenStackFlavorLimit(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackFlavor(context.Background()), testQuery{limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "m1.small")
+	cloud.assertQuery("/compute/v2.1/flavors/detail", url.Values{"limit": {"1"}})
+}
+
+func TestListOpenStackFlavorError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /compute/v2.1/flavors/detail", http.StatusForbidden)
+
+	if _, err := cloud.list(tableOpenStackFlavor(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGetOpenStackFlavor(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	table := tableOpenStackFlavor(context.Background())
+
+	row, err := cloud.get(table, testQuery{quals: map[string]any{"id": "1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, row, testRow{"name": "m1.small", "vcpus": int64(2)})
+
+	row, err = cloud.get(table, testQuery{quals: map[string]any{"id": "42"}})
+	if err != nil || row != nil {
+		t.Errorf("expected no row and no error, got %v (error: %v)", row, err)
+	}
+}
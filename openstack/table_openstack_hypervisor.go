@@ -5,6 +5,7 @@ import (
 
 	"github.com/dihedron/steampipe-plugin-utils/utils"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/hypervisors"
+	"github.com/gophercloud/gophercloud/pagination"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -14,8 +15,9 @@ import (
 
 func tableOpenStackHypervisor(_ context.Context) *plugin.Table {
 	return &plugin.Table{
-		Name:        "openstack_hypervisor",
-		Description: "OpenStack Hypervisor",
+		Name:              "openstack_hypervisor",
+		Description:       "OpenStack Hypervisor",
+		GetMatrixItemFunc: regionMatrix,
 		Columns: []*plugin.Column{
 			{
 				Name:        "id",
@@ -143,12 +145,30 @@ func tableOpenStackHypervisor(_ context.Context) *plugin.Table {
 				Description: "The service this hypervisor represents",
 				Transform:   transform.FromField("Service"),
 			},
+			{
+				Name:        "service_host",
+				Type:        proto.ColumnType_STRING,
+				Description: "The host of the service this hypervisor represents, as in openstack_compute_service.host",
+				Transform:   transform.FromField("Service.Host"),
+			},
+			{
+				Name:        "service_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the service this hypervisor represents, as in openstack_compute_service.id",
+				Transform:   transform.FromField("Service.ID"),
+			},
 			{
 				Name:        "hypervisor_type",
 				Type:        proto.ColumnType_STRING,
 				Description: "The type of hypervisor",
 				Transform:   transform.FromField("HypervisorType"),
 			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the hypervisor belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
 		},
 		List: &plugin.ListConfig{
 			Hydrate: listOpenStackHypervisor,
@@ -181,29 +201,34 @@ func listOpenStackHypervisor(ctx context.Context, d *plugin.QueryData, h *plugin
 	}
 
 	opts := buildOpenStackHypervisorFilter(ctx, d.EqualsQuals)
+	if limit := getPageSize(d); limit > 0 {
+		opts.Limit = &limit
+	}
 
-	allPages, err := hypervisors.List(client, opts).AllPages()
+	err = hypervisors.List(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+		allHypervisors, err := hypervisors.ExtractHypervisors(page)
+		plugin.Logger(ctx).Debug("all hypervisor", "all_hypervisor", utils.ToPrettyJSON(allHypervisors))
+		if err != nil {
+			plugin.Logger(ctx).Error("error extracting hypervisors", "error", err)
+			return false, err
+		}
+		plugin.Logger(ctx).Debug("hypervisors retrieved", "count", len(allHypervisors))
+
+		for _, hypervisor := range allHypervisors {
+			hypervisor := hypervisor
+			plugin.Logger(ctx).Debug("hypervisor", "hypervisor", utils.ToPrettyJSON(hypervisor))
+			d.StreamListItem(ctx, &hypervisor)
+			if rowsRemaining(ctx, d) == 0 {
+				plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+				return false, nil
+			}
+		}
+		return true, nil
+	})
 	if err != nil {
 		plugin.Logger(ctx).Error("error listing hypervisors with options", "options", utils.ToPrettyJSON(opts), "error", err)
 		return nil, err
 	}
-	allHypervisors, err := hypervisors.ExtractHypervisors(allPages)
-	plugin.Logger(ctx).Debug("all hypervisor", "all_hypervisor", utils.ToPrettyJSON(allHypervisors))
-	if err != nil {
-		plugin.Logger(ctx).Error("error extracting hypervisors", "error", err)
-		return nil, err
-	}
-	plugin.Logger(ctx).Debug("hypervisors retrieved", "count", len(allHypervisors))
-
-	for _, hypervisor := range allHypervisors {
-		if ctx.Err() != nil {
-			plugin.Logger(ctx).Debug("context done, exit")
-			break
-		}
-		hypervisor := hypervisor
-		plugin.Logger(ctx).Debug("hypervisor", "hypervisor", utils.ToPrettyJSON(hypervisor))
-		d.StreamListItem(ctx, &hypervisor)
-	}
 	return nil, nil
 }
 
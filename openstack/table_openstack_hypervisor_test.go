@@ -0,0 +1,77 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestListOpenStackHypervisor(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackHypervisor(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "hypervisor_hostname", "compute-01.example.com", "compute-02.example.com")
+	assertRow(t, rows[0], testRow{
+		"id":                      "b1e43b5f-eec1-44e0-9f10-7b4945c0226d",
+		"host_ip":                 "10.0.0.11",
+		"state":                   "up",
+		"status":                  "enabled",
+		"hypervisor_type":         "QEMU",
+		"hypervisor_version":      "4002000",
+		"cpu_vendor":              "Intel",
+		"cpu_arch":                "x86_64",
+		"cpu_model":               "Cascadelake-Server",
+		"vcpus":                   int64(64),
+		"vcpus_used":              int64(6),
+		"memory_mb":               int64(257680),
+		"free_disk_on_hypervisor": int64(1727),
+		"local_gb":                int64(1787),
+		"running_vms":             int64(2),
+		"service":                 "{Host:compute-01 ID:4c5e9bd5-53c6-4b4f-8e0e-5d1f3a2b1c0d DisabledReason:}",
+		"service_host":            "compute-01",
+		"service_id":              "4c5e9bd5-53c6-4b4f-8e0e-5d1f3a2b1c0d",
+		"region":                  "RegionOne",
+	})
+	// older microversions return the CPU info as a string
+	assertRow(t, rows[1], testRow{"cpu_model": "Skylake-Server", "status": "disabled"})
+}
+
+func TestListOpenStackHypervisorLimit(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackHypervisor(context.Background()), testQuery{limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "hypervisor_hostname", "compute-01.example.com")
+	cloud.assertQuery("/compute/v2.1/os-hypervisors/detail", url.Values{"limit": {"1"}})
+}
+
+func TestListOpenStackHypervisorError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /compute/v2.1/os-hypervisors/detail", http.StatusForbidden)
+
+	if _, err := cloud.list(tableOpenStackHypervisor(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGetOpenStackHypervisor(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	table := tableOpenStackHypervisor(context.Background())
+
+	row, err := cloud.get(table, testQuery{quals: map[string]any{"id": "b1e43b5f-eec1-44e0-9f10-7b4945c0226d"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, row, testRow{"hypervisor_hostname": "compute-01.example.com", "cpu_arch": "x86_64"})
+
+	row, err = cloud.get(table, testQuery{quals: map[string]any{"id": "00000000-0000-0000-0000-000000000000"}})
+	if err != nil || row != nil {
+		t.Errorf("expected no row and no error, got %v (error: %v)", row, err)
+	}
+}
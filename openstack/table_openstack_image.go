@@ -3,9 +3,12 @@ package openstack
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/images"
+	"github.com/gophercloud/gophercloud/pagination"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -15,8 +18,9 @@ import (
 
 func tableOpenStackImage(_ context.Context) *plugin.Table {
 	return &plugin.Table{
-		Name:        "openstack_image",
-		Description: "OpenStack Disk Image",
+		Name:              "openstack_image",
+		Description:       "OpenStack Disk Image",
+		GetMatrixItemFunc: regionMatrix,
 		Columns: []*plugin.Column{
 			{
 				Name:        "id",
@@ -72,6 +76,13 @@ func tableOpenStackImage(_ context.Context) *plugin.Table {
 				Description: "The id of the project the image belongs to.",
 				Transform:   transform.FromField("Owner"),
 			},
+			{
+				Name:        "project_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the project the image belongs to.",
+				Hydrate:     getProjectName,
+				Transform:   transform.FromValue(),
+			},
 			{
 				Name:        "protected",
 				Type:        proto.ColumnType_BOOL,
@@ -116,13 +127,13 @@ func tableOpenStackImage(_ context.Context) *plugin.Table {
 			},
 			{
 				Name:        "created_at",
-				Type:        proto.ColumnType_STRING,
+				Type:        proto.ColumnType_TIMESTAMP,
 				Description: "Timestamp when the volume was created.",
 				Transform:   transform.FromField("CreatedAt").Transform(ToTime),
 			},
 			{
 				Name:        "updated_at",
-				Type:        proto.ColumnType_STRING,
+				Type:        proto.ColumnType_TIMESTAMP,
 				Description: "The date when this volume was last updated.",
 				Transform:   transform.FromField("UpdatedAt").Transform(ToTime),
 			},
@@ -132,6 +143,12 @@ func tableOpenStackImage(_ context.Context) *plugin.Table {
 				Description: "VirtualSize is the virtual size of the image.",
 				Transform:   transform.FromField("VirtualSize").Transform(transform.NullIfZeroValue),
 			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the disk image belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
 		},
 		List: &plugin.ListConfig{
 			Hydrate: listOpenStackImage,
@@ -156,11 +173,21 @@ func tableOpenStackImage(_ context.Context) *plugin.Table {
 					Name:    "disk_format",
 					Require: plugin.Optional,
 				},
+				&plugin.KeyColumn{
+					Name:      "created_at",
+					Operators: timeRangeOperators,
+					Require:   plugin.Optional,
+				},
+				&plugin.KeyColumn{
+					Name:      "updated_at",
+					Operators: timeRangeOperators,
+					Require:   plugin.Optional,
+				},
 			},
 		},
 		Get: &plugin.GetConfig{
 			KeyColumns: plugin.AllColumns([]string{"id", "project_id"}),
-			Hydrate:    getOpenStackImage,
+			Hydrate:    getInScope(getOpenStackImage),
 		},
 	}
 }
@@ -173,33 +200,47 @@ func listOpenStackImage(ctx context.Context, d *plugin.QueryData, h *plugin.Hydr
 
 	plugin.Logger(ctx).Debug("retrieving openstack images list", "query data", utils.ToPrettyJSON(d))
 
-	client, err := getServiceClient(ctx, d, ImageServiceV2)
+	scope, err := getProjectScope(ctx, d)
 	if err != nil {
-		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
 		return nil, err
 	}
 
 	opts := buildOpenStackImageFilter(ctx, d.EqualsQuals)
+	opts.Limit = getPageSize(d)
+	opts.CreatedAtQuery = getImageDateQuery(getTimeRange(d, "created_at"))
+	opts.UpdatedAtQuery = getImageDateQuery(getTimeRange(d, "updated_at"))
 
-	allPages, err := images.List(client, opts).AllPages()
-	if err != nil {
-		plugin.Logger(ctx).Error("error listing images with options", "options", utils.ToPrettyJSON(opts), "error", err)
-		return nil, err
-	}
-	allImages, err := images.ExtractImages(allPages)
-	if err != nil {
-		plugin.Logger(ctx).Error("error extracting images", "error", err)
-		return nil, err
-	}
-	plugin.Logger(ctx).Debug("images retrieved", "count", len(allImages))
+	err = forEachProject(ctx, d, scope, ImageServiceV2, func(client *gophercloud.ServiceClient, projectID string) error {
+		opts := opts
+		opts.Owner = projectID
 
-	for _, image := range allImages {
-		if ctx.Err() != nil {
-			plugin.Logger(ctx).Debug("context done, exit")
-			break
+		err := images.List(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+			allImages, err := images.ExtractImages(page)
+			if err != nil {
+				plugin.Logger(ctx).Error("error extracting images", "error", err)
+				return false, err
+			}
+			plugin.Logger(ctx).Debug("images retrieved", "count", len(allImages))
+
+			for _, image := range allImages {
+				image := image
+				d.StreamListItem(ctx, image)
+				if rowsRemaining(ctx, d) == 0 {
+					plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+					return false, nil
+				}
+			}
+			return true, nil
+		})
+		if err != nil {
+			plugin.Logger(ctx).Error("error listing images with options", "options", utils.ToPrettyJSON(opts), "error", err)
+			return err
 		}
-		image := image
-		d.StreamListItem(ctx, image)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return nil, nil
 }
@@ -219,6 +260,12 @@ func getOpenStackImage(ctx context.Context, d *plugin.QueryData, h *plugin.Hydra
 		return nil, err
 	}
 
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+
 	result := images.Get(client, id)
 	//plugin.Logger(ctx).Debug("request run", "result", utils.ToPrettyJSON(result))
 
@@ -229,6 +276,11 @@ func getOpenStackImage(ctx context.Context, d *plugin.QueryData, h *plugin.Hydra
 		return nil, err
 	}
 
+	if !scope.Includes(image.Owner) {
+		plugin.Logger(ctx).Debug("image not in project scope", "id", id, "project", image.Owner)
+		return nil, nil
+	}
+
 	return image, nil
 }
 func buildOpenStackImageFilter(ctx context.Context, quals plugin.KeyColumnEqualsQualMap) images.ListOpts {
@@ -268,3 +320,17 @@ func buildOpenStackImageFilter(ctx context.Context, quals plugin.KeyColumnEquals
 	plugin.Logger(ctx).Debug("returning", "filter", utils.ToPrettyJSON(opts))
 	return opts
 }
+
+// getImageDateQuery turns a time range into a Glance date filter, or nil if
+// the range is unbounded.
+func getImageDateQuery(r timeRange) *images.ImageDateQuery {
+	operator, value, ok := strings.Cut(r.Filter(), ":")
+	if !ok {
+		return nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &images.ImageDateQuery{Date: date, Filter: images.ImageDateFilter(operator)}
+}
@@ -0,0 +1,109 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestListOpenStackImage(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackImage(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "ubuntu-22.04", "cirros")
+	assertRow(t, rows[0], testRow{
+		"id":               "70a599e0-31e7-49b7-b260-868f441e862b",
+		"status":           "active",
+		"tags":             `["lts"]`,
+		"container_format": "bare",
+		"disk_format":      "qcow2",
+		"min_disk":         int64(10),
+		"image_min_ram":    int64(512),
+		"project_id":       TestProjectID,
+		"protected":        true,
+		"visibility":       "public",
+		"hidden":           false,
+		"size":             int64(2361393152),
+		"virtual_size":     int64(10737418240),
+		"created_at":       "2022-09-01T12:00:00Z",
+		"region":           "RegionOne",
+	})
+	// zero values are turned into nulls
+	assertRow(t, rows[1], testRow{"min_disk": nil, "size": nil, "hidden": true})
+}
+
+func TestListOpenStackImageFilters(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	_, err := cloud.list(tableOpenStackImage(context.Background()), testQuery{
+		quals: map[string]any{
+			"id":               "70a599e0-31e7-49b7-b260-868f441e862b",
+			"name":             "ubuntu-22.04",
+			"status":           "ACTIVE",
+			"container_format": "bare",
+			"disk_format":      "qcow2",
+		},
+		limit: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloud.assertQuery("/image/v2/images", url.Values{
+		"id":               {"70a599e0-31e7-49b7-b260-868f441e862b"},
+		"name":             {"ubuntu-22.04"},
+		"status":           {"active"},
+		"container_format": {"bare"},
+		"disk_format":      {"qcow2"},
+		"limit":            {"3"},
+	})
+}
+
+func TestListOpenStackImageError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /image/v2/images", http.StatusInternalServerError)
+
+	if _, err := cloud.list(tableOpenStackImage(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGetOpenStackImage(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	table := tableOpenStackImage(context.Background())
+
+	row, err := cloud.get(table, testQuery{quals: map[string]any{"id": "70a599e0-31e7-49b7-b260-868f441e862b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, row, testRow{"name": "ubuntu-22.04", "visibility": "public"})
+
+	row, err = cloud.get(table, testQuery{quals: map[string]any{"id": "00000000-0000-0000-0000-000000000000"}})
+	if err != nil || row != nil {
+		t.Errorf("expected no row and no error, got %v (error: %v)", row, err)
+	}
+}
+
+func TestListOpenStackImageTimeRanges(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	_, err := cloud.list(tableOpenStackImage(context.Background()), testQuery{
+		quals: map[string]any{
+			"created_at >": time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC),
+			"created_at <": time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC),
+			"updated_at <": time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Glance accepts a single filter per attribute
+	cloud.assertQuery("/image/v2/images", url.Values{
+		"created_at": {"gt:2022-09-01T00:00:00Z"},
+		"updated_at": {"lt:2022-10-01T00:00:00Z"},
+	})
+}
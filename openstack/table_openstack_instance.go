@@ -2,9 +2,14 @@ package openstack
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/pagination"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -14,9 +19,10 @@ import (
 
 func tableOpenStackInstance(_ context.Context) *plugin.Table {
 	return &plugin.Table{
-		Name:        "openstack_instance",
-		Description: "OpenStack Virtual Machine Instance",
-		Columns: []*plugin.Column{
+		Name:              "openstack_instance",
+		Description:       "OpenStack Virtual Machine Instance",
+		GetMatrixItemFunc: regionMatrix,
+		Columns: append([]*plugin.Column{
 			{
 				Name:        "id",
 				Type:        proto.ColumnType_STRING,
@@ -41,6 +47,13 @@ func tableOpenStackInstance(_ context.Context) *plugin.Table {
 				Description: "The ID of the instance's project (aka tenant)",
 				Transform:   transform.FromField("TenantID"),
 			},
+			{
+				Name:        "project_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the project the instance belongs to.",
+				Hydrate:     getProjectName,
+				Transform:   transform.FromValue(),
+			},
 			{
 				Name:        "user_id",
 				Type:        proto.ColumnType_STRING,
@@ -48,26 +61,33 @@ func tableOpenStackInstance(_ context.Context) *plugin.Table {
 				Transform:   transform.FromField("UserID"),
 			},
 			{
-				Name:        "created_at",
+				Name:        "user_name",
 				Type:        proto.ColumnType_STRING,
-				Description: "The creation time of the instance",
+				Description: "The name of the instance's user.",
+				Hydrate:     getUserName,
+				Transform:   transform.FromValue(),
+			},
+			{
+				Name:        "created_at",
+				Type:        proto.ColumnType_TIMESTAMP,
+				Description: "The creation time of the instance; unlike updated_at, filters on it are not passed on to Nova, which cannot filter on creation time",
 				Transform:   transform.FromField("CreatedAt").Transform(ToTime),
 			},
 			{
 				Name:        "launched_at",
-				Type:        proto.ColumnType_STRING,
+				Type:        proto.ColumnType_TIMESTAMP,
 				Description: "The launch time of the instance",
 				Transform:   transform.FromField("LaunchedAt").Transform(ToTime),
 			},
 			{
 				Name:        "updated_at",
-				Type:        proto.ColumnType_STRING,
+				Type:        proto.ColumnType_TIMESTAMP,
 				Description: "The update time of the instance",
 				Transform:   transform.FromField("UpdatedAt").Transform(ToTime),
 			},
 			{
 				Name:        "terminated_at",
-				Type:        proto.ColumnType_STRING,
+				Type:        proto.ColumnType_TIMESTAMP,
 				Description: "The termination time of the instance",
 				Transform:   transform.FromField("TerminatedAt").Transform(ToTime),
 			},
@@ -249,6 +269,12 @@ func tableOpenStackInstance(_ context.Context) *plugin.Table {
 				Description: "The security groups that this instance has applied",
 				Transform:   transform.FromField("SecurityGroups"),
 			},
+			{
+				Name:        "server_groups",
+				Type:        proto.ColumnType_JSON,
+				Description: "The IDs of the server groups the instance belongs to (since microversion 2.71).",
+				Transform:   transform.FromField("ServerGroups"),
+			},
 			{
 				Name:        "flavor_disk",
 				Type:        proto.ColumnType_INT,
@@ -292,6 +318,13 @@ func tableOpenStackInstance(_ context.Context) *plugin.Table {
 					return nil, nil
 				}),
 			},
+			{
+				Name:        "image_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the Glance image used to start the instance.",
+				Hydrate:     getImageName,
+				Transform:   transform.FromValue(),
+			},
 			{
 				Name:        "attached_volume_ids",
 				Type:        proto.ColumnType_JSON,
@@ -309,10 +342,16 @@ func tableOpenStackInstance(_ context.Context) *plugin.Table {
 					return nil, nil
 				}),
 			},
-		},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the virtual machine instance belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
+		}, tagColumns()...),
 		List: &plugin.ListConfig{
 			Hydrate: listOpenStackInstance,
-			KeyColumns: plugin.KeyColumnSlice{
+			KeyColumns: append(plugin.KeyColumnSlice{
 				&plugin.KeyColumn{
 					Name:    "name",
 					Require: plugin.Optional,
@@ -353,12 +392,16 @@ func tableOpenStackInstance(_ context.Context) *plugin.Table {
 					Name:    "availability_zone",
 					Require: plugin.Optional,
 				},
-				// TODO: add tags
-			},
+				&plugin.KeyColumn{
+					Name:      "updated_at",
+					Operators: timeRangeOperators,
+					Require:   plugin.Optional,
+				},
+			}, tagKeyColumns()...),
 		},
 		Get: &plugin.GetConfig{
 			KeyColumns: plugin.SingleColumn("id"),
-			Hydrate:    getOpenStackInstance,
+			Hydrate:    getInScope(getOpenStackInstance),
 		},
 	}
 }
@@ -377,29 +420,69 @@ func listOpenStackInstance(ctx context.Context, d *plugin.QueryData, h *plugin.H
 		return nil, err
 	}
 
-	opts := buildOpenStackInstanceFilter(ctx, d.EqualsQuals)
-
-	allPages, err := servers.List(client, opts).AllPages()
+	scope, err := getProjectScope(ctx, d)
 	if err != nil {
-		plugin.Logger(ctx).Error("error listing instances with options", "options", utils.ToPrettyJSON(opts), "error", err)
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
 		return nil, err
 	}
-	allInstances := []*apiInstance{}
-	err = servers.ExtractServersInto(allPages, &allInstances)
-	if err != nil {
-		plugin.Logger(ctx).Error("error extracting instances", "error", err)
+
+	opts := listInstancesOpts{ListOpts: buildOpenStackInstanceFilter(ctx, d.EqualsQuals)}
+	opts.AllTenants = scope.AllTenants
+	opts.Limit = getPageSize(d)
+
+	// older microversions would silently ignore the tag filters and return
+	// all instances
+	if !getTagFilter(d.EqualsQuals).IsEmpty() && compareMicroversions(client.Microversion, "2.26") < 0 {
+		err := fmt.Errorf("filtering instances by tag requires compute microversion 2.26 or later, using %s", client.Microversion)
+		plugin.Logger(ctx).Error("error listing instances", "error", err)
 		return nil, err
 	}
-	plugin.Logger(ctx).Debug("instances retrieved", "count", len(allInstances))
 
-	for _, instance := range allInstances {
-		if ctx.Err() != nil {
-			plugin.Logger(ctx).Debug("context done, exit")
-			break
+	// ranges on updated_at become changes-since and (since 2.66) changes-before
+	// filters; Nova then returns deleted instances too, which are skipped so
+	// that results are the same as without the filters, unless they are what
+	// the status qual asks for
+	updated := getTimeRange(d, "updated_at")
+	skipDeleted := !updated.IsEmpty() && !strings.EqualFold(d.EqualsQualString("status"), "DELETED")
+	opts.ChangesSince = updated.Since()
+	if compareMicroversions(client.Microversion, "2.66") >= 0 {
+		opts.ChangesBefore = updated.Before()
+	}
+
+	err = forEachProject(ctx, d, scope, ComputeV2, func(client *gophercloud.ServiceClient, projectID string) error {
+		opts := opts
+		opts.TenantID = projectID
+
+		err := servers.List(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+			allInstances := []*apiInstance{}
+			if err := servers.ExtractServersInto(page, &allInstances); err != nil {
+				plugin.Logger(ctx).Error("error extracting instances", "error", err)
+				return false, err
+			}
+			plugin.Logger(ctx).Debug("instances retrieved", "count", len(allInstances))
+
+			for _, instance := range allInstances {
+				instance := instance
+				if skipDeleted && instance.Status == "DELETED" {
+					continue
+				}
+				plugin.Logger(ctx).Debug("streaming instance", "data", utils.ToPrettyJSON(instance))
+				d.StreamListItem(ctx, instance)
+				if rowsRemaining(ctx, d) == 0 {
+					plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+					return false, nil
+				}
+			}
+			return true, nil
+		})
+		if err != nil {
+			plugin.Logger(ctx).Error("error listing instances with options", "options", utils.ToPrettyJSON(opts), "error", err)
+			return err
 		}
-		instance := instance
-		plugin.Logger(ctx).Debug("streaming instance", "data", utils.ToPrettyJSON(instance))
-		d.StreamListItem(ctx, instance)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return nil, nil
 }
@@ -411,6 +494,13 @@ func getOpenStackInstance(ctx context.Context, d *plugin.QueryData, h *plugin.Hy
 	setLogLevel(ctx, d)
 
 	id := d.EqualsQuals["id"].GetStringValue()
+	return getOpenStackInstanceByID(ctx, d, id)
+}
+
+// getOpenStackInstanceByID retrieves the instance with the given ID, or nil if
+// it is not in the connection's project scope.
+func getOpenStackInstanceByID(ctx context.Context, d *plugin.QueryData, id string) (interface{}, error) {
+
 	plugin.Logger(ctx).Debug("retrieving openstack instance", "id", id)
 
 	client, err := getServiceClient(ctx, d, ComputeV2)
@@ -419,6 +509,12 @@ func getOpenStackInstance(ctx context.Context, d *plugin.QueryData, h *plugin.Hy
 		return nil, err
 	}
 
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+
 	result := servers.Get(client, id)
 	plugin.Logger(ctx).Debug("API call complete", "result", utils.ToPrettyJSON(result))
 
@@ -429,13 +525,16 @@ func getOpenStackInstance(ctx context.Context, d *plugin.QueryData, h *plugin.Hy
 	}
 
 	plugin.Logger(ctx).Debug("returning instance", "data", utils.ToPrettyJSON(instance))
+	if !scope.Includes(instance.TenantID) {
+		plugin.Logger(ctx).Debug("instance not in project scope", "id", id, "project", instance.TenantID)
+		return nil, nil
+	}
+
 	return instance, nil
 }
 
 func buildOpenStackInstanceFilter(ctx context.Context, quals plugin.KeyColumnEqualsQualMap) servers.ListOpts {
-	opts := servers.ListOpts{
-		AllTenants: true,
-	}
+	opts := servers.ListOpts{}
 
 	if value, ok := quals["name"]; ok {
 		opts.Name = value.GetStringValue()
@@ -467,10 +566,32 @@ func buildOpenStackInstanceFilter(ctx context.Context, quals plugin.KeyColumnEqu
 	if value, ok := quals["availability_zone"]; ok {
 		opts.AvailabilityZone = value.GetStringValue()
 	}
+	tags := getTagFilter(quals)
+	opts.Tags = tags.Tags
+	opts.TagsAny = tags.TagsAny
+	opts.NotTags = tags.NotTags
+	opts.NotTagsAny = tags.NotTagsAny
 	plugin.Logger(ctx).Debug("returning", "filter", utils.ToPrettyJSON(opts))
 	return opts
 }
 
+// listInstancesOpts adds to servers.ListOpts the filters that gophercloud does
+// not support.
+type listInstancesOpts struct {
+	servers.ListOpts
+	// ChangesBefore selects the instances changed before (or at) the given
+	// time; it requires microversion 2.66.
+	ChangesBefore string
+}
+
+func (opts listInstancesOpts) ToServerListQuery() (string, error) {
+	query, err := opts.ListOpts.ToServerListQuery()
+	if err != nil {
+		return "", err
+	}
+	return addQueryParams(query, map[string]string{"changes-before": opts.ChangesBefore})
+}
+
 // apiInstance is an internal type used to unmarshal more datafrom the API
 // response than would usually be possible through the ordinary gophercloud
 // struct. OpenStack API microversions enable more response data that is not
@@ -510,14 +631,9 @@ type apiInstance struct {
 		Swap         int    `json:"swap"`
 		VCPUs        int    `json:"vcpus"`
 	} `json:"flavor"`
-	Addresses map[string][]struct {
-		MACAddress string `json:"OS-EXT-IPS-MAC:mac_addr"`
-		IPType     string `json:"OS-EXT-IPS:type"`
-		IPAddress  string `json:"addr"`
-		IPVersion  int    `json:"version"`
-	} `json:"addresses"`
-	Metadata map[string]string `json:"metadata"`
-	Links    []struct {
+	Addresses map[string][]apiInstanceAddress `json:"addresses"`
+	Metadata  map[string]string               `json:"metadata"`
+	Links     []struct {
 		Href string `json:"href"`
 		Rel  string `json:"rel"`
 	} `json:"links"`
@@ -549,28 +665,43 @@ type apiInstance struct {
 	//	TaskState          interface{}              `json:"OS-EXT-STS:task_state"`
 }
 
+// apiInstanceAddress is an address of an instance on one of its networks,
+// as reported by Nova.
+type apiInstanceAddress struct {
+	MACAddress string `json:"OS-EXT-IPS-MAC:mac_addr"`
+	IPType     string `json:"OS-EXT-IPS:type"`
+	IPAddress  string `json:"addr"`
+	IPVersion  int    `json:"version"`
+}
+
 //// UTILITY FUNCTIONS
 
 // Get Instance IP addresses
 func getVmIpAddresses(ctx context.Context, d *transform.TransformData) (any, error) {
 	var results []map[string]string
-	if value, ok := d.Value.(map[string][]struct {
-		MACAddress string `json:"OS-EXT-IPS-MAC:mac_addr"`
-		IPType     string `json:"OS-EXT-IPS:type"`
-		IPAddress  string `json:"addr"`
-		IPVersion  int    `json:"version"`
-	}); ok {
+	if value, ok := d.Value.(map[string][]apiInstanceAddress); ok {
 		results = make([]map[string]string, 0, len(value))
-		for k, v := range value {
-			ip := make(map[string]string, len(v))
-			for _, a := range v {
-				ip["Network"] = k
-				ip["IPAddress"] = a.IPAddress
-				ip["MACAddress"] = a.MACAddress
-				results = append(results, ip)
+		for _, network := range sortedNetworks(value) {
+			for _, a := range value[network] {
+				results = append(results, map[string]string{
+					"Network":    network,
+					"IPAddress":  a.IPAddress,
+					"MACAddress": a.MACAddress,
+				})
 			}
 		}
 		return results, nil
 	}
 	return results, nil
 }
+
+// sortedNetworks returns the names of the networks the instance has addresses
+// on, in alphabetical order so that results are stable.
+func sortedNetworks(addresses map[string][]apiInstanceAddress) []string {
+	networks := make([]string, 0, len(addresses))
+	for network := range addresses {
+		networks = append(networks, network)
+	}
+	sort.Strings(networks)
+	return networks
+}
@@ -0,0 +1,334 @@
+package openstack
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/instanceactions"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/pagination"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableOpenStackInstanceAction(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:              "openstack_instance_action",
+		Description:       "OpenStack Instance Action",
+		GetMatrixItemFunc: regionMatrix,
+		Columns: []*plugin.Column{
+			{
+				Name:        "instance_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the instance the action was performed on, as in openstack_instance.id.",
+				Transform:   transform.FromField("InstanceUUID"),
+			},
+			{
+				Name:        "action",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the action, e.g. create, reboot, resize or delete.",
+				Transform:   transform.FromField("Action"),
+			},
+			{
+				Name:        "request_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the API request that started the action.",
+				Transform:   transform.FromField("RequestID"),
+			},
+			{
+				Name:        "user_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the user who requested the action.",
+				Transform:   transform.FromField("UserID"),
+			},
+			{
+				Name:        "user_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the user who requested the action.",
+				Hydrate:     getUserName,
+				Transform:   transform.FromValue(),
+			},
+			{
+				Name:        "project_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the project the user who requested the action was scoped to, which may not be the instance's (e.g. for admins).",
+				Transform:   transform.FromField("ProjectID"),
+			},
+			{
+				Name:        "project_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the project the user who requested the action was scoped to.",
+				Hydrate:     getProjectName,
+				Transform:   transform.FromValue(),
+			},
+			{
+				Name:        "start_time",
+				Type:        proto.ColumnType_TIMESTAMP,
+				Description: "The time the action started.",
+				Transform:   transform.FromField("StartTime").Transform(ToTime),
+			},
+			{
+				Name:        "updated_at",
+				Type:        proto.ColumnType_TIMESTAMP,
+				Description: "The time the action was last updated (since microversion 2.58).",
+				Transform:   transform.FromField("UpdatedAt").Transform(ToTime),
+			},
+			{
+				Name:        "message",
+				Type:        proto.ColumnType_STRING,
+				Description: "The error message of the action, if it failed.",
+				Transform:   transform.FromField("Message").Transform(transform.NullIfZeroValue),
+			},
+			{
+				Name:        "events",
+				Type:        proto.ColumnType_JSON,
+				Description: "The events of the action, with their result; their host and hostId are returned since microversion 2.62, their details since 2.84, and events are only returned to admins before 2.51.",
+				Hydrate:     getOpenStackInstanceActionEvents,
+				Transform:   transform.FromValue(),
+			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the instance belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
+		},
+		List: &plugin.ListConfig{
+			Hydrate: listOpenStackInstanceAction,
+			KeyColumns: plugin.KeyColumnSlice{
+				&plugin.KeyColumn{
+					Name:    "instance_id",
+					Require: plugin.Optional,
+				},
+				&plugin.KeyColumn{
+					Name:      "start_time",
+					Operators: timeRangeOperators,
+					Require:   plugin.Optional,
+				},
+			},
+		},
+	}
+}
+
+//// LIST FUNCTION
+
+func listOpenStackInstanceAction(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	plugin.Logger(ctx).Debug("retrieving openstack instance action list", "query data", utils.ToPrettyJSON(d))
+
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+
+	// actions are always updated after they start, so a lower bound on
+	// start_time becomes a changes-since filter on instances and, since 2.58,
+	// on their actions; upper bounds cannot be pushed down
+	started := getTimeRange(d, "start_time")
+
+	// the actions of a single instance are retrieved directly
+	if id := d.EqualsQualString("instance_id"); id != "" {
+		get := getInScope(func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+			return getOpenStackInstanceByID(ctx, d, id)
+		})
+		instance, err := get(ctx, d, h)
+		switch {
+		case errors.As(err, &gophercloud.ErrDefault404{}) && !scope.Rescoped && scope.ProjectIDs == nil:
+			// deleted instances cannot be retrieved, but their actions can, and
+			// there is no project scope to check them against
+			plugin.Logger(ctx).Debug("instance not found, retrieving its actions anyway", "id", id)
+			err = listOpenStackInstanceActionsOf(ctx, d, id, "", started)
+		case errors.As(err, &gophercloud.ErrDefault404{}):
+			plugin.Logger(ctx).Debug("instance not found", "id", id)
+			return nil, nil
+		case err != nil:
+			plugin.Logger(ctx).Error("error retrieving instance", "id", id, "error", err)
+			return nil, err
+		case instance != nil:
+			projectID := instance.(*apiInstance).TenantID
+			err = listOpenStackInstanceActionsOf(scope.Context(ctx, projectID), d, id, projectID, started)
+		}
+		if err != nil && !errors.As(err, &gophercloud.ErrDefault404{}) {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	// otherwise the instances changed within the range (including deleted
+	// ones) are listed first, then their actions within the limit on requests
+	// in flight
+	opts := servers.ListOpts{
+		AllTenants:   scope.AllTenants,
+		ChangesSince: started.Since(),
+	}
+	var lock sync.Mutex
+	allInstances := []*apiInstance{}
+	err = forEachProject(ctx, d, scope, ComputeV2, func(client *gophercloud.ServiceClient, projectID string) error {
+		opts := opts
+		opts.TenantID = projectID
+
+		err := servers.List(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+			instances := []*apiInstance{}
+			if err := servers.ExtractServersInto(page, &instances); err != nil {
+				plugin.Logger(ctx).Error("error extracting instances", "error", err)
+				return false, err
+			}
+			plugin.Logger(ctx).Debug("instances retrieved", "count", len(instances))
+
+			lock.Lock()
+			defer lock.Unlock()
+			allInstances = append(allInstances, instances...)
+			return true, nil
+		})
+		if err != nil {
+			plugin.Logger(ctx).Error("error listing instances with options", "options", utils.ToPrettyJSON(opts), "error", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	openstackConfig, err := getConnectionConfig(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving connection configuration", "error", err)
+		return nil, err
+	}
+	err = forEachConcurrently(ctx, getMaxConcurrency(openstackConfig), allInstances, func(instance *apiInstance) error {
+		if rowsRemaining(ctx, d) == 0 {
+			return nil
+		}
+		err := listOpenStackInstanceActionsOf(scope.Context(ctx, instance.TenantID), d, instance.ID, instance.TenantID, started)
+		// the instance may have been purged in the meantime
+		if errors.As(err, &gophercloud.ErrDefault404{}) {
+			plugin.Logger(ctx).Debug("instance not found", "id", instance.ID)
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+//// HYDRATE FUNCTIONS
+
+// getOpenStackInstanceActionEvents retrieves the events of the action, which
+// are not returned when listing actions.
+func getOpenStackInstanceActionEvents(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	action := h.Item.(*apiInstanceAction)
+	plugin.Logger(ctx).Debug("retrieving openstack instance action events", "instance", action.InstanceUUID, "request", action.RequestID)
+
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+	client, err := getServiceClient(scope.Context(ctx, action.instanceProjectID), d, ComputeV2)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		return nil, err
+	}
+
+	detail := &struct {
+		Events *[]apiInstanceActionEvent `json:"events"`
+	}{}
+	if err := instanceactions.Get(client, action.InstanceUUID, action.RequestID).ExtractInto(detail); err != nil {
+		plugin.Logger(ctx).Error("error retrieving instance action", "instance", action.InstanceUUID, "request", action.RequestID, "error", err)
+		return nil, err
+	}
+	if detail.Events == nil {
+		return nil, nil
+	}
+	return *detail.Events, nil
+}
+
+//// UTILITY FUNCTIONS
+
+// apiInstanceAction is an action performed on an instance, as returned by any
+// microversion.
+type apiInstanceAction struct {
+	Action       string `json:"action"`
+	InstanceUUID string `json:"instance_uuid"`
+	RequestID    string `json:"request_id"`
+	UserID       string `json:"user_id"`
+	ProjectID    string `json:"project_id"`
+	StartTime    Time   `json:"start_time"`
+	UpdatedAt    Time   `json:"updated_at"`
+	Message      string `json:"message"`
+	// instanceProjectID is the project of the instance, whose token is used
+	// to retrieve the events when re-scoping; it is empty if unknown.
+	instanceProjectID string
+}
+
+// apiInstanceActionEvent is an event of an instance action, as returned by any
+// microversion.
+type apiInstanceActionEvent struct {
+	Event      string  `json:"event"`
+	StartTime  Time    `json:"start_time"`
+	FinishTime Time    `json:"finish_time"`
+	Result     string  `json:"result"`
+	Traceback  *string `json:"traceback,omitempty"`
+	Host       *string `json:"host,omitempty"`
+	HostID     *string `json:"hostId,omitempty"`
+	Details    *string `json:"details,omitempty"`
+}
+
+// listOpenStackInstanceActionsOf streams the actions of the given instance,
+// which belongs to the given project (if known), most recent first; before
+// microversion 2.58 the lower bound of the range cannot be pushed down, and
+// actions that started before it are skipped here.
+func listOpenStackInstanceActionsOf(ctx context.Context, d *plugin.QueryData, instanceID string, projectID string, started timeRange) error {
+	client, err := getServiceClient(ctx, d, ComputeV2)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		return err
+	}
+
+	opts := instanceactions.ListOpts{}
+	if started.From != nil && compareMicroversions(client.Microversion, "2.58") >= 0 {
+		opts.ChangesSince = utils.PointerTo(started.From.Truncate(time.Second))
+	}
+
+	err = instanceactions.List(client, instanceID, opts).EachPage(func(page pagination.Page) (bool, error) {
+		allActions := []*apiInstanceAction{}
+		if err := instanceactions.ExtractInstanceActionsInto(page, &allActions); err != nil {
+			plugin.Logger(ctx).Error("error extracting instance actions", "instance", instanceID, "error", err)
+			return false, err
+		}
+		plugin.Logger(ctx).Debug("instance actions retrieved", "instance", instanceID, "count", len(allActions))
+
+		for _, action := range allActions {
+			if started.From != nil && time.Time(action.StartTime).Before(started.From.Truncate(time.Second)) {
+				continue
+			}
+			action.instanceProjectID = projectID
+			d.StreamListItem(ctx, action)
+			if rowsRemaining(ctx, d) == 0 {
+				plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		plugin.Logger(ctx).Error("error listing instance actions with options", "instance", instanceID, "options", utils.ToPrettyJSON(opts), "error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+)
+
+func TestListOpenStackInstanceAction(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackInstanceAction(context.Background()), testQuery{quals: map[string]any{
+		"instance_id": "9168b536-cd40-4630-b43f-b259807c6e87",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "action", "reboot", "resize", "create")
+	assertRows(t, rows, "user_name", "admin", "alice", "alice")
+	assertRows(t, rows, "message", nil, "No valid host was found. There are not enough hosts available.", nil)
+	assertRow(t, rows[0], testRow{
+		"instance_id":  "9168b536-cd40-4630-b43f-b259807c6e87",
+		"request_id":   "req-7c1e4b2a-9d3f-4e5a-8b6c-0d1e2f3a4b5c",
+		"user_id":      TestUserID,
+		"project_id":   TestProjectID,
+		"project_name": "admin",
+		"start_time":   "2022-10-11T14:17:30Z",
+		"updated_at":   "2022-10-11T14:17:48Z",
+		"events":       `[{"event":"compute_reboot_instance","start_time":"2022-10-11T14:17:31Z","finish_time":"2022-10-11T14:17:48Z","result":"Success","host":"compute-01","hostId":"2091634baaccdc4c5a1d57069c833e402921df696b7f970791b12ec6"}]`,
+		"region":       "RegionOne",
+	})
+	if requests := cloud.received("/compute/v2.1/servers/detail"); len(requests) != 0 {
+		t.Errorf("expected no instances to be listed, got %d requests", len(requests))
+	}
+}
+
+func TestListOpenStackInstanceActionDeletedInstance(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	table := tableOpenStackInstanceAction(context.Background())
+
+	// deleted instances cannot be retrieved, but their actions are listed
+	rows, err := cloud.list(table, testQuery{quals: map[string]any{"instance_id": "0e1f2a3b-4c5d-4e6f-8a7b-9c0d1e2f3a4b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "action", "delete", "create")
+
+	// unknown instances have no actions
+	rows, err = cloud.list(table, testQuery{quals: map[string]any{"instance_id": "00000000-0000-0000-0000-000000000000"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 0 {
+		t.Errorf("expected no rows, got %v", rows)
+	}
+}
+
+func TestListOpenStackInstanceActionScope(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.config.Scope = utils.PointerTo(OtherProjectID)
+	table := tableOpenStackInstanceAction(context.Background())
+
+	// the actions of instances out of scope are not listed, even if the
+	// instances were deleted and their project is unknown
+	for _, id := range []string{"9168b536-cd40-4630-b43f-b259807c6e87", "0e1f2a3b-4c5d-4e6f-8a7b-9c0d1e2f3a4b"} {
+		rows, err := cloud.list(table, testQuery{quals: map[string]any{"instance_id": id}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 0 {
+			t.Errorf("%s: expected no rows, got %v", id, rows)
+		}
+	}
+
+	// Nova filters the instances by project
+	if _, err := cloud.list(table, testQuery{}); err != nil {
+		t.Fatal(err)
+	}
+	cloud.assertQuery("/compute/v2.1/servers/detail", url.Values{"tenant_id": {OtherProjectID}})
+}
+
+func TestListOpenStackInstanceActionAllInstances(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.config.MaxConcurrency = utils.PointerTo(1)
+
+	// without an instance_id, the actions of the instances changed since the
+	// lower bound of start_time are listed
+	rows, err := cloud.list(tableOpenStackInstanceAction(context.Background()), testQuery{quals: map[string]any{
+		"start_time >=": time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "action", "reboot", "resize", "create")
+	assertRows(t, rows, "instance_id",
+		"9168b536-cd40-4630-b43f-b259807c6e87", "9168b536-cd40-4630-b43f-b259807c6e87", "c5c7f1b4-4c8e-4e5b-9d8e-2b1d3c4e5f60")
+	cloud.assertQuery("/compute/v2.1/servers/detail", url.Values{"changes-since": {"2022-10-01T00:00:00Z"}, "all_tenants": {"true"}})
+	cloud.assertQuery("/compute/v2.1/servers/9168b536-cd40-4630-b43f-b259807c6e87/os-instance-actions", url.Values{"changes-since": {"2022-10-01T00:00:00Z"}})
+}
+
+func TestListOpenStackInstanceActionMicroversion(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.config.ComputeV2Microversion = utils.PointerTo("2.57")
+
+	// before 2.58 actions cannot be filtered by Nova
+	rows, err := cloud.list(tableOpenStackInstanceAction(context.Background()), testQuery{quals: map[string]any{
+		"instance_id":   "9168b536-cd40-4630-b43f-b259807c6e87",
+		"start_time >=": time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "action", "reboot", "resize")
+	cloud.assertQuery("/compute/v2.1/servers/9168b536-cd40-4630-b43f-b259807c6e87/os-instance-actions", url.Values{"changes-since": nil})
+}
+
+func TestListOpenStackInstanceActionError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /compute/v2.1/servers/9168b536-cd40-4630-b43f-b259807c6e87/os-instance-actions", http.StatusForbidden)
+
+	if _, err := cloud.list(tableOpenStackInstanceAction(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
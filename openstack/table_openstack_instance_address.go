@@ -0,0 +1,310 @@
+package openstack
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"sync"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
+	"github.com/gophercloud/gophercloud/pagination"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableOpenStackInstanceAddress(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:              "openstack_instance_address",
+		Description:       "OpenStack Instance Address",
+		GetMatrixItemFunc: regionMatrix,
+		Columns: []*plugin.Column{
+			{
+				Name:        "instance_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the instance.",
+				Transform:   transform.FromField("InstanceID"),
+			},
+			{
+				Name:        "instance_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the instance.",
+				Transform:   transform.FromField("InstanceName"),
+			},
+			{
+				Name:        "project_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the instance's project (aka tenant).",
+				Transform:   transform.FromField("ProjectID"),
+			},
+			{
+				Name:        "project_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the project the instance belongs to.",
+				Hydrate:     getProjectName,
+				Transform:   transform.FromValue(),
+			},
+			{
+				Name:        "network",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the network the address is on.",
+				Transform:   transform.FromField("Network"),
+			},
+			{
+				Name:        "mac",
+				Type:        proto.ColumnType_STRING,
+				Description: "The MAC address of the instance's interface on the network.",
+				Transform:   transform.FromField("MACAddress"),
+			},
+			{
+				Name:        "ip",
+				Type:        proto.ColumnType_IPADDR,
+				Description: "The IP address.",
+				Transform:   transform.FromField("IPAddress"),
+			},
+			{
+				Name:        "version",
+				Type:        proto.ColumnType_INT,
+				Description: "The IP version of the address (4 or 6).",
+				Transform:   transform.FromField("IPVersion"),
+			},
+			{
+				Name:        "type",
+				Type:        proto.ColumnType_STRING,
+				Description: "The type of the address: fixed or floating.",
+				Transform:   transform.FromField("IPType"),
+			},
+			{
+				Name:        "port_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the Neutron port the address is bound to (for floating addresses, the port they are associated with).",
+				Hydrate:     getOpenStackInstanceAddressPort,
+				Transform:   transform.FromValue(),
+			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the instance belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
+		},
+		List: &plugin.ListConfig{
+			Hydrate: listOpenStackInstanceAddress,
+			KeyColumns: plugin.KeyColumnSlice{
+				&plugin.KeyColumn{
+					Name:    "instance_id",
+					Require: plugin.Optional,
+				},
+				&plugin.KeyColumn{
+					Name:    "ip",
+					Require: plugin.Optional,
+				},
+				&plugin.KeyColumn{
+					Name:    "project_id",
+					Require: plugin.Optional,
+				},
+			},
+		},
+	}
+}
+
+//// LIST FUNCTION
+
+func listOpenStackInstanceAddress(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	plugin.Logger(ctx).Debug("retrieving openstack instance address list", "query data", utils.ToPrettyJSON(d))
+
+	// a single instance is retrieved directly
+	if id := d.EqualsQualString("instance_id"); id != "" {
+		get := getInScope(func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+			return getOpenStackInstanceByID(ctx, d, id)
+		})
+		instance, err := get(ctx, d, h)
+		if errors.As(err, &gophercloud.ErrDefault404{}) {
+			plugin.Logger(ctx).Debug("instance not found", "id", id)
+			return nil, nil
+		}
+		if err != nil {
+			plugin.Logger(ctx).Error("error retrieving instance", "id", id, "error", err)
+			return nil, err
+		}
+		if instance != nil {
+			streamOpenStackInstanceAddresses(ctx, d, instance.(*apiInstance))
+		}
+		return nil, nil
+	}
+
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+
+	opts := servers.ListOpts{
+		AllTenants: scope.AllTenants,
+	}
+	// Nova matches the ip and ip6 filters as regular expressions against
+	// each address of the instances
+	if ip := getQualIP(d, "ip"); ip != nil {
+		filter := "^" + regexp.QuoteMeta(ip.String()) + "$"
+		if ip.To4() != nil {
+			opts.IP = filter
+		} else {
+			opts.IP6 = filter
+		}
+	}
+
+	err = forEachProject(ctx, d, scope, ComputeV2, func(client *gophercloud.ServiceClient, projectID string) error {
+		opts := opts
+		opts.TenantID = projectID
+
+		err := servers.List(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+			allInstances := []*apiInstance{}
+			if err := servers.ExtractServersInto(page, &allInstances); err != nil {
+				plugin.Logger(ctx).Error("error extracting instances", "error", err)
+				return false, err
+			}
+			plugin.Logger(ctx).Debug("instances retrieved", "count", len(allInstances))
+
+			for _, instance := range allInstances {
+				if !streamOpenStackInstanceAddresses(ctx, d, instance) {
+					return false, nil
+				}
+			}
+			return true, nil
+		})
+		if err != nil {
+			plugin.Logger(ctx).Error("error listing instances with options", "options", utils.ToPrettyJSON(opts), "error", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+//// HYDRATE FUNCTIONS
+
+// getOpenStackInstanceAddressPort returns the ID of the port of the instance
+// with the address's MAC address; the ports of each instance are listed once
+// for all its addresses, with the token of the instance's project, since
+// ports are only visible within it.
+func getOpenStackInstanceAddressPort(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	address := h.Item.(*instanceAddress)
+	plugin.Logger(ctx).Debug("retrieving openstack instance address port", "instance", address.InstanceID, "mac", address.MACAddress)
+
+	address.instancePorts.once.Do(func() {
+		address.instancePorts.ports, address.instancePorts.err = listOpenStackInstancePorts(ctx, d, address.InstanceID, address.ProjectID)
+	})
+	allPorts, err := address.instancePorts.ports, address.instancePorts.err
+	if err != nil {
+		return nil, err
+	}
+	for _, port := range allPorts {
+		if port.DeviceID == address.InstanceID && port.MACAddress == address.MACAddress {
+			return port.ID, nil
+		}
+	}
+	return nil, nil
+}
+
+//// UTILITY FUNCTIONS
+
+// instanceAddress is an address of an instance on one of its networks.
+type instanceAddress struct {
+	apiInstanceAddress
+	InstanceID   string
+	InstanceName string
+	ProjectID    string
+	Network      string
+	// instancePorts is shared by all the addresses of the instance.
+	instancePorts *instancePorts
+}
+
+// instancePorts holds the ports of an instance, listed at most once.
+type instancePorts struct {
+	once  sync.Once
+	ports []ports.Port
+	err   error
+}
+
+// listOpenStackInstancePorts returns the ports attached to the instance.
+func listOpenStackInstancePorts(ctx context.Context, d *plugin.QueryData, instanceID string, projectID string) ([]ports.Port, error) {
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+	client, err := getServiceClient(scope.Context(ctx, projectID), d, NetworkV2)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		return nil, err
+	}
+
+	opts := ports.ListOpts{
+		DeviceID: instanceID,
+	}
+	allPages, err := ports.List(client, opts).AllPages()
+	if err != nil {
+		plugin.Logger(ctx).Error("error listing ports with options", "options", utils.ToPrettyJSON(opts), "error", err)
+		return nil, err
+	}
+	allPorts, err := ports.ExtractPorts(allPages)
+	if err != nil {
+		plugin.Logger(ctx).Error("error extracting ports", "error", err)
+		return nil, err
+	}
+	plugin.Logger(ctx).Debug("instance ports retrieved", "instance", instanceID, "count", len(allPorts))
+	return allPorts, nil
+}
+
+// streamOpenStackInstanceAddresses streams a row for each address of the
+// instance, on every network; it returns false when no more rows are needed.
+func streamOpenStackInstanceAddresses(ctx context.Context, d *plugin.QueryData, instance *apiInstance) bool {
+	instancePorts := &instancePorts{}
+	for _, network := range sortedNetworks(instance.Addresses) {
+		for _, address := range instance.Addresses[network] {
+			item := &instanceAddress{
+				apiInstanceAddress: address,
+				InstanceID:         instance.ID,
+				InstanceName:       instance.Name,
+				ProjectID:          instance.TenantID,
+				Network:            network,
+				instancePorts:      instancePorts,
+			}
+			plugin.Logger(ctx).Debug("streaming instance address", "data", utils.ToPrettyJSON(item))
+			d.StreamListItem(ctx, item)
+			if rowsRemaining(ctx, d) == 0 {
+				plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// getQualIP returns the IP address in the given qual, which Postgres passes as
+// a single-host CIDR, or nil if there is none.
+func getQualIP(d *plugin.QueryData, column string) net.IP {
+	value := d.EqualsQualString(column)
+	if ip, network, err := net.ParseCIDR(value); err == nil {
+		if ones, bits := network.Mask.Size(); ones == bits {
+			return ip
+		}
+		return nil
+	}
+	return net.ParseIP(value)
+}
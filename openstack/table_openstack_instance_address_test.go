@@ -0,0 +1,79 @@
+package openstack
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"testing"
+)
+
+func TestListOpenStackInstanceAddress(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackInstanceAddress(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// one row per address, including the dual-stack ones on the same NIC
+	assertRows(t, rows, "ip", "192.168.0.3", "fd00::f816:3eff:fe4c:2c30", "203.0.113.10")
+	assertRows(t, rows, "version", int64(4), int64(6), int64(4))
+	assertRows(t, rows, "type", "fixed", "fixed", "floating")
+	assertRows(t, rows, "port_id", "d80b1a3b-4fc1-49f3-952e-1e2ab7081d8b", "d80b1a3b-4fc1-49f3-952e-1e2ab7081d8b", "d80b1a3b-4fc1-49f3-952e-1e2ab7081d8b")
+	assertRow(t, rows[0], testRow{
+		"instance_id":   "9168b536-cd40-4630-b43f-b259807c6e87",
+		"instance_name": "web-01",
+		"project_id":    TestProjectID,
+		"project_name":  "admin",
+		"network":       "private",
+		"mac":           "fa:16:3e:4c:2c:30",
+		"port_id":       "d80b1a3b-4fc1-49f3-952e-1e2ab7081d8b",
+		"region":        "RegionOne",
+	})
+	// the ports are listed once for all the addresses of the instance
+	if requests := cloud.received("/network/v2.0/ports"); len(requests) != 1 {
+		t.Errorf("expected one request for the ports, got %d", len(requests))
+	}
+	cloud.assertQuery("/network/v2.0/ports", url.Values{
+		"device_id":   {"9168b536-cd40-4630-b43f-b259807c6e87"},
+		"mac_address": nil,
+	})
+}
+
+func TestListOpenStackInstanceAddressIP(t *testing.T) {
+	var tests = []struct {
+		ip       string
+		expected url.Values
+	}{
+		{ip: "203.0.113.10", expected: url.Values{"ip": {`^203\.0\.113\.10$`}}},
+		{ip: "fd00::f816:3eff:fe4c:2c30", expected: url.Values{"ip6": {`^fd00::f816:3eff:fe4c:2c30$`}}},
+	}
+
+	for _, test := range tests {
+		cloud := newFakeOpenStack(t)
+		if _, err := cloud.list(tableOpenStackInstanceAddress(context.Background()), testQuery{quals: map[string]any{"ip": net.ParseIP(test.ip)}}); err != nil {
+			t.Errorf("%s: unexpected error: %v", test.ip, err)
+			continue
+		}
+		cloud.assertQuery("/compute/v2.1/servers/detail", test.expected)
+	}
+}
+
+func TestListOpenStackInstanceAddressInstanceID(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	table := tableOpenStackInstanceAddress(context.Background())
+
+	rows, err := cloud.list(table, testQuery{quals: map[string]any{"instance_id": "9168b536-cd40-4630-b43f-b259807c6e87"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "ip", "192.168.0.3")
+	if requests := cloud.received("/compute/v2.1/servers/detail"); len(requests) != 0 {
+		t.Errorf("expected no requests for the instance list, got %d", len(requests))
+	}
+
+	// missing instances result in no rows rather than errors
+	rows, err = cloud.list(table, testQuery{quals: map[string]any{"instance_id": "00000000-0000-0000-0000-000000000000"}})
+	if err != nil || len(rows) != 0 {
+		t.Errorf("expected no rows and no error, got %v (error: %v)", rows, err)
+	}
+}
@@ -0,0 +1,177 @@
+package openstack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableOpenStackInstanceConsoleLog(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:              "openstack_instance_console_log",
+		Description:       "OpenStack Instance Console Log, one row per line",
+		GetMatrixItemFunc: regionMatrix,
+		Columns: []*plugin.Column{
+			{
+				Name:        "instance_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the instance, as in openstack_instance.id.",
+				Transform:   transform.FromField("InstanceID"),
+			},
+			{
+				Name:        "line_number",
+				Type:        proto.ColumnType_INT,
+				Description: "The number of the line within the returned output, starting from 1.",
+				Transform:   transform.FromField("LineNumber"),
+			},
+			{
+				Name:        "line",
+				Type:        proto.ColumnType_STRING,
+				Description: "The text of the line, without the line terminator.",
+				Transform:   transform.FromField("Line"),
+			},
+			{
+				Name:        "length",
+				Type:        proto.ColumnType_INT,
+				Description: "The number of lines requested from the end of the console log, if any; by default, or with -1, the whole log is returned.",
+				Transform:   transform.FromField("Length"),
+			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the instance belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
+		},
+		List: &plugin.ListConfig{
+			Hydrate: listOpenStackInstanceConsoleLog,
+			KeyColumns: plugin.KeyColumnSlice{
+				&plugin.KeyColumn{
+					Name:    "instance_id",
+					Require: plugin.Required,
+				},
+				&plugin.KeyColumn{
+					Name:    "length",
+					Require: plugin.Optional,
+				},
+			},
+		},
+	}
+}
+
+//// LIST FUNCTION
+
+func listOpenStackInstanceConsoleLog(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	plugin.Logger(ctx).Debug("retrieving openstack instance console log", "query data", utils.ToPrettyJSON(d))
+
+	// Nova takes -1 for the whole log, and would return it for 0 too since
+	// gophercloud leaves zero lengths out, so no lines are requested then
+	opts := servers.ShowConsoleOutputOpts{}
+	var length *int64
+	if value, ok := d.EqualsQuals["length"]; ok {
+		length = utils.PointerTo(value.GetInt64Value())
+		if *length < -1 {
+			err := fmt.Errorf("invalid length %d, must be positive or -1 for the whole log", *length)
+			plugin.Logger(ctx).Error("error retrieving console log", "error", err)
+			return nil, err
+		}
+		if *length == 0 {
+			return nil, nil
+		}
+		opts.Length = int(*length)
+	}
+
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+
+	// the instance is retrieved first to check it is in scope and to use the
+	// token of its project when re-scoping
+	id := d.EqualsQualString("instance_id")
+	get := getInScope(func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+		return getOpenStackInstanceByID(ctx, d, id)
+	})
+	instance, err := get(ctx, d, h)
+	if errors.As(err, &gophercloud.ErrDefault404{}) {
+		plugin.Logger(ctx).Debug("instance not found", "id", id)
+		return nil, nil
+	}
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving instance", "id", id, "error", err)
+		return nil, err
+	}
+	if instance == nil {
+		return nil, nil
+	}
+
+	client, err := getServiceClient(scope.Context(ctx, instance.(*apiInstance).TenantID), d, ComputeV2)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		return nil, err
+	}
+
+	output, err := servers.ShowConsoleOutput(client, id, opts).Extract()
+	// instances that were never scheduled or whose console is not available
+	// (e.g. while building or shelved) have no log, which must not fail
+	// queries across many instances
+	if errors.As(err, &gophercloud.ErrDefault404{}) || errors.As(err, &gophercloud.ErrDefault409{}) {
+		plugin.Logger(ctx).Debug("console log not available", "id", id, "error", err)
+		return nil, nil
+	}
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving console log with options", "id", id, "options", utils.ToPrettyJSON(opts), "error", err)
+		return nil, err
+	}
+
+	for i, line := range splitConsoleLog(output) {
+		d.StreamListItem(ctx, &consoleLogLine{
+			InstanceID: id,
+			LineNumber: i + 1,
+			Line:       line,
+			Length:     length,
+		})
+		if rowsRemaining(ctx, d) == 0 {
+			plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+			return nil, nil
+		}
+	}
+	return nil, nil
+}
+
+//// UTILITY FUNCTIONS
+
+// consoleLogLine is a line of the console log of an instance.
+type consoleLogLine struct {
+	InstanceID string
+	LineNumber int
+	Line       string
+	Length     *int64
+}
+
+// splitConsoleLog splits the console log into lines, accepting both Unix and
+// DOS line terminators; a terminator at the end does not start a new line.
+func splitConsoleLog(output string) []string {
+	if output == "" {
+		return []string{}
+	}
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
@@ -0,0 +1,143 @@
+package openstack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+)
+
+const consoleLogPath = "/compute/v2.1/servers/9168b536-cd40-4630-b43f-b259807c6e87/action"
+
+func TestListOpenStackInstanceConsoleLog(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	var requests []map[string]map[string]any
+	cloud.handle("POST "+consoleLogPath, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, body)
+		writeJSON(w, http.StatusOK, map[string]any{
+			"output": "[    0.000000] Linux version 5.15.0-52-generic\r\n[    2.871234] Kernel panic - not syncing: VFS: Unable to mount root fs\n\ncloud-init: failed\n",
+		})
+	})
+	table := tableOpenStackInstanceConsoleLog(context.Background())
+
+	rows, err := cloud.list(table, testQuery{quals: map[string]any{"instance_id": "9168b536-cd40-4630-b43f-b259807c6e87"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "line",
+		"[    0.000000] Linux version 5.15.0-52-generic",
+		"[    2.871234] Kernel panic - not syncing: VFS: Unable to mount root fs",
+		"",
+		"cloud-init: failed")
+	assertRows(t, rows, "line_number", int64(1), int64(2), int64(3), int64(4))
+	assertRow(t, rows[0], testRow{
+		"instance_id": "9168b536-cd40-4630-b43f-b259807c6e87",
+		"length":      nil,
+		"region":      "RegionOne",
+	})
+
+	rows, err = cloud.list(table, testQuery{quals: map[string]any{"instance_id": "9168b536-cd40-4630-b43f-b259807c6e87", "length": int64(50)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, rows[0], testRow{"length": int64(50)})
+
+	rows, err = cloud.list(table, testQuery{quals: map[string]any{"instance_id": "9168b536-cd40-4630-b43f-b259807c6e87", "length": int64(-1)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, rows[0], testRow{"length": int64(-1)})
+
+	// no lines are requested with a zero length, and negative lengths other
+	// than -1 are invalid
+	rows, err = cloud.list(table, testQuery{quals: map[string]any{"instance_id": "9168b536-cd40-4630-b43f-b259807c6e87", "length": int64(0)}})
+	if err != nil || len(rows) != 0 {
+		t.Errorf("expected no rows and no error with length 0, got %v (error: %v)", rows, err)
+	}
+	if _, err := cloud.list(table, testQuery{quals: map[string]any{"instance_id": "9168b536-cd40-4630-b43f-b259807c6e87", "length": int64(-2)}}); err == nil {
+		t.Error("expected error with length -2")
+	}
+
+	expected := []map[string]map[string]any{
+		{"os-getConsoleOutput": {}},
+		{"os-getConsoleOutput": {"length": 50.0}},
+		{"os-getConsoleOutput": {"length": -1.0}},
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("expected requests %v, got %v", expected, requests)
+	}
+}
+
+func TestListOpenStackInstanceConsoleLogNotAvailable(t *testing.T) {
+	table := tableOpenStackInstanceConsoleLog(context.Background())
+	for _, status := range []int{http.StatusNotFound, http.StatusConflict} {
+		cloud := newFakeOpenStack(t)
+		cloud.fail("POST "+consoleLogPath, status)
+
+		rows, err := cloud.list(table, testQuery{quals: map[string]any{"instance_id": "9168b536-cd40-4630-b43f-b259807c6e87"}})
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", status, err)
+		}
+		if len(rows) != 0 {
+			t.Errorf("%d: expected no rows, got %v", status, rows)
+		}
+	}
+
+	// unknown instances have no log
+	cloud := newFakeOpenStack(t)
+	rows, err := cloud.list(table, testQuery{quals: map[string]any{"instance_id": "00000000-0000-0000-0000-000000000000"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 0 {
+		t.Errorf("expected no rows, got %v", rows)
+	}
+}
+
+func TestListOpenStackInstanceConsoleLogScope(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.config.Scope = utils.PointerTo(OtherProjectID)
+
+	rows, err := cloud.list(tableOpenStackInstanceConsoleLog(context.Background()), testQuery{quals: map[string]any{"instance_id": "9168b536-cd40-4630-b43f-b259807c6e87"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 0 {
+		t.Errorf("expected no rows, got %v", rows)
+	}
+	if requests := cloud.received(consoleLogPath); len(requests) != 0 {
+		t.Errorf("expected no console log requests for instances out of scope, got %d", len(requests))
+	}
+}
+
+func TestListOpenStackInstanceConsoleLogError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("POST "+consoleLogPath, http.StatusInternalServerError)
+
+	if _, err := cloud.list(tableOpenStackInstanceConsoleLog(context.Background()), testQuery{quals: map[string]any{"instance_id": "9168b536-cd40-4630-b43f-b259807c6e87"}}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestSplitConsoleLog(t *testing.T) {
+	var tests = []struct {
+		output   string
+		expected []string
+	}{
+		{output: "", expected: []string{}},
+		{output: "\n", expected: []string{""}},
+		{output: "one", expected: []string{"one"}},
+		{output: "one\ntwo\n", expected: []string{"one", "two"}},
+		{output: "one\r\n\r\ntwo", expected: []string{"one", "", "two"}},
+	}
+	for _, test := range tests {
+		if actual := splitConsoleLog(test.output); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%q: expected %q, got %q", test.output, test.expected, actual)
+		}
+	}
+}
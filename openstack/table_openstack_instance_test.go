@@ -0,0 +1,214 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+)
+
+func TestListOpenStackInstance(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackInstance(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "web-01", "db-01")
+	assertRow(t, rows[0], testRow{
+		"id":                  "9168b536-cd40-4630-b43f-b259807c6e87",
+		"project_id":          TestProjectID,
+		"project_name":        "admin",
+		"user_name":           "admin",
+		"created_at":          "2022-09-24T13:53:23Z",
+		"launched_at":         "2022-09-24T13:54:01Z",
+		"terminated_at":       nil,
+		"status":              "ACTIVE",
+		"power_state_id":      int64(1),
+		"power_state_name":    "RUNNING",
+		"availability_zone":   "nova",
+		"hypervisor_hostname": "compute-01.example.com",
+		"flavor_name":         "m1.small",
+		"flavor_vcpus":        int64(2),
+		"flavor_ram":          int64(2048),
+		"flavor_cores":        int64(2),
+		"flavor_vgpus":        nil,
+		"flavor_rng_allowed":  true,
+		"image_id":            "70a599e0-31e7-49b7-b260-868f441e862b",
+		"image_name":          "ubuntu-22.04",
+		"attached_volume_ids": `["521752a6-acf6-4b2d-bc7a-119f9148cd8c"]`,
+		"addresses":           `[{"IPAddress":"192.168.0.3","MACAddress":"fa:16:3e:4c:2c:30","Network":"private"},{"IPAddress":"fd00::f816:3eff:fe4c:2c30","MACAddress":"fa:16:3e:4c:2c:30","Network":"private"},{"IPAddress":"203.0.113.10","MACAddress":"fa:16:3e:4c:2c:30","Network":"private"}]`,
+		"tags":                `["production","web"]`,
+		"security_groups":     `[{"name":"default"}]`,
+		"region":              "RegionOne",
+	})
+	assertRow(t, rows[1], testRow{
+		"power_state_name": "SHUTDOWN",
+		"image_id":         nil,
+		"flavor_cores":     nil,
+	})
+	// all tenants are always listed
+	cloud.assertQuery("/compute/v2.1/servers/detail", url.Values{"all_tenants": {"true"}})
+}
+
+func TestListOpenStackInstanceFilters(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	_, err := cloud.list(tableOpenStackInstance(context.Background()), testQuery{
+		quals: map[string]any{
+			"name":              "web-01",
+			"host_id":           "compute-01",
+			"status":            "ACTIVE",
+			"flavor_name":       "m1.small",
+			"project_id":        TestProjectID,
+			"user_id":           "5d3c1a2b0e5f4e8b9c6a7d8e9f0a1b2c",
+			"availability_zone": "nova",
+		},
+		limit: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloud.assertQuery("/compute/v2.1/servers/detail", url.Values{
+		"name":              {"web-01"},
+		"host":              {"compute-01"},
+		"status":            {"ACTIVE"},
+		"flavor":            {"m1.small"},
+		"tenant_id":         {TestProjectID},
+		"user_id":           {"5d3c1a2b0e5f4e8b9c6a7d8e9f0a1b2c"},
+		"availability_zone": {"nova"},
+		"limit":             {"10"},
+	})
+}
+
+func TestListOpenStackInstanceLimit(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackInstance(context.Background()), testQuery{limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "web-01")
+	cloud.assertQuery("/compute/v2.1/servers/detail", url.Values{"limit": {"1"}})
+}
+
+func TestListOpenStackInstanceError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /compute/v2.1/servers/detail", http.StatusInternalServerError)
+
+	if _, err := cloud.list(tableOpenStackInstance(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGetOpenStackInstance(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	table := tableOpenStackInstance(context.Background())
+
+	row, err := cloud.get(table, testQuery{quals: map[string]any{"id": "9168b536-cd40-4630-b43f-b259807c6e87"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, row, testRow{"name": "web-01", "flavor_disk": int64(20), "server_groups": `["3f2a1b4c-5d6e-4f70-8a9b-0c1d2e3f4a5b"]`, "region": "RegionOne"})
+
+	// missing instances result in no rows rather than errors
+	row, err = cloud.get(table, testQuery{quals: map[string]any{"id": "00000000-0000-0000-0000-000000000000"}})
+	if err != nil || row != nil {
+		t.Errorf("expected no row and no error, got %v (error: %v)", row, err)
+	}
+}
+
+func TestListOpenStackInstanceUpdatedAt(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	// Nova also returns instances deleted in the time range
+	cloud.handle("GET /compute/v2.1/servers/detail", func(w http.ResponseWriter, r *http.Request) {
+		allServers := []any{}
+		for _, server := range []map[string]any{
+			{"id": "0d5a9c1e-2f3b-4c4d-8e5f-6a7b8c9d0e1f", "name": "gone", "status": "DELETED"},
+			{"id": "9168b536-cd40-4630-b43f-b259807c6e87", "name": "web-01", "status": "ACTIVE"},
+		} {
+			if status := r.URL.Query().Get("status"); status == "" || status == server["status"] {
+				allServers = append(allServers, server)
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"servers": allServers})
+	})
+
+	rows, err := cloud.list(tableOpenStackInstance(context.Background()), testQuery{
+		quals: map[string]any{
+			"updated_at >=": time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC),
+			"updated_at <":  time.Date(2022, 10, 8, 12, 30, 0, 0, time.UTC),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "web-01")
+	cloud.assertQuery("/compute/v2.1/servers/detail", url.Values{
+		"changes-since":  {"2022-10-01T00:00:00Z"},
+		"changes-before": {"2022-10-08T12:30:00Z"},
+	})
+
+	// deleted instances are kept when they are what the query asks for
+	rows, err = cloud.list(tableOpenStackInstance(context.Background()), testQuery{
+		quals: map[string]any{
+			"status":        "DELETED",
+			"updated_at >=": time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "gone")
+	cloud.assertQuery("/compute/v2.1/servers/detail", url.Values{
+		"status":        {"DELETED"},
+		"changes-since": {"2022-10-01T00:00:00Z"},
+	})
+
+	// changes-before requires microversion 2.66
+	cloud = newFakeOpenStack(t)
+	cloud.config.ComputeV2Microversion = utils.PointerTo("2.65")
+	if _, err := cloud.list(tableOpenStackInstance(context.Background()), testQuery{
+		quals: map[string]any{"updated_at <": time.Date(2022, 10, 8, 0, 0, 0, 0, time.UTC)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	cloud.assertQuery("/compute/v2.1/servers/detail", url.Values{"changes-before": nil, "changes-since": nil})
+}
+
+func TestListOpenStackInstanceTags(t *testing.T) {
+	query := testQuery{
+		quals: map[string]any{
+			"tags_all":     "production, web",
+			"tags_any":     "team-x,team-y",
+			"not_tags":     "deprecated",
+			"not_tags_any": "test,staging",
+		},
+	}
+
+	cloud := newFakeOpenStack(t)
+	rows, err := cloud.list(tableOpenStackInstance(context.Background()), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, rows[0], testRow{"tags_all": "production, web", "not_tags": "deprecated"})
+	cloud.assertQuery("/compute/v2.1/servers/detail", url.Values{
+		"tags":         {"production,web"},
+		"tags-any":     {"team-x,team-y"},
+		"not-tags":     {"deprecated"},
+		"not-tags-any": {"test,staging"},
+	})
+
+	// older microversions would ignore the filters
+	cloud = newFakeOpenStack(t)
+	cloud.config.ComputeV2Microversion = utils.PointerTo("2.25")
+	if _, err := cloud.list(tableOpenStackInstance(context.Background()), query); err == nil {
+		t.Error("expected error")
+	}
+	if requests := cloud.received("/compute/v2.1/servers/detail"); len(requests) != 0 {
+		t.Errorf("expected no requests, got %d", len(requests))
+	}
+}
@@ -0,0 +1,419 @@
+package openstack
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/keypairs"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/tokens"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/users"
+	"github.com/gophercloud/gophercloud/pagination"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableOpenStackKeypair(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:              "openstack_keypair",
+		Description:       "OpenStack Key Pair",
+		GetMatrixItemFunc: regionMatrix,
+		Columns: []*plugin.Column{
+			{
+				Name:        "name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the key pair, unique for each user.",
+				Transform:   transform.FromField("Name"),
+			},
+			{
+				Name:        "user_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the user owning the key pair.",
+				Transform:   transform.FromField("UserID"),
+			},
+			{
+				Name:        "user_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the user owning the key pair.",
+				Hydrate:     getUserName,
+				Transform:   transform.FromValue(),
+			},
+			{
+				Name:        "fingerprint",
+				Type:        proto.ColumnType_STRING,
+				Description: "The fingerprint of the public key.",
+				Transform:   transform.FromField("Fingerprint"),
+			},
+			{
+				Name:        "public_key",
+				Type:        proto.ColumnType_STRING,
+				Description: "The public key, in OpenSSH format for ssh key pairs and as a PEM certificate for x509 ones.",
+				Transform:   transform.FromField("PublicKey"),
+			},
+			{
+				Name:        "type",
+				Type:        proto.ColumnType_STRING,
+				Description: "The type of the key pair: ssh or x509.",
+				Transform:   transform.FromField("Type"),
+			},
+			{
+				Name:        "key_algorithm",
+				Type:        proto.ColumnType_STRING,
+				Description: "The algorithm of the public key (rsa, dsa, ecdsa, ed25519, ecdsa-sk or ed25519-sk), computed from the key itself.",
+				Transform:   transform.FromField("KeyAlgorithm").Transform(transform.NullIfZeroValue),
+			},
+			{
+				Name:        "key_bits",
+				Type:        proto.ColumnType_INT,
+				Description: "The size of the public key in bits (the modulus for rsa and dsa keys), computed from the key itself.",
+				Transform:   transform.FromField("KeyBits").Transform(transform.NullIfZeroValue),
+			},
+			{
+				Name:        "created_at",
+				Type:        proto.ColumnType_TIMESTAMP,
+				Description: "The creation time of the key pair.",
+				Hydrate:     getOpenStackKeypairDetail,
+				Transform:   transform.FromField("CreatedAt").Transform(ToTime),
+			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the key pair belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
+		},
+		List: &plugin.ListConfig{
+			Hydrate: listOpenStackKeypair,
+			KeyColumns: plugin.KeyColumnSlice{
+				&plugin.KeyColumn{
+					Name:    "user_id",
+					Require: plugin.Optional,
+				},
+				&plugin.KeyColumn{
+					Name:    "name",
+					Require: plugin.Optional,
+				},
+			},
+		},
+	}
+}
+
+//// LIST FUNCTION
+
+func listOpenStackKeypair(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	plugin.Logger(ctx).Debug("retrieving openstack key pair list", "query data", utils.ToPrettyJSON(d))
+
+	client, err := getServiceClient(ctx, d, ComputeV2)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		return nil, err
+	}
+
+	currentUserID, err := getCurrentUserID(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving current user", "error", err)
+		return nil, err
+	}
+
+	// key pairs are listed one user at a time: the given one or, failing
+	// that, all those in Keystone, falling back to the current user if they
+	// cannot be listed
+	userIDs := []string{d.EqualsQualString("user_id")}
+	if userIDs[0] == "" {
+		userIDs, err = getKeypairUserIDs(ctx, d, currentUserID)
+		if err != nil {
+			plugin.Logger(ctx).Error("error retrieving users", "error", err)
+			return nil, err
+		}
+	}
+
+	// before 2.10 the user_id parameter is silently ignored and the key pairs
+	// of the current user returned instead
+	if compareMicroversions(client.Microversion, "2.10") < 0 {
+		if d.EqualsQualString("user_id") != "" && d.EqualsQualString("user_id") != currentUserID {
+			err := fmt.Errorf("listing the key pairs of other users requires compute microversion 2.10 or later, using %s", client.Microversion)
+			plugin.Logger(ctx).Error("error listing key pairs", "error", err)
+			return nil, err
+		}
+		plugin.Logger(ctx).Warn("listing the key pairs of the current user only", "microversion", client.Microversion)
+		userIDs = []string{currentUserID}
+	}
+
+	openstackConfig, err := getConnectionConfig(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving connection configuration", "error", err)
+		return nil, err
+	}
+
+	// with a name, the key pair of each user is retrieved directly instead of
+	// listing all of them
+	name := d.EqualsQualString("name")
+	err = forEachConcurrently(ctx, getMaxConcurrency(openstackConfig), userIDs, func(userID string) error {
+		if name != "" {
+			keypair, err := getOpenStackKeypairOf(client, name, userID, currentUserID)
+			if errors.As(err, &gophercloud.ErrDefault404{}) {
+				plugin.Logger(ctx).Debug("key pair not found", "name", name, "user", userID)
+				return nil
+			}
+			if err != nil {
+				plugin.Logger(ctx).Error("error retrieving key pair", "name", name, "user", userID, "error", err)
+				return err
+			}
+			d.StreamListItem(ctx, keypair)
+			return nil
+		}
+
+		opts := keypairs.ListOpts{}
+		if userID != currentUserID {
+			opts.UserID = userID
+		}
+
+		err := keypairs.List(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+			allKeypairs, err := keypairs.ExtractKeyPairs(page)
+			if err != nil {
+				plugin.Logger(ctx).Error("error extracting key pairs", "error", err)
+				return false, err
+			}
+			plugin.Logger(ctx).Debug("key pairs retrieved", "user", userID, "count", len(allKeypairs))
+
+			for _, keypair := range allKeypairs {
+				keypair.UserID = userID
+				d.StreamListItem(ctx, newAPIKeypair(keypair))
+				if rowsRemaining(ctx, d) == 0 {
+					plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+					return false, nil
+				}
+			}
+			return true, nil
+		})
+		if err != nil {
+			plugin.Logger(ctx).Error("error listing key pairs with options", "options", utils.ToPrettyJSON(opts), "error", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+//// HYDRATE FUNCTIONS
+
+// getOpenStackKeypairDetail retrieves the details of the key pair which are
+// not part of the list response, such as its creation time.
+func getOpenStackKeypairDetail(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	keypair := h.Item.(*apiKeypair)
+	if keypair.detail != nil {
+		return keypair.detail, nil
+	}
+	plugin.Logger(ctx).Debug("retrieving openstack key pair", "name", keypair.Name, "user", keypair.UserID)
+
+	client, err := getServiceClient(ctx, d, ComputeV2)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		return nil, err
+	}
+	currentUserID, err := getCurrentUserID(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving current user", "error", err)
+		return nil, err
+	}
+
+	detailed, err := getOpenStackKeypairOf(client, keypair.Name, keypair.UserID, currentUserID)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving key pair", "name", keypair.Name, "error", err)
+		return nil, err
+	}
+	return detailed.detail, nil
+}
+
+//// UTILITY FUNCTIONS
+
+// apiKeypair is a key pair along with the algorithm and size of its public
+// key.
+type apiKeypair struct {
+	keypairs.KeyPair
+	KeyAlgorithm string
+	KeyBits      int
+	// detail is set when the key pair was retrieved individually.
+	detail *apiKeypairDetail
+}
+
+// apiKeypairDetail holds the attributes of a key pair that are only returned
+// when it is retrieved individually.
+type apiKeypairDetail struct {
+	CreatedAt Time `json:"created_at"`
+}
+
+// getOpenStackKeypairOf retrieves the key pair of the given user by name,
+// along with its details.
+func getOpenStackKeypairOf(client *gophercloud.ServiceClient, name string, userID string, currentUserID string) (*apiKeypair, error) {
+	opts := keypairs.GetOpts{}
+	if userID != currentUserID {
+		opts.UserID = userID
+	}
+	result := keypairs.Get(client, name, opts)
+	keypair, err := result.Extract()
+	if err != nil {
+		return nil, err
+	}
+	detail := &apiKeypairDetail{}
+	if err := result.ExtractIntoStructPtr(detail, "keypair"); err != nil {
+		return nil, err
+	}
+	keypair.UserID = userID
+	item := newAPIKeypair(*keypair)
+	item.detail = detail
+	return item, nil
+}
+
+func newAPIKeypair(keypair keypairs.KeyPair) *apiKeypair {
+	result := &apiKeypair{KeyPair: keypair}
+	result.KeyAlgorithm, result.KeyBits = getPublicKeyInfo(keypair.PublicKey)
+	return result
+}
+
+// getKeypairUserIDs returns the IDs of the users in Keystone or, if they
+// cannot be listed, that of the current user.
+func getKeypairUserIDs(ctx context.Context, d *plugin.QueryData, currentUserID string) ([]string, error) {
+	client, err := getServiceClient(ctx, d, IdentityV3)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		return nil, err
+	}
+
+	userIDs := []string{}
+	err = users.List(client, users.ListOpts{}).EachPage(func(page pagination.Page) (bool, error) {
+		allUsers, err := users.ExtractUsers(page)
+		if err != nil {
+			plugin.Logger(ctx).Error("error extracting users", "error", err)
+			return false, err
+		}
+		for _, user := range allUsers {
+			userIDs = append(userIDs, user.ID)
+		}
+		return true, nil
+	})
+	if errors.As(err, &gophercloud.ErrDefault403{}) {
+		plugin.Logger(ctx).Warn("no rights to list users, listing the key pairs of the current user only", "user", currentUserID)
+		return []string{currentUserID}, nil
+	}
+	if err != nil {
+		plugin.Logger(ctx).Error("error listing users", "error", err)
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// getCurrentUserID returns the ID of the user the connection is authenticated
+// as.
+func getCurrentUserID(ctx context.Context, d *plugin.QueryData) (string, error) {
+	api, err := getAuthenticatedClient(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("no valid authenticated provider client available", "error", err)
+		return "", err
+	}
+	result, ok := api.GetAuthResult().(tokens.CreateResult)
+	if !ok {
+		return "", fmt.Errorf("no Identity V3 token available")
+	}
+	user, err := result.ExtractUser()
+	if err != nil {
+		plugin.Logger(ctx).Error("error extracting user from token", "error", err)
+		return "", err
+	}
+	return user.ID, nil
+}
+
+// getPublicKeyInfo returns the algorithm and size in bits of a public key in
+// OpenSSH format or of the key in a PEM certificate, or zero values if the key
+// cannot be parsed.
+func getPublicKeyInfo(publicKey string) (string, int) {
+	publicKey = strings.TrimSpace(publicKey)
+	if block, _ := pem.Decode([]byte(publicKey)); block != nil {
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return "", 0
+		}
+		switch key := certificate.PublicKey.(type) {
+		case *rsa.PublicKey:
+			return "rsa", key.N.BitLen()
+		case *ecdsa.PublicKey:
+			return "ecdsa", key.Curve.Params().BitSize
+		case ed25519.PublicKey:
+			return "ed25519", 256
+		}
+		return "", 0
+	}
+
+	fields := strings.Fields(publicKey)
+	if len(fields) < 2 {
+		return "", 0
+	}
+	data, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return "", 0
+	}
+	// the key is a sequence of length-prefixed strings, starting with its type
+	keyType, data := readSSHString(data)
+	switch string(keyType) {
+	case "ssh-rsa":
+		_, data = readSSHString(data) // the exponent
+		modulus, _ := readSSHString(data)
+		return "rsa", new(big.Int).SetBytes(modulus).BitLen()
+	case "ssh-dss":
+		prime, _ := readSSHString(data)
+		return "dsa", new(big.Int).SetBytes(prime).BitLen()
+	case "ecdsa-sha2-nistp256", "ecdsa-sha2-nistp384", "ecdsa-sha2-nistp521":
+		curve, _ := readSSHString(data)
+		switch string(curve) {
+		case "nistp256":
+			return "ecdsa", 256
+		case "nistp384":
+			return "ecdsa", 384
+		case "nistp521":
+			return "ecdsa", 521
+		}
+	case "ssh-ed25519":
+		return "ed25519", 256
+	case "sk-ecdsa-sha2-nistp256@openssh.com":
+		return "ecdsa-sk", 256
+	case "sk-ssh-ed25519@openssh.com":
+		return "ed25519-sk", 256
+	}
+	return "", 0
+}
+
+// readSSHString reads a length-prefixed string in the SSH wire format and
+// returns it along with the remaining data, or nil if it is truncated.
+func readSSHString(data []byte) ([]byte, []byte) {
+	if len(data) < 4 {
+		return nil, nil
+	}
+	length := binary.BigEndian.Uint32(data)
+	if uint64(len(data)-4) < uint64(length) {
+		return nil, nil
+	}
+	return data[4 : 4+length], data[4+length:]
+}
@@ -0,0 +1,259 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+)
+
+// AliceUserID is the ID of the user, other than the one the fake Keystone
+// issues tokens to, in the users fixture.
+const AliceUserID = "0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e"
+
+const (
+	testKeyRSA1024         = "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAAAgQDVgp2KdNFzbCHdnvNxf23SXnJVLjPa2LiAQXH7ytE4cg/o+q/RV2oHsVCFm4RUODqh/U7SQMaNLeJjKNFNV+YMKQRr/SYjFJrdV2wazYuWUSjqqO+YLiUiGG5RTv89MkqY/reSeTLDC19gqWbxCri1rIzNGZFKXN4KyJUVpiUXsw== alice@laptop"
+	testKeyED25519         = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFa3aPq9AwFEsj4SQrmrie2PdgOepbR+kBSWRq0XCe5z admin"
+	testKeyECDSA384        = "ecdsa-sha2-nistp384 AAAAE2VjZHNhLXNoYTItbmlzdHAzODQAAAAIbmlzdHAzODQAAABhBD41zhxZL3zaH0zfXYxyicZzZTAbZ7UlI9ccZtx/bWek5KqOyC0+AmDqfkUq9tg+BXlGNLxhr2hBgZvDXYF7wGUf7QxGKqFhkjSJDZE6d/cCk+SrjPjL+KMmI+N3dfhL2Q== root@vm"
+	testKeyRSA4096         = "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAACAQDIyRTgkwhuOoEn+Hv6AF4uVI1NF7na7FBMJrCM0GH7kpXm1h3N0h1vI8DW6o+uMrtbUx+k/sjKUu13X0XJEQ04vuY8PlvP/JQmuCCYyU6qylwkb6IqqUd3Nv52xo8Z2VI+g/jC3rdWvCeg9YbKNTPknOAPAkjUq/HULwDzeTtva0cQCm/7nIB7UfWoKn7MtetYbSRDw6ftnnbj93rR4d6wWRpDB9H2UtzsHstVrT87jNim3gzelNU/mjOv9XdroN+qJdqg4+cTdDB9kQik+KRg9c4LjpwF2xppPyBnYu3DtwgdMRkeQ0KtrYsWBSWCFfqd2VJY6ifgLPSUQ8nCrFvVpzDthKt7uj+yWuVFzA6mctm5fliHNaWdiDlO/2EcEiyR/AEaAsiR3d2gt1iE0A5kUiwt51zpqn9jLZcH2q91qMRpS2egsMs1nG1KJzIC11JOBeYny+7emsFkl0r9YAuhtequJratHRGanKQheCzL9filJhilG3oAUKLHi/ef4GMXOwpE9vspGub2FvdDckZyjQmVvqsHnbqyl2s9t1rU5ELCTEbFBsPaCJ0IPPxSatMbzonVSALTeGQBlX6olpsZguccEl9l5JOhZ9urOMYB4vrJmsVgAM/bsY0KkrwDr8X5w67nzXknTSJ+y6mPMZyEZOlSFIczHdTaFBoOEdaR8w== root@vm"
+	testCertificateRSA2048 = `-----BEGIN CERTIFICATE-----
+MIIDATCCAemgAwIBAgIUY+8cx2QGGiSCqukiag1ds/Zxv/YwDQYJKoZIhvcNAQEL
+BQAwEDEOMAwGA1UEAwwFYWxpY2UwHhcNMjYxMDE3MDIyMDE1WhcNMzYxMDE0MDIy
+MDE1WjAQMQ4wDAYDVQQDDAVhbGljZTCCASIwDQYJKoZIhvcNAQEBBQADggEPADCC
+AQoCggEBAKlJGZf030lUnOmOF91r/FVasARdir9/QWQPP13ikZFqqCe5EwnRKHGO
+It6D8h3eu9dEwXfBxfZoBOZ0yaGVmQisJyBt22oJQiocRsJUz3wCePzBcGdlSSzQ
+wx+whzdarNMrnfbgQ7TuF7DYOrq2b0OK011BcQP2wPBqj2HoE3t4Rxdj8ciRa2u3
+xm5QvTJ9Cny2I7KeGxXC93lFiPAJeffKIlNxDqUDf53KPD6L6yF++Ms9lKhxTxNO
+ql3oxsnN4SPMX8nA0DYaVN0/Bgaz7Vkq5ImPUI/AhoXj5xu+ZbwURWFGqT60b12d
+oArf155amGTjgPSdlXdUErk+/4jx2H0CAwEAAaNTMFEwHQYDVR0OBBYEFEJrnOSF
+CCHNfGSZbfTJy2OX8cuwMB8GA1UdIwQYMBaAFEJrnOSFCCHNfGSZbfTJy2OX8cuw
+MA8GA1UdEwEB/wQFMAMBAf8wDQYJKoZIhvcNAQELBQADggEBABGBO8DVmT18jjeC
+txijHzlmWvUQsVjaMH1PLnI+WJjDVaYtQUIhU/Yp5ep9t/uVYY2sppWCIKsPClRq
+EzvQH7kVCULlA7jhBG/zqva7FOO+IPPOa1FxkUueEi9TYM3yAmuCxOHls6iioZnQ
+gilseyVW8qxu/qKUNqdeRLP5wT9p6Xq0hKYtM9uSlnniu9lnqjM1bvqZnjrqO+0C
+hDvm7I4zPnbJ5jKQBhXJOeWNCQ8Rsf+S+8OSwMEuJT9pIDqLNBG6UPRc6EmkPGmW
+v0Vg2JtHWo8YQR/5KOy2I4+RKD1d5Lgp6+WIAmV3JGZ5w6mRCldTcRVSHJncs7hs
+FPoxxDA=
+-----END CERTIFICATE-----`
+)
+
+// withAliceKeypairs makes the fake Nova return a weak RSA key, an x509
+// certificate and a strong RSA key for alice, and the fixtures for the
+// current user; key pairs are only found for the user they belong to.
+func withAliceKeypairs(cloud *fakeOpenStack) {
+	keypair := func(name, publicKey, keyType string) map[string]any {
+		return map[string]any{"name": name, "fingerprint": "fingerprint-" + name, "public_key": publicKey, "type": keyType}
+	}
+	aliceKeypairs := []map[string]any{
+		keypair("laptop", testKeyRSA1024, "ssh"),
+		keypair("cert", testCertificateRSA2048, "x509"),
+		keypair("big", testKeyRSA4096, "ssh"),
+	}
+	notFound := func(w http.ResponseWriter) {
+		writeJSON(w, http.StatusNotFound, map[string]any{
+			"itemNotFound": map[string]any{"code": http.StatusNotFound, "message": "Keypair not found."},
+		})
+	}
+
+	cloud.handle("GET /compute/v2.1/os-keypairs", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("user_id") != AliceUserID {
+			cloud.serveFixture(w, http.StatusOK, "compute/os-keypairs.json")
+			return
+		}
+		allKeypairs := []any{}
+		for _, keypair := range aliceKeypairs {
+			allKeypairs = append(allKeypairs, map[string]any{"keypair": keypair})
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"keypairs": allKeypairs})
+	})
+	for _, keypair := range aliceKeypairs {
+		keypair := keypair
+		cloud.handle("GET /compute/v2.1/os-keypairs/"+keypair["name"].(string), func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("user_id") != AliceUserID {
+				notFound(w)
+				return
+			}
+			detail := map[string]any{"user_id": AliceUserID, "created_at": "2023-01-02T03:04:05.000000"}
+			for key, value := range keypair {
+				detail[key] = value
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"keypair": detail})
+		})
+	}
+	for _, name := range []string{"deploy", "ops"} {
+		path := "/compute/v2.1/os-keypairs/" + name
+		cloud.handle("GET "+path, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Has("user_id") {
+				notFound(w)
+				return
+			}
+			cloud.serveFixture(w, http.StatusOK, fixturePath(path))
+		})
+	}
+}
+
+func TestListOpenStackKeypair(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	withAliceKeypairs(cloud)
+	// users are queried one at a time, in the order Keystone returns them
+	cloud.config.MaxConcurrency = utils.PointerTo(1)
+
+	rows, err := cloud.list(tableOpenStackKeypair(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "deploy", "ops", "laptop", "cert", "big")
+	assertRows(t, rows, "user_name", "admin", "admin", "alice", "alice", "alice")
+	assertRows(t, rows, "key_algorithm", "ed25519", "ecdsa", "rsa", "rsa", "rsa")
+	assertRows(t, rows, "key_bits", int64(256), int64(384), int64(1024), int64(2048), int64(4096))
+	assertRows(t, rows, "type", "ssh", "ssh", "ssh", "x509", "ssh")
+	assertRow(t, rows[0], testRow{
+		"user_id":     TestUserID,
+		"fingerprint": "49:f2:fe:02:8c:63:aa:44:fc:ac:48:1e:22:55:da:19",
+		"public_key":  testKeyED25519 + "\n",
+		"created_at":  "2022-10-07T18:56:02Z",
+		"region":      "RegionOne",
+	})
+	assertRow(t, rows[2], testRow{"user_id": AliceUserID, "created_at": "2023-01-02T03:04:05Z"})
+
+	// the current user's key pairs are listed without user_id, the others'
+	// with it, and so are their details
+	queries := []url.Values{}
+	for _, request := range cloud.received("/compute/v2.1/os-keypairs") {
+		queries = append(queries, request.Query)
+	}
+	if len(queries) != 2 || (queries[0].Get("user_id") == "") == (queries[1].Get("user_id") == "") {
+		t.Errorf("expected one request with and one without user_id, got %v", queries)
+	}
+	if requests := cloud.received("/compute/v2.1/os-keypairs/laptop"); len(requests) != 1 || requests[0].Query.Get("user_id") != AliceUserID {
+		t.Errorf("expected the details of laptop to be retrieved for alice, got %v", requests)
+	}
+	if requests := cloud.received("/compute/v2.1/os-keypairs/deploy"); len(requests) != 1 || requests[0].Query.Has("user_id") {
+		t.Errorf("expected the details of deploy to be retrieved for the current user, got %v", requests)
+	}
+}
+
+func TestListOpenStackKeypairFilters(t *testing.T) {
+	var tests = []struct {
+		quals    map[string]any
+		expected []any
+	}{
+		{quals: map[string]any{"user_id": AliceUserID}, expected: []any{"laptop", "cert", "big"}},
+		{quals: map[string]any{"user_id": AliceUserID, "name": "cert"}, expected: []any{"cert"}},
+		{quals: map[string]any{"name": "ops"}, expected: []any{"ops"}},
+	}
+
+	for _, test := range tests {
+		cloud := newFakeOpenStack(t)
+		withAliceKeypairs(cloud)
+
+		rows, err := cloud.list(tableOpenStackKeypair(context.Background()), testQuery{quals: test.quals})
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.quals, err)
+			continue
+		}
+		assertRows(t, rows, "name", test.expected...)
+		if _, ok := test.quals["user_id"]; ok && len(cloud.received("/identity/v3/users")) != 0 {
+			t.Errorf("%v: expected no users to be listed", test.quals)
+		}
+		// key pairs are retrieved by name rather than listed
+		if _, ok := test.quals["name"]; ok && len(cloud.received("/compute/v2.1/os-keypairs")) != 0 {
+			t.Errorf("%v: expected no key pairs to be listed", test.quals)
+		}
+	}
+}
+
+func TestListOpenStackKeypairName(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	withAliceKeypairs(cloud)
+
+	rows, err := cloud.list(tableOpenStackKeypair(context.Background()), testQuery{quals: map[string]any{"user_id": AliceUserID, "name": "cert"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, rows[0], testRow{
+		"name":          "cert",
+		"user_id":       AliceUserID,
+		"user_name":     "alice",
+		"type":          "x509",
+		"key_algorithm": "rsa",
+		"key_bits":      int64(2048),
+		"created_at":    "2023-01-02T03:04:05Z",
+	})
+	// the details come with the key pair, with a single request
+	if requests := cloud.received("/compute/v2.1/os-keypairs/cert"); len(requests) != 1 || requests[0].Query.Get("user_id") != AliceUserID {
+		t.Errorf("expected one request for cert with user_id, got %v", requests)
+	}
+
+	// without user_id, the key pair is looked for among those of every user
+	cloud = newFakeOpenStack(t)
+	withAliceKeypairs(cloud)
+	rows, err = cloud.list(tableOpenStackKeypair(context.Background()), testQuery{quals: map[string]any{"name": "laptop"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "user_id", AliceUserID)
+	if requests := cloud.received("/compute/v2.1/os-keypairs/laptop"); len(requests) != 2 {
+		t.Errorf("expected one request for laptop per user, got %d", len(requests))
+	}
+
+	// errors other than 404 are reported
+	cloud = newFakeOpenStack(t)
+	cloud.fail("GET /compute/v2.1/os-keypairs/laptop", http.StatusInternalServerError)
+	if _, err := cloud.list(tableOpenStackKeypair(context.Background()), testQuery{quals: map[string]any{"name": "laptop"}}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestListOpenStackKeypairCurrentUserOnly(t *testing.T) {
+	// users cannot be listed
+	cloud := newFakeOpenStack(t)
+	withAliceKeypairs(cloud)
+	cloud.fail("GET /identity/v3/users", http.StatusForbidden)
+
+	rows, err := cloud.list(tableOpenStackKeypair(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "deploy", "ops")
+
+	// user_id is ignored before 2.10
+	cloud = newFakeOpenStack(t)
+	withAliceKeypairs(cloud)
+	cloud.config.ComputeV2Microversion = utils.PointerTo("2.9")
+
+	rows, err = cloud.list(tableOpenStackKeypair(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "deploy", "ops")
+	if _, err := cloud.list(tableOpenStackKeypair(context.Background()), testQuery{quals: map[string]any{"user_id": AliceUserID}}); err == nil {
+		t.Error("expected error listing the key pairs of another user before 2.10")
+	}
+}
+
+func TestGetPublicKeyInfo(t *testing.T) {
+	var tests = []struct {
+		publicKey string
+		algorithm string
+		bits      int
+	}{
+		{publicKey: testKeyRSA1024, algorithm: "rsa", bits: 1024},
+		{publicKey: testKeyRSA4096, algorithm: "rsa", bits: 4096},
+		{publicKey: testKeyED25519, algorithm: "ed25519", bits: 256},
+		{publicKey: testKeyECDSA384, algorithm: "ecdsa", bits: 384},
+		{publicKey: testCertificateRSA2048, algorithm: "rsa", bits: 2048},
+		{publicKey: "ssh-rsa not-base64", algorithm: "", bits: 0},
+		{publicKey: "ssh-rsa AAAAB3NzaC1yc2EAAAAD", algorithm: "rsa", bits: 0},
+		{publicKey: "", algorithm: "", bits: 0},
+	}
+
+	for _, test := range tests {
+		algorithm, bits := getPublicKeyInfo(test.publicKey)
+		if algorithm != test.algorithm || bits != test.bits {
+			t.Errorf("%.20s: expected %s/%d, got %s/%d", test.publicKey, test.algorithm, test.bits, algorithm, bits)
+		}
+	}
+}
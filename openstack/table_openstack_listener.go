@@ -4,7 +4,9 @@ import (
 	"context"
 
 	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/listeners"
+	"github.com/gophercloud/gophercloud/pagination"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -14,8 +16,9 @@ import (
 
 func tableOpenStackListener(_ context.Context) *plugin.Table {
 	return &plugin.Table{
-		Name:        "openstack_listener",
-		Description: "OpenStack Listener",
+		Name:              "openstack_listener",
+		Description:       "OpenStack Listener",
+		GetMatrixItemFunc: regionMatrix,
 		Columns: []*plugin.Column{
 			{
 				Name:        "id",
@@ -41,6 +44,13 @@ func tableOpenStackListener(_ context.Context) *plugin.Table {
 				Description: "The ID of the project owning this network.",
 				Transform:   transform.FromField("ProjectID"),
 			},
+			{
+				Name:        "project_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the project the listener belongs to.",
+				Hydrate:     getProjectName,
+				Transform:   transform.FromValue(),
+			},
 			{
 				Name:        "protocol_port",
 				Type:        proto.ColumnType_STRING,
@@ -107,6 +117,12 @@ func tableOpenStackListener(_ context.Context) *plugin.Table {
 				Description: "Tags is a list of security group tags. Tags are arbitrarily defined strings attached to a security group.",
 				Transform:   transform.FromField("Tags"),
 			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the listener belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
 		},
 		List: &plugin.ListConfig{
 			Hydrate: listOpenStacklistener,
@@ -135,7 +151,7 @@ func tableOpenStackListener(_ context.Context) *plugin.Table {
 		},
 		Get: &plugin.GetConfig{
 			KeyColumns: plugin.SingleColumn("id"),
-			Hydrate:    getOpenStacklistener,
+			Hydrate:    getInScope(getOpenStacklistener),
 		},
 	}
 }
@@ -148,33 +164,45 @@ func listOpenStacklistener(ctx context.Context, d *plugin.QueryData, h *plugin.H
 
 	plugin.Logger(ctx).Debug("retrieving openstack listener list", "query data", utils.ToPrettyJSON(d))
 
-	client, err := getServiceClient(ctx, d, LbaasV2)
+	scope, err := getProjectScope(ctx, d)
 	if err != nil {
-		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
 		return nil, err
 	}
 
 	opts := buildOpenStacklistenerFilter(ctx, d.EqualsQuals)
+	opts.Limit = getPageSize(d)
 
-	allPages, err := listeners.List(client, opts).AllPages()
-	if err != nil {
-		plugin.Logger(ctx).Error("error listing listener with options", "options", utils.ToPrettyJSON(opts), "error", err)
-		return nil, err
-	}
-	alllisteners, err := listeners.ExtractListeners(allPages)
-	if err != nil {
-		plugin.Logger(ctx).Error("error extracting networks", "error", err)
-		return nil, err
-	}
-	plugin.Logger(ctx).Debug("listeners retrieved", "count", len(alllisteners))
+	err = forEachProject(ctx, d, scope, LbaasV2, func(client *gophercloud.ServiceClient, projectID string) error {
+		opts := opts
+		opts.ProjectID = projectID
+
+		err := listeners.List(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+			alllisteners, err := listeners.ExtractListeners(page)
+			if err != nil {
+				plugin.Logger(ctx).Error("error extracting networks", "error", err)
+				return false, err
+			}
+			plugin.Logger(ctx).Debug("listeners retrieved", "count", len(alllisteners))
 
-	for _, listener := range alllisteners {
-		if ctx.Err() != nil {
-			plugin.Logger(ctx).Debug("context done, exit")
-			break
+			for _, listener := range alllisteners {
+				listener := listener
+				d.StreamListItem(ctx, &listener)
+				if rowsRemaining(ctx, d) == 0 {
+					plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+					return false, nil
+				}
+			}
+			return true, nil
+		})
+		if err != nil {
+			plugin.Logger(ctx).Error("error listing listener with options", "options", utils.ToPrettyJSON(opts), "error", err)
+			return err
 		}
-		listener := listener
-		d.StreamListItem(ctx, &listener)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return nil, nil
 }
@@ -194,6 +222,12 @@ func getOpenStacklistener(ctx context.Context, d *plugin.QueryData, h *plugin.Hy
 		return nil, err
 	}
 
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+
 	result := listeners.Get(client, id)
 	var listener *listeners.Listener
 	listener, err = result.Extract()
@@ -202,6 +236,11 @@ func getOpenStacklistener(ctx context.Context, d *plugin.QueryData, h *plugin.Hy
 		return nil, err
 	}
 
+	if !scope.Includes(listener.ProjectID) {
+		plugin.Logger(ctx).Debug("listener not in project scope", "id", id, "project", listener.ProjectID)
+		return nil, nil
+	}
+
 	return listener, nil
 }
 
@@ -0,0 +1,79 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestListOpenStackListener(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackListener(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "web-http")
+	assertRow(t, rows[0], testRow{
+		"id":                  "023f2e34-7806-443b-bfae-16c324569a3d",
+		"description":         "http listener",
+		"project_id":          TestProjectID,
+		"protocol":            "HTTP",
+		"protocol_port":       "80",
+		"default_pool_id":     "4029d267-3983-4224-a3d0-afb3fe16a2cd",
+		"loadbalancers":       `[{"id":"607226db-27ef-4d41-ae89-f2a800e9c2db"}]`,
+		"admin_state_up":      "true",
+		"provisioning_status": "ACTIVE",
+		"operating_status":    "ONLINE",
+		"tags":                `["web"]`,
+		"region":              "RegionOne",
+	})
+}
+
+func TestListOpenStackListenerFilters(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	_, err := cloud.list(tableOpenStackListener(context.Background()), testQuery{
+		quals: map[string]any{
+			"id":         "023f2e34-7806-443b-bfae-16c324569a3d",
+			"name":       "web-http",
+			"project_id": TestProjectID,
+		},
+		limit: 5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloud.assertQuery("/load-balancer/v2.0/lbaas/listeners", url.Values{
+		"id":         {"023f2e34-7806-443b-bfae-16c324569a3d"},
+		"name":       {"web-http"},
+		"project_id": {TestProjectID},
+		"limit":      {"5"},
+	})
+}
+
+func TestListOpenStackListenerError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /load-balancer/v2.0/lbaas/listeners", http.StatusInternalServerError)
+
+	if _, err := cloud.list(tableOpenStackListener(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGetOpenStackListener(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	table := tableOpenStackListener(context.Background())
+
+	row, err := cloud.get(table, testQuery{quals: map[string]any{"id": "023f2e34-7806-443b-bfae-16c324569a3d"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, row, testRow{"name": "web-http", "protocol": "HTTP"})
+
+	row, err = cloud.get(table, testQuery{quals: map[string]any{"id": "00000000-0000-0000-0000-000000000000"}})
+	if err != nil || row != nil {
+		t.Errorf("expected no row and no error, got %v (error: %v)", row, err)
+	}
+}
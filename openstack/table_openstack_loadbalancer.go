@@ -4,7 +4,9 @@ import (
 	"context"
 
 	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/loadbalancers"
+	"github.com/gophercloud/gophercloud/pagination"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -14,9 +16,10 @@ import (
 
 func tableOpenStackLoadBalancer(_ context.Context) *plugin.Table {
 	return &plugin.Table{
-		Name:        "openstack_loadbalancer",
-		Description: "OpenStack Loadbalancer",
-		Columns: []*plugin.Column{
+		Name:              "openstack_loadbalancer",
+		Description:       "OpenStack Loadbalancer",
+		GetMatrixItemFunc: regionMatrix,
+		Columns: append([]*plugin.Column{
 			{
 				Name:        "id",
 				Type:        proto.ColumnType_STRING,
@@ -41,6 +44,13 @@ func tableOpenStackLoadBalancer(_ context.Context) *plugin.Table {
 				Description: "The ID of the project owning this network.",
 				Transform:   transform.FromField("ProjectID"),
 			},
+			{
+				Name:        "project_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the project the load balancer belongs to.",
+				Hydrate:     getProjectName,
+				Transform:   transform.FromValue(),
+			},
 			{
 				Name:        "flavor_id",
 				Type:        proto.ColumnType_STRING,
@@ -101,10 +111,16 @@ func tableOpenStackLoadBalancer(_ context.Context) *plugin.Table {
 				Description: "Tags is a list of security group tags. Tags are arbitrarily defined strings attached to a security group.",
 				Transform:   transform.FromField("Tags"),
 			},
-		},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the loadbalancer belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
+		}, tagColumns()...),
 		List: &plugin.ListConfig{
 			Hydrate: listOpenStackLoadbalancer,
-			KeyColumns: plugin.KeyColumnSlice{
+			KeyColumns: append(plugin.KeyColumnSlice{
 				&plugin.KeyColumn{
 					Name:    "id",
 					Require: plugin.Optional,
@@ -125,11 +141,11 @@ func tableOpenStackLoadBalancer(_ context.Context) *plugin.Table {
 					Name:    "operating_status",
 					Require: plugin.Optional,
 				},
-			},
+			}, tagKeyColumns()...),
 		},
 		Get: &plugin.GetConfig{
 			KeyColumns: plugin.SingleColumn("id"),
-			Hydrate:    getOpenStackLoadbalancer,
+			Hydrate:    getInScope(getOpenStackLoadbalancer),
 		},
 	}
 }
@@ -142,33 +158,45 @@ func listOpenStackLoadbalancer(ctx context.Context, d *plugin.QueryData, h *plug
 
 	plugin.Logger(ctx).Debug("retrieving openstack loadbalancer list", "query data", utils.ToPrettyJSON(d))
 
-	client, err := getServiceClient(ctx, d, LbaasV2)
+	scope, err := getProjectScope(ctx, d)
 	if err != nil {
-		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
 		return nil, err
 	}
 
 	opts := buildOpenStackLoadbalancerFilter(ctx, d.EqualsQuals)
+	opts.Limit = getPageSize(d)
 
-	allPages, err := loadbalancers.List(client, opts).AllPages()
-	if err != nil {
-		plugin.Logger(ctx).Error("error listing loadbalancer with options", "options", utils.ToPrettyJSON(opts), "error", err)
-		return nil, err
-	}
-	allLoadbalancers, err := loadbalancers.ExtractLoadBalancers(allPages)
-	if err != nil {
-		plugin.Logger(ctx).Error("error extracting networks", "error", err)
-		return nil, err
-	}
-	plugin.Logger(ctx).Debug("loadbalancers retrieved", "count", len(allLoadbalancers))
+	err = forEachProject(ctx, d, scope, LbaasV2, func(client *gophercloud.ServiceClient, projectID string) error {
+		opts := opts
+		opts.ProjectID = projectID
 
-	for _, loadbalancer := range allLoadbalancers {
-		if ctx.Err() != nil {
-			plugin.Logger(ctx).Debug("context done, exit")
-			break
+		err := loadbalancers.List(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+			allLoadbalancers, err := loadbalancers.ExtractLoadBalancers(page)
+			if err != nil {
+				plugin.Logger(ctx).Error("error extracting networks", "error", err)
+				return false, err
+			}
+			plugin.Logger(ctx).Debug("loadbalancers retrieved", "count", len(allLoadbalancers))
+
+			for _, loadbalancer := range allLoadbalancers {
+				loadbalancer := loadbalancer
+				d.StreamListItem(ctx, &loadbalancer)
+				if rowsRemaining(ctx, d) == 0 {
+					plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+					return false, nil
+				}
+			}
+			return true, nil
+		})
+		if err != nil {
+			plugin.Logger(ctx).Error("error listing loadbalancer with options", "options", utils.ToPrettyJSON(opts), "error", err)
+			return err
 		}
-		loadbalancer := loadbalancer
-		d.StreamListItem(ctx, &loadbalancer)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return nil, nil
 }
@@ -188,6 +216,12 @@ func getOpenStackLoadbalancer(ctx context.Context, d *plugin.QueryData, h *plugi
 		return nil, err
 	}
 
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+
 	result := loadbalancers.Get(client, id)
 	var loadbalancer *loadbalancers.LoadBalancer
 	loadbalancer, err = result.Extract()
@@ -196,6 +230,11 @@ func getOpenStackLoadbalancer(ctx context.Context, d *plugin.QueryData, h *plugi
 		return nil, err
 	}
 
+	if !scope.Includes(loadbalancer.ProjectID) {
+		plugin.Logger(ctx).Debug("loadbalancer not in project scope", "id", id, "project", loadbalancer.ProjectID)
+		return nil, nil
+	}
+
 	return loadbalancer, nil
 }
 
@@ -218,6 +257,11 @@ func buildOpenStackLoadbalancerFilter(ctx context.Context, quals plugin.KeyColum
 		opts.OperatingStatus = value.GetStringValue()
 	}
 
+	tags := getTagFilter(quals)
+	opts.Tags = toTagList(tags.Tags)
+	opts.TagsAny = toTagList(tags.TagsAny)
+	opts.TagsNot = toTagList(tags.NotTags)
+	opts.TagsNotAny = toTagList(tags.NotTagsAny)
 	plugin.Logger(ctx).Debug("returning", "filter", utils.ToPrettyJSON(opts))
 	return opts
 }
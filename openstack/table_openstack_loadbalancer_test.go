@@ -0,0 +1,103 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestListOpenStackLoadBalancer(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackLoadBalancer(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "web-lb", "db-lb")
+	assertRow(t, rows[0], testRow{
+		"id":               "607226db-27ef-4d41-ae89-f2a800e9c2db",
+		"description":      "web frontend",
+		"project_id":       TestProjectID,
+		"vip_network_id":   "0f2e8b6a-3c4d-4e5f-9a0b-1c2d3e4f5a6b",
+		"vip_subnet_id":    "a0304c3a-4f08-4c43-88af-d796509c97d2",
+		"vip_port_id":      "b4e7a6c1-2d3f-4a5b-8c9d-0e1f2a3b4c5d",
+		"vip_address":      "203.0.113.50",
+		"provider":         "amphora",
+		"operating_status": "ONLINE",
+		"tags":             `["web"]`,
+		"region":           "RegionOne",
+	})
+	assertRow(t, rows[1], testRow{"operating_status": "OFFLINE", "tags": `[]`})
+}
+
+func TestListOpenStackLoadBalancerFilters(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	_, err := cloud.list(tableOpenStackLoadBalancer(context.Background()), testQuery{
+		quals: map[string]any{
+			"id":               "607226db-27ef-4d41-ae89-f2a800e9c2db",
+			"name":             "web-lb",
+			"description":      "web frontend",
+			"project_id":       TestProjectID,
+			"operating_status": "ONLINE",
+		},
+		limit: 5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloud.assertQuery("/load-balancer/v2.0/lbaas/loadbalancers", url.Values{
+		"id":               {"607226db-27ef-4d41-ae89-f2a800e9c2db"},
+		"name":             {"web-lb"},
+		"description":      {"web frontend"},
+		"project_id":       {TestProjectID},
+		"operating_status": {"ONLINE"},
+		"limit":            {"5"},
+	})
+}
+
+func TestListOpenStackLoadBalancerError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /load-balancer/v2.0/lbaas/loadbalancers", http.StatusInternalServerError)
+
+	if _, err := cloud.list(tableOpenStackLoadBalancer(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGetOpenStackLoadBalancer(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	table := tableOpenStackLoadBalancer(context.Background())
+
+	row, err := cloud.get(table, testQuery{quals: map[string]any{"id": "607226db-27ef-4d41-ae89-f2a800e9c2db"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, row, testRow{"name": "web-lb", "vip_address": "203.0.113.50"})
+
+	row, err = cloud.get(table, testQuery{quals: map[string]any{"id": "00000000-0000-0000-0000-000000000000"}})
+	if err != nil || row != nil {
+		t.Errorf("expected no row and no error, got %v (error: %v)", row, err)
+	}
+}
+
+func TestListOpenStackLoadBalancerTags(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	_, err := cloud.list(tableOpenStackLoadBalancer(context.Background()), testQuery{
+		quals: map[string]any{
+			"tags_any":     "team-x,team-y",
+			"not_tags_any": "staging",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloud.assertQuery("/load-balancer/v2.0/lbaas/loadbalancers", url.Values{
+		"tags":         nil,
+		"tags-any":     {"team-x,team-y"},
+		"not-tags":     nil,
+		"not-tags-any": {"staging"},
+	})
+}
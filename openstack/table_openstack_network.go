@@ -4,7 +4,9 @@ import (
 	"context"
 
 	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/networks"
+	"github.com/gophercloud/gophercloud/pagination"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -14,9 +16,10 @@ import (
 
 func tableOpenStackNetwork(_ context.Context) *plugin.Table {
 	return &plugin.Table{
-		Name:        "openstack_network",
-		Description: "OpenStack Network",
-		Columns: []*plugin.Column{
+		Name:              "openstack_network",
+		Description:       "OpenStack Network",
+		GetMatrixItemFunc: regionMatrix,
+		Columns: append([]*plugin.Column{
 			{
 				Name:        "id",
 				Type:        proto.ColumnType_STRING,
@@ -41,6 +44,13 @@ func tableOpenStackNetwork(_ context.Context) *plugin.Table {
 				Description: "The ID of the project owning this network.",
 				Transform:   transform.FromField("ProjectID"),
 			},
+			{
+				Name:        "project_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the project the network belongs to.",
+				Hydrate:     getProjectName,
+				Transform:   transform.FromValue(),
+			},
 			{
 				Name:        "admin_state_up",
 				Type:        proto.ColumnType_BOOL,
@@ -169,13 +179,13 @@ func tableOpenStackNetwork(_ context.Context) *plugin.Table {
 			},
 			{
 				Name:        "created_at",
-				Type:        proto.ColumnType_STRING,
-				Description: "Timestamp when the port was created.",
+				Type:        proto.ColumnType_TIMESTAMP,
+				Description: "Timestamp when the network was created. Unlike updated_at, filters on it are not passed on to Neutron, which cannot filter on creation time.",
 				Transform:   transform.FromField("CreatedAt").Transform(ToTime),
 			},
 			{
 				Name:        "updated_at",
-				Type:        proto.ColumnType_STRING,
+				Type:        proto.ColumnType_TIMESTAMP,
 				Description: "Timestamp when the port was last updated.",
 				Transform:   transform.FromField("UpdatedAt").Transform(ToTime),
 			},
@@ -185,10 +195,16 @@ func tableOpenStackNetwork(_ context.Context) *plugin.Table {
 				Description: "Tags is a list of security group tags. Tags are arbitrarily defined strings attached to a security group.",
 				Transform:   transform.FromField("Tags"),
 			},
-		},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the network belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
+		}, tagColumns()...),
 		List: &plugin.ListConfig{
 			Hydrate: listOpenStackNetwork,
-			KeyColumns: plugin.KeyColumnSlice{
+			KeyColumns: append(plugin.KeyColumnSlice{
 				&plugin.KeyColumn{
 					Name:    "id",
 					Require: plugin.Optional,
@@ -217,12 +233,16 @@ func tableOpenStackNetwork(_ context.Context) *plugin.Table {
 					Name:    "admin_state_up",
 					Require: plugin.Optional,
 				},
-				// TODO: add tags support
-			},
+				&plugin.KeyColumn{
+					Name:      "updated_at",
+					Operators: timeRangeOperators,
+					Require:   plugin.Optional,
+				},
+			}, tagKeyColumns()...),
 		},
 		Get: &plugin.GetConfig{
 			KeyColumns: plugin.SingleColumn("id"),
-			Hydrate:    getOpenStackNetwork,
+			Hydrate:    getInScope(getOpenStackNetwork),
 		},
 	}
 }
@@ -235,33 +255,47 @@ func listOpenStackNetwork(ctx context.Context, d *plugin.QueryData, h *plugin.Hy
 
 	plugin.Logger(ctx).Debug("retrieving openstack networks list", "query data", utils.ToPrettyJSON(d))
 
-	client, err := getServiceClient(ctx, d, NetworkV2)
+	scope, err := getProjectScope(ctx, d)
 	if err != nil {
-		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
 		return nil, err
 	}
 
-	opts := buildOpenStackNetworkFilter(ctx, d.EqualsQuals)
+	opts := listNetworksOpts{ListOpts: buildOpenStackNetworkFilter(ctx, d.EqualsQuals)}
+	opts.Limit = getPageSize(d)
+	// Neutron can only filter on the lower end of updated_at ranges
+	opts.ChangedSince = getTimeRange(d, "updated_at").Since()
 
-	allPages, err := networks.List(client, opts).AllPages()
-	if err != nil {
-		plugin.Logger(ctx).Error("error listing networks with options", "options", utils.ToPrettyJSON(opts), "error", err)
-		return nil, err
-	}
-	allNetworks, err := networks.ExtractNetworks(allPages)
-	if err != nil {
-		plugin.Logger(ctx).Error("error extracting networks", "error", err)
-		return nil, err
-	}
-	plugin.Logger(ctx).Debug("networks retrieved", "count", len(allNetworks))
+	err = forEachProject(ctx, d, scope, NetworkV2, func(client *gophercloud.ServiceClient, projectID string) error {
+		opts := opts
+		opts.ProjectID = projectID
+
+		err := networks.List(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+			allNetworks, err := networks.ExtractNetworks(page)
+			if err != nil {
+				plugin.Logger(ctx).Error("error extracting networks", "error", err)
+				return false, err
+			}
+			plugin.Logger(ctx).Debug("networks retrieved", "count", len(allNetworks))
 
-	for _, network := range allNetworks {
-		if ctx.Err() != nil {
-			plugin.Logger(ctx).Debug("context done, exit")
-			break
+			for _, network := range allNetworks {
+				network := network
+				d.StreamListItem(ctx, &network)
+				if rowsRemaining(ctx, d) == 0 {
+					plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+					return false, nil
+				}
+			}
+			return true, nil
+		})
+		if err != nil {
+			plugin.Logger(ctx).Error("error listing networks with options", "options", utils.ToPrettyJSON(opts), "error", err)
+			return err
 		}
-		network := network
-		d.StreamListItem(ctx, &network)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return nil, nil
 }
@@ -281,6 +315,12 @@ func getOpenStackNetwork(ctx context.Context, d *plugin.QueryData, h *plugin.Hyd
 		return nil, err
 	}
 
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+
 	result := networks.Get(client, id)
 	var network *networks.Network
 	network, err = result.Extract()
@@ -289,6 +329,11 @@ func getOpenStackNetwork(ctx context.Context, d *plugin.QueryData, h *plugin.Hyd
 		return nil, err
 	}
 
+	if !scope.Includes(network.ProjectID) {
+		plugin.Logger(ctx).Debug("network not in project scope", "id", id, "project", network.ProjectID)
+		return nil, nil
+	}
+
 	return network, nil
 }
 
@@ -317,6 +362,27 @@ func buildOpenStackNetworkFilter(ctx context.Context, quals plugin.KeyColumnEqua
 		opts.Shared = utils.PointerTo(value.GetBoolValue())
 	}
 
+	tags := getTagFilter(quals)
+	opts.Tags = tags.Tags
+	opts.TagsAny = tags.TagsAny
+	opts.NotTags = tags.NotTags
+	opts.NotTagsAny = tags.NotTagsAny
 	plugin.Logger(ctx).Debug("returning", "filter", utils.ToPrettyJSON(opts))
 	return opts
 }
+
+// listNetworksOpts adds to networks.ListOpts the filters that gophercloud does
+// not support.
+type listNetworksOpts struct {
+	networks.ListOpts
+	// ChangedSince selects the networks updated since (or at) the given time.
+	ChangedSince string
+}
+
+func (opts listNetworksOpts) ToNetworkListQuery() (string, error) {
+	query, err := opts.ListOpts.ToNetworkListQuery()
+	if err != nil {
+		return "", err
+	}
+	return addQueryParams(query, map[string]string{"changed_since": opts.ChangedSince})
+}
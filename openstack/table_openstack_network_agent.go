@@ -0,0 +1,224 @@
+package openstack
+
+import (
+	"context"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/agents"
+	"github.com/gophercloud/gophercloud/pagination"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableOpenStackNetworkAgent(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:              "openstack_network_agent",
+		Description:       "OpenStack Network Agent",
+		GetMatrixItemFunc: regionMatrix,
+		Columns: []*plugin.Column{
+			{
+				Name:        "id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The unique ID of the agent.",
+				Transform:   transform.FromField("ID"),
+			},
+			{
+				Name:        "agent_type",
+				Type:        proto.ColumnType_STRING,
+				Description: "The type of the agent, e.g. Open vSwitch agent or L3 agent.",
+				Transform:   transform.FromField("AgentType"),
+			},
+			{
+				Name:        "binary",
+				Type:        proto.ColumnType_STRING,
+				Description: "The binary of the agent, e.g. neutron-openvswitch-agent.",
+				Transform:   transform.FromField("Binary"),
+			},
+			{
+				Name:        "host",
+				Type:        proto.ColumnType_STRING,
+				Description: "The host the agent runs on, as in openstack_hypervisor.service_host for agents on compute nodes.",
+				Transform:   transform.FromField("Host"),
+			},
+			{
+				Name:        "alive",
+				Type:        proto.ColumnType_BOOL,
+				Description: "Whether the agent has reported its state recently enough to be considered alive.",
+				Transform:   transform.FromField("Alive"),
+			},
+			{
+				Name:        "admin_state_up",
+				Type:        proto.ColumnType_BOOL,
+				Description: "The administrative state of the agent.",
+				Transform:   transform.FromField("AdminStateUp"),
+			},
+			{
+				Name:        "heartbeat_timestamp",
+				Type:        proto.ColumnType_TIMESTAMP,
+				Description: "The time the agent last reported its state.",
+				Transform:   transform.FromField("HeartbeatTimestamp").Transform(ToTime),
+			},
+			{
+				Name:        "started_at",
+				Type:        proto.ColumnType_TIMESTAMP,
+				Description: "The time the agent was last started.",
+				Transform:   transform.FromField("StartedAt").Transform(ToTime),
+			},
+			{
+				Name:        "created_at",
+				Type:        proto.ColumnType_TIMESTAMP,
+				Description: "The time the agent first reported its state.",
+				Transform:   transform.FromField("CreatedAt").Transform(ToTime),
+			},
+			{
+				Name:        "availability_zone",
+				Type:        proto.ColumnType_STRING,
+				Description: "The availability zone of the agent, for DHCP and L3 agents.",
+				Transform:   transform.FromField("AvailabilityZone").Transform(transform.NullIfZeroValue),
+			},
+			{
+				Name:        "topic",
+				Type:        proto.ColumnType_STRING,
+				Description: "The AMQP topic the agent listens on.",
+				Transform:   transform.FromField("Topic"),
+			},
+			{
+				Name:        "description",
+				Type:        proto.ColumnType_STRING,
+				Description: "The description of the agent.",
+				Transform:   transform.FromField("Description").Transform(transform.NullIfZeroValue),
+			},
+			{
+				Name:        "configurations",
+				Type:        proto.ColumnType_JSON,
+				Description: "The configuration of the agent, which depends on its type.",
+				Transform:   transform.FromField("Configurations"),
+			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the agent belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
+		},
+		List: &plugin.ListConfig{
+			Hydrate: listOpenStackNetworkAgent,
+			KeyColumns: plugin.KeyColumnSlice{
+				&plugin.KeyColumn{
+					Name:    "agent_type",
+					Require: plugin.Optional,
+				},
+				&plugin.KeyColumn{
+					Name:    "binary",
+					Require: plugin.Optional,
+				},
+				&plugin.KeyColumn{
+					Name:    "host",
+					Require: plugin.Optional,
+				},
+				&plugin.KeyColumn{
+					Name:    "alive",
+					Require: plugin.Optional,
+				},
+				&plugin.KeyColumn{
+					Name:    "availability_zone",
+					Require: plugin.Optional,
+				},
+			},
+		},
+		Get: &plugin.GetConfig{
+			KeyColumns: plugin.SingleColumn("id"),
+			Hydrate:    getOpenStackNetworkAgent,
+		},
+	}
+}
+
+//// LIST FUNCTION
+
+func listOpenStackNetworkAgent(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	plugin.Logger(ctx).Debug("retrieving openstack network agent list", "query data", utils.ToPrettyJSON(d))
+
+	client, err := getServiceClient(ctx, d, NetworkV2)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		return nil, err
+	}
+
+	opts := buildOpenStackNetworkAgentFilter(ctx, d.EqualsQuals)
+
+	err = agents.List(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+		allAgents, err := agents.ExtractAgents(page)
+		if err != nil {
+			plugin.Logger(ctx).Error("error extracting network agents", "error", err)
+			return false, err
+		}
+		plugin.Logger(ctx).Debug("network agents retrieved", "count", len(allAgents))
+
+		for _, agent := range allAgents {
+			agent := agent
+			d.StreamListItem(ctx, &agent)
+			if rowsRemaining(ctx, d) == 0 {
+				plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		plugin.Logger(ctx).Error("error listing network agents with options", "options", utils.ToPrettyJSON(opts), "error", err)
+		return nil, err
+	}
+	return nil, nil
+}
+
+//// HYDRATE FUNCTIONS
+
+func getOpenStackNetworkAgent(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	id := d.EqualsQuals["id"].GetStringValue()
+	plugin.Logger(ctx).Debug("retrieving openstack network agent", "id", id)
+
+	client, err := getServiceClient(ctx, d, NetworkV2)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		return nil, err
+	}
+
+	agent, err := agents.Get(client, id).Extract()
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving network agent", "error", err)
+		return nil, err
+	}
+	return agent, nil
+}
+
+//// UTILITY FUNCTIONS
+
+func buildOpenStackNetworkAgentFilter(ctx context.Context, quals plugin.KeyColumnEqualsQualMap) agents.ListOpts {
+	opts := agents.ListOpts{}
+	if value, ok := quals["agent_type"]; ok {
+		opts.AgentType = value.GetStringValue()
+	}
+	if value, ok := quals["binary"]; ok {
+		opts.Binary = value.GetStringValue()
+	}
+	if value, ok := quals["host"]; ok {
+		opts.Host = value.GetStringValue()
+	}
+	if value, ok := quals["alive"]; ok {
+		opts.Alive = utils.PointerTo(value.GetBoolValue())
+	}
+	if value, ok := quals["availability_zone"]; ok {
+		opts.AvailabilityZone = value.GetStringValue()
+	}
+	plugin.Logger(ctx).Debug("returning", "filter", utils.ToPrettyJSON(opts))
+	return opts
+}
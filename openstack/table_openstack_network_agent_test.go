@@ -0,0 +1,81 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestListOpenStackNetworkAgent(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackNetworkAgent(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "agent_type", "Open vSwitch agent", "L3 agent")
+	assertRows(t, rows, "alive", true, false)
+	assertRows(t, rows, "availability_zone", nil, "nova")
+	assertRow(t, rows[0], testRow{
+		"id":                  "2f4e6a8c-1b3d-4f5a-9c7e-0d2b4f6a8c1e",
+		"binary":              "neutron-openvswitch-agent",
+		"host":                "compute-01",
+		"admin_state_up":      true,
+		"heartbeat_timestamp": "2022-10-12T08:15:30Z",
+		"started_at":          "2022-10-01T06:30:12Z",
+		"created_at":          "2022-09-01T10:00:00Z",
+		"configurations":      `{"bridge_mappings":{"physnet1":"br-ex"},"tunnel_types":["vxlan"]}`,
+		"description":         nil,
+		"region":              "RegionOne",
+	})
+}
+
+func TestListOpenStackNetworkAgentFilters(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	_, err := cloud.list(tableOpenStackNetworkAgent(context.Background()), testQuery{
+		quals: map[string]any{
+			"agent_type":        "L3 agent",
+			"binary":            "neutron-l3-agent",
+			"host":              "network-01",
+			"alive":             false,
+			"availability_zone": "nova",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloud.assertQuery("/network/v2.0/agents", url.Values{
+		"agent_type":        {"L3 agent"},
+		"binary":            {"neutron-l3-agent"},
+		"host":              {"network-01"},
+		"alive":             {"false"},
+		"availability_zone": {"nova"},
+	})
+}
+
+func TestListOpenStackNetworkAgentError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /network/v2.0/agents", http.StatusForbidden)
+
+	if _, err := cloud.list(tableOpenStackNetworkAgent(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGetOpenStackNetworkAgent(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	table := tableOpenStackNetworkAgent(context.Background())
+
+	row, err := cloud.get(table, testQuery{quals: map[string]any{"id": "2f4e6a8c-1b3d-4f5a-9c7e-0d2b4f6a8c1e"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, row, testRow{"host": "compute-01", "alive": true})
+
+	row, err = cloud.get(table, testQuery{quals: map[string]any{"id": "00000000-0000-0000-0000-000000000000"}})
+	if err != nil || row != nil {
+		t.Errorf("expected no row and no error, got %v (error: %v)", row, err)
+	}
+}
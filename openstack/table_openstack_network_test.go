@@ -0,0 +1,126 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestListOpenStackNetwork(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackNetwork(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "private", "public")
+	assertRow(t, rows[0], testRow{
+		"id":                      "396f12f8-521e-4b91-8e21-2e003500433a",
+		"description":             "tenant network",
+		"project_id":              TestProjectID,
+		"admin_state_up":          true,
+		"availability_zone_hints": "[nova]",
+		"shared":                  false,
+		"status":                  "ACTIVE",
+		"subnets":                 `["a0304c3a-4f08-4c43-88af-d796509c97d2"]`,
+		"revision_number":         int64(2),
+		"created_at":              "2022-09-20T08:59:00Z",
+		"tags":                    `["tenant"]`,
+		"region":                  "RegionOne",
+	})
+	assertRow(t, rows[1], testRow{"shared": true})
+}
+
+func TestListOpenStackNetworkFilters(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	_, err := cloud.list(tableOpenStackNetwork(context.Background()), testQuery{
+		quals: map[string]any{
+			"id":             "396f12f8-521e-4b91-8e21-2e003500433a",
+			"name":           "private",
+			"description":    "tenant network",
+			"project_id":     TestProjectID,
+			"status":         "ACTIVE",
+			"shared":         false,
+			"admin_state_up": true,
+		},
+		limit: 5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloud.assertQuery("/network/v2.0/networks", url.Values{
+		"id":             {"396f12f8-521e-4b91-8e21-2e003500433a"},
+		"name":           {"private"},
+		"description":    {"tenant network"},
+		"project_id":     {TestProjectID},
+		"status":         {"ACTIVE"},
+		"shared":         {"false"},
+		"admin_state_up": {"true"},
+		"limit":          {"5"},
+	})
+}
+
+func TestListOpenStackNetworkError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /network/v2.0/networks", http.StatusInternalServerError)
+
+	if _, err := cloud.list(tableOpenStackNetwork(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGetOpenStackNetwork(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	table := tableOpenStackNetwork(context.Background())
+
+	row, err := cloud.get(table, testQuery{quals: map[string]any{"id": "396f12f8-521e-4b91-8e21-2e003500433a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, row, testRow{"name": "private"})
+
+	row, err = cloud.get(table, testQuery{quals: map[string]any{"id": "00000000-0000-0000-0000-000000000000"}})
+	if err != nil || row != nil {
+		t.Errorf("expected no row and no error, got %v (error: %v)", row, err)
+	}
+}
+
+func TestListOpenStackNetworkUpdatedAt(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	_, err := cloud.list(tableOpenStackNetwork(context.Background()), testQuery{
+		quals: map[string]any{
+			"updated_at >": time.Date(2022, 10, 1, 8, 30, 0, 0, time.UTC),
+			"updated_at <": time.Date(2022, 10, 8, 0, 0, 0, 0, time.UTC),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only the lower end can be pushed down
+	cloud.assertQuery("/network/v2.0/networks", url.Values{"changed_since": {"2022-10-01T08:30:00Z"}})
+}
+
+func TestListOpenStackNetworkTags(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackNetwork(context.Background()), testQuery{
+		quals: map[string]any{
+			"tags_all": "team-x,production",
+			"not_tags": "deprecated",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, rows[0], testRow{"tags_all": "team-x,production", "tags_any": nil})
+	cloud.assertQuery("/network/v2.0/networks", url.Values{
+		"tags":         {"team-x,production"},
+		"tags-any":     nil,
+		"not-tags":     {"deprecated"},
+		"not-tags-any": nil,
+	})
+}
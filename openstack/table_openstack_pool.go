@@ -4,7 +4,9 @@ import (
 	"context"
 
 	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/pools"
+	"github.com/gophercloud/gophercloud/pagination"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -14,8 +16,9 @@ import (
 
 func tableOpenStackPool(_ context.Context) *plugin.Table {
 	return &plugin.Table{
-		Name:        "openstack_pool",
-		Description: "OpenStack pool",
+		Name:              "openstack_pool",
+		Description:       "OpenStack pool",
+		GetMatrixItemFunc: regionMatrix,
 		Columns: []*plugin.Column{
 			{
 				Name:        "raw",
@@ -47,6 +50,13 @@ func tableOpenStackPool(_ context.Context) *plugin.Table {
 				Description: "The ID of the project owning this network.",
 				Transform:   transform.FromField("ProjectID"),
 			},
+			{
+				Name:        "project_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the project the pool belongs to.",
+				Hydrate:     getProjectName,
+				Transform:   transform.FromValue(),
+			},
 			{
 				Name:        "protocol",
 				Type:        proto.ColumnType_STRING,
@@ -107,6 +117,12 @@ func tableOpenStackPool(_ context.Context) *plugin.Table {
 				Description: "Tags is a list of security group tags. Tags are arbitrarily defined strings attached to a security group.",
 				Transform:   transform.FromField("Tags"),
 			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the pool belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
 		},
 		List: &plugin.ListConfig{
 			Hydrate: listOpenStackpool,
@@ -135,7 +151,7 @@ func tableOpenStackPool(_ context.Context) *plugin.Table {
 		},
 		Get: &plugin.GetConfig{
 			KeyColumns: plugin.SingleColumn("id"),
-			Hydrate:    getOpenStackpool,
+			Hydrate:    getInScope(getOpenStackpool),
 		},
 	}
 }
@@ -148,34 +164,46 @@ func listOpenStackpool(ctx context.Context, d *plugin.QueryData, h *plugin.Hydra
 
 	plugin.Logger(ctx).Debug("retrieving openstack pool list", "query data", utils.ToPrettyJSON(d))
 
-	client, err := getServiceClient(ctx, d, LbaasV2)
+	scope, err := getProjectScope(ctx, d)
 	if err != nil {
-		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
 		return nil, err
 	}
 
 	opts := buildOpenStackpoolFilter(ctx, d.EqualsQuals)
+	opts.Limit = getPageSize(d)
 
-	allPages, err := pools.List(client, opts).AllPages()
-	if err != nil {
-		plugin.Logger(ctx).Error("error listing pool with options", "options", utils.ToPrettyJSON(opts), "error", err)
-		return nil, err
-	}
-	allpools, err := pools.ExtractPools(allPages)
-	plugin.Logger(ctx).Debug("retrieving openstack allpools", "query data", utils.ToPrettyJSON(allpools))
-	if err != nil {
-		plugin.Logger(ctx).Error("error extracting networks", "error", err)
-		return nil, err
-	}
-	plugin.Logger(ctx).Debug("pools retrieved", "count", len(allpools))
+	err = forEachProject(ctx, d, scope, LbaasV2, func(client *gophercloud.ServiceClient, projectID string) error {
+		opts := opts
+		opts.ProjectID = projectID
 
-	for _, pool := range allpools {
-		if ctx.Err() != nil {
-			plugin.Logger(ctx).Debug("context done, exit")
-			break
+		err := pools.List(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+			allpools, err := pools.ExtractPools(page)
+			plugin.Logger(ctx).Debug("retrieving openstack allpools", "query data", utils.ToPrettyJSON(allpools))
+			if err != nil {
+				plugin.Logger(ctx).Error("error extracting networks", "error", err)
+				return false, err
+			}
+			plugin.Logger(ctx).Debug("pools retrieved", "count", len(allpools))
+
+			for _, pool := range allpools {
+				pool := pool
+				d.StreamListItem(ctx, &pool)
+				if rowsRemaining(ctx, d) == 0 {
+					plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+					return false, nil
+				}
+			}
+			return true, nil
+		})
+		if err != nil {
+			plugin.Logger(ctx).Error("error listing pool with options", "options", utils.ToPrettyJSON(opts), "error", err)
+			return err
 		}
-		pool := pool
-		d.StreamListItem(ctx, &pool)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return nil, nil
 }
@@ -195,6 +223,12 @@ func getOpenStackpool(ctx context.Context, d *plugin.QueryData, h *plugin.Hydrat
 		return nil, err
 	}
 
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+
 	result := pools.Get(client, id)
 	var pool *pools.Pool
 	pool, err = result.Extract()
@@ -203,6 +237,11 @@ func getOpenStackpool(ctx context.Context, d *plugin.QueryData, h *plugin.Hydrat
 		return nil, err
 	}
 
+	if !scope.Includes(pool.ProjectID) {
+		plugin.Logger(ctx).Debug("pool not in project scope", "id", id, "project", pool.ProjectID)
+		return nil, nil
+	}
+
 	return pool, nil
 }
 
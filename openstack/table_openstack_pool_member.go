@@ -4,7 +4,9 @@ import (
 	"context"
 
 	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/pools"
+	"github.com/gophercloud/gophercloud/pagination"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -14,8 +16,9 @@ import (
 
 func tableOpenStackPoolMember(_ context.Context) *plugin.Table {
 	return &plugin.Table{
-		Name:        "openstack_pool_member",
-		Description: "OpenStack Pool Member",
+		Name:              "openstack_pool_member",
+		Description:       "OpenStack Pool Member",
+		GetMatrixItemFunc: regionMatrix,
 		Columns: []*plugin.Column{
 			{
 				Name:        "id",
@@ -41,6 +44,13 @@ func tableOpenStackPoolMember(_ context.Context) *plugin.Table {
 				Description: "The ID of the project owning this network.",
 				Transform:   transform.FromField("ProjectID"),
 			},
+			{
+				Name:        "project_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the project the member belongs to.",
+				Hydrate:     getProjectName,
+				Transform:   transform.FromValue(),
+			},
 			{
 				Name:        "address",
 				Type:        proto.ColumnType_STRING,
@@ -89,6 +99,12 @@ func tableOpenStackPoolMember(_ context.Context) *plugin.Table {
 				Description: "Tags is a list of security group tags. Tags are arbitrarily defined strings attached to a security group.",
 				Transform:   transform.FromField("Tags"),
 			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the pool member belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
 		},
 		List: &plugin.ListConfig{
 			Hydrate: listOpenStackPoolMember,
@@ -108,66 +124,90 @@ func listOpenStackPoolMember(ctx context.Context, d *plugin.QueryData, h *plugin
 
 	plugin.Logger(ctx).Debug("returning", "filter", utils.ToPrettyJSON(d))
 
-	client, err := getServiceClient(ctx, d, LbaasV2)
-
-	// First get a list of pools
-	opts := buildOpenStackpoolFilter(ctx, d.EqualsQuals)
-	allPages, err := pools.List(client, opts).AllPages()
+	openstackConfig, err := getConnectionConfig(ctx, d)
 	if err != nil {
-		plugin.Logger(ctx).Error("error listing pool with options", "options", utils.ToPrettyJSON(opts), "error", err)
+		plugin.Logger(ctx).Error("error retrieving connection configuration", "error", err)
 		return nil, err
 	}
-	allpools, err := pools.ExtractPools(allPages)
-	pool_id := d.EqualsQuals["pool_id"].GetStringValue()
-
-	if pool_id != "" {
-		filteredPools := make([]pools.Pool, 0)
-		for _, pool := range allpools {
-			if pool.ID == pool_id {
-				filteredPools = append(filteredPools, pool)
-			}
-		}
-		allpools = filteredPools
-	}
-
-	plugin.Logger(ctx).Debug("retrieving openstack pool member", "pool id", pool_id)
 
+	scope, err := getProjectScope(ctx, d)
 	if err != nil {
-		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
 		return nil, err
 	}
 
-	for _, pool := range allpools {
-		opts := buildOpenStackPoolMemberFilter(ctx, d.EqualsQuals)
-		// opts := pools.ListMembersOpts{}
-
-		allPages, err := pools.ListMembers(client, pool.ID, opts).AllPages()
-
-		plugin.Logger(ctx).Debug("allPages", "---->", utils.ToPrettyJSON(allPages))
+	pool_id := d.EqualsQuals["pool_id"].GetStringValue()
+	plugin.Logger(ctx).Debug("retrieving openstack pool member", "pool id", pool_id)
 
-		if err != nil {
-			plugin.Logger(ctx).Error("error listing pool with options", "options", utils.ToPrettyJSON(opts), "error", err)
-			return nil, err
-		}
+	// first get the pools in scope, project by project and page by page
+	opts := buildOpenStackpoolFilter(ctx, d.EqualsQuals)
 
-		allmembers, err := pools.ExtractMembers(allPages)
+	err = forEachProject(ctx, d, scope, LbaasV2, func(client *gophercloud.ServiceClient, projectID string) error {
+		opts := opts
+		opts.ProjectID = projectID
 
-		if err != nil {
-			plugin.Logger(ctx).Error("error extracting networks", "error", err)
-			return nil, err
-		}
+		err := pools.List(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+			allpools, err := pools.ExtractPools(page)
+			if err != nil {
+				plugin.Logger(ctx).Error("error extracting pools", "error", err)
+				return false, err
+			}
 
-		plugin.Logger(ctx).Debug("allPools", "---->", utils.ToPrettyJSON(allmembers))
+			if pool_id != "" {
+				filteredPools := make([]pools.Pool, 0)
+				for _, pool := range allpools {
+					if pool.ID == pool_id {
+						filteredPools = append(filteredPools, pool)
+					}
+				}
+				allpools = filteredPools
+			}
 
-		for _, member := range allmembers {
-			if ctx.Err() != nil {
-				plugin.Logger(ctx).Debug("context done, exit")
-				break
+			// members are listed pool by pool, in parallel but within the same limit
+			// on requests in flight as the rest of the connection
+			err = forEachConcurrently(ctx, getMaxConcurrency(openstackConfig), allpools, func(pool pools.Pool) error {
+				opts := buildOpenStackPoolMemberFilter(ctx, d.EqualsQuals)
+				opts.Limit = getPageSize(d)
+
+				err := pools.ListMembers(client, pool.ID, opts).EachPage(func(page pagination.Page) (bool, error) {
+					allmembers, err := pools.ExtractMembers(page)
+					if err != nil {
+						plugin.Logger(ctx).Error("error extracting members", "error", err)
+						return false, err
+					}
+
+					plugin.Logger(ctx).Debug("allPools", "---->", utils.ToPrettyJSON(allmembers))
+
+					for _, member := range allmembers {
+						member.PoolID = pool.ID
+						plugin.Logger(ctx).Debug("pool", "---->", utils.ToPrettyJSON(member))
+						d.StreamListItem(ctx, member)
+						if rowsRemaining(ctx, d) == 0 {
+							plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+							return false, nil
+						}
+					}
+					return true, nil
+				})
+				if err != nil {
+					plugin.Logger(ctx).Error("error listing pool members with options", "options", utils.ToPrettyJSON(opts), "error", err)
+					return err
+				}
+				return nil
+			})
+			if err != nil {
+				return false, err
 			}
-			member.PoolID = pool.ID
-			plugin.Logger(ctx).Debug("pool", "---->", utils.ToPrettyJSON(member))
-			d.StreamListItem(ctx, member)
+			return rowsRemaining(ctx, d) > 0, nil
+		})
+		if err != nil {
+			plugin.Logger(ctx).Error("error listing pool with options", "options", utils.ToPrettyJSON(opts), "error", err)
+			return err
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return nil, nil
@@ -0,0 +1,77 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+)
+
+func TestListOpenStackPoolMember(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackPoolMember(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "web-01")
+	assertRow(t, rows[0], testRow{
+		"id":                  "957a1ace-1bd2-449b-8455-820b6e4b63f3",
+		"pool_id":             "4029d267-3983-4224-a3d0-afb3fe16a2cd",
+		"project_id":          TestProjectID,
+		"address":             "192.168.0.3",
+		"subnet_id":           "a0304c3a-4f08-4c43-88af-d796509c97d2",
+		"backup":              false,
+		"protocol_port":       "8080",
+		"provisioning_status": "ACTIVE",
+		"operating_status":    "ONLINE",
+		"tags":                `["web"]`,
+		"region":              "RegionOne",
+	})
+	// members are listed pool by pool
+	for _, pool := range []string{"4029d267-3983-4224-a3d0-afb3fe16a2cd", "7d1b5c3e-9f2a-4b6d-8e0c-1a2b3c4d5e6f"} {
+		if requests := cloud.received("/load-balancer/v2.0/lbaas/pools/" + pool + "/members"); len(requests) != 1 {
+			t.Errorf("expected one request for the members of pool %s, got %d", pool, len(requests))
+		}
+	}
+}
+
+func TestListOpenStackPoolMemberFilters(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackPoolMember(context.Background()), testQuery{
+		quals: map[string]any{"pool_id": "7d1b5c3e-9f2a-4b6d-8e0c-1a2b3c4d5e6f"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 0 {
+		t.Errorf("expected no members, got %d", len(rows))
+	}
+	// only the members of the selected pool are listed
+	if requests := cloud.received("/load-balancer/v2.0/lbaas/pools/4029d267-3983-4224-a3d0-afb3fe16a2cd/members"); len(requests) != 0 {
+		t.Errorf("expected no requests for the members of other pools, got %d", len(requests))
+	}
+}
+
+func TestListOpenStackPoolMemberScope(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.config.Scope = utils.PointerTo(OtherProjectID)
+
+	if _, err := cloud.list(tableOpenStackPoolMember(context.Background()), testQuery{}); err != nil {
+		t.Fatal(err)
+	}
+	// the pools are listed in the project in scope, like in openstack_pool
+	cloud.assertQuery("/load-balancer/v2.0/lbaas/pools", url.Values{"project_id": {OtherProjectID}})
+}
+
+func TestListOpenStackPoolMemberError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /load-balancer/v2.0/lbaas/pools/4029d267-3983-4224-a3d0-afb3fe16a2cd/members", http.StatusInternalServerError)
+
+	if _, err := cloud.list(tableOpenStackPoolMember(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
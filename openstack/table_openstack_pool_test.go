@@ -0,0 +1,78 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestListOpenStackPool(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackPool(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "web-pool", "spare-pool")
+	assertRow(t, rows[0], testRow{
+		"id":                  "4029d267-3983-4224-a3d0-afb3fe16a2cd",
+		"project_id":          TestProjectID,
+		"protocol":            "HTTP",
+		"subnet_id":           "a0304c3a-4f08-4c43-88af-d796509c97d2",
+		"loadbalancers":       `[{"id":"607226db-27ef-4d41-ae89-f2a800e9c2db"}]`,
+		"listeners":           `[{"id":"023f2e34-7806-443b-bfae-16c324569a3d"}]`,
+		"provisioning_status": "ACTIVE",
+		"operating_status":    "ONLINE",
+		"session_persistence": `{"type":"SOURCE_IP"}`,
+		"region":              "RegionOne",
+	})
+	assertRow(t, rows[1], testRow{"operating_status": "OFFLINE", "loadbalancers": `[]`})
+}
+
+func TestListOpenStackPoolFilters(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	_, err := cloud.list(tableOpenStackPool(context.Background()), testQuery{
+		quals: map[string]any{
+			"id":         "4029d267-3983-4224-a3d0-afb3fe16a2cd",
+			"name":       "web-pool",
+			"project_id": TestProjectID,
+		},
+		limit: 5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloud.assertQuery("/load-balancer/v2.0/lbaas/pools", url.Values{
+		"id":         {"4029d267-3983-4224-a3d0-afb3fe16a2cd"},
+		"name":       {"web-pool"},
+		"project_id": {TestProjectID},
+		"limit":      {"5"},
+	})
+}
+
+func TestListOpenStackPoolError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /load-balancer/v2.0/lbaas/pools", http.StatusInternalServerError)
+
+	if _, err := cloud.list(tableOpenStackPool(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGetOpenStackPool(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	table := tableOpenStackPool(context.Background())
+
+	row, err := cloud.get(table, testQuery{quals: map[string]any{"id": "4029d267-3983-4224-a3d0-afb3fe16a2cd"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, row, testRow{"name": "web-pool", "protocol": "HTTP"})
+
+	row, err = cloud.get(table, testQuery{quals: map[string]any{"id": "00000000-0000-0000-0000-000000000000"}})
+	if err != nil || row != nil {
+		t.Errorf("expected no row and no error, got %v (error: %v)", row, err)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 
 	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
+	"github.com/gophercloud/gophercloud/pagination"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -14,9 +16,10 @@ import (
 
 func tableOpenStackPort(_ context.Context) *plugin.Table {
 	return &plugin.Table{
-		Name:        "openstack_port",
-		Description: "OpenStack Network Port",
-		Columns: []*plugin.Column{
+		Name:              "openstack_port",
+		Description:       "OpenStack Network Port",
+		GetMatrixItemFunc: regionMatrix,
+		Columns: append([]*plugin.Column{
 			{
 				Name:        "id",
 				Type:        proto.ColumnType_STRING,
@@ -41,6 +44,13 @@ func tableOpenStackPort(_ context.Context) *plugin.Table {
 				Description: "Network that this port is associated with.",
 				Transform:   transform.FromField("NetworkID"),
 			},
+			{
+				Name:        "network_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the network the port belongs to.",
+				Hydrate:     getNetworkName,
+				Transform:   transform.FromValue(),
+			},
 			{
 				Name:        "admin_state_up",
 				Type:        proto.ColumnType_BOOL,
@@ -71,6 +81,13 @@ func tableOpenStackPort(_ context.Context) *plugin.Table {
 				Description: "The ID of the project owning this port.",
 				Transform:   transform.FromField("ProjectID"),
 			},
+			{
+				Name:        "project_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the project the port belongs to.",
+				Hydrate:     getProjectName,
+				Transform:   transform.FromValue(),
+			},
 			{
 				Name:        "device_owner",
 				Type:        proto.ColumnType_STRING,
@@ -91,13 +108,13 @@ func tableOpenStackPort(_ context.Context) *plugin.Table {
 			},
 			{
 				Name:        "created_at",
-				Type:        proto.ColumnType_STRING,
-				Description: "Timestamp when the port was created.",
+				Type:        proto.ColumnType_TIMESTAMP,
+				Description: "Timestamp when the port was created. Unlike updated_at, filters on it are not passed on to Neutron, which cannot filter on creation time.",
 				Transform:   transform.FromField("CreatedAt").Transform(ToTime),
 			},
 			{
 				Name:        "updated_at",
-				Type:        proto.ColumnType_STRING,
+				Type:        proto.ColumnType_TIMESTAMP,
 				Description: "Timestamp when the port was last updated.",
 				Transform:   transform.FromField("UpdatedAt").Transform(ToTime),
 			},
@@ -107,10 +124,22 @@ func tableOpenStackPort(_ context.Context) *plugin.Table {
 				Description: "The IDs of the security groups that apply to the current port.",
 				Transform:   transform.FromField("SecurityGroups").Transform(transform.EnsureStringArray),
 			},
-		},
+			{
+				Name:        "tags",
+				Type:        proto.ColumnType_JSON,
+				Description: "Tags is a list of port tags. Tags are arbitrarily defined strings attached to a port.",
+				Transform:   transform.FromField("Tags"),
+			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the network port belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
+		}, tagColumns()...),
 		List: &plugin.ListConfig{
 			Hydrate: listOpenStackPort,
-			KeyColumns: plugin.KeyColumnSlice{
+			KeyColumns: append(plugin.KeyColumnSlice{
 				&plugin.KeyColumn{
 					Name:    "id",
 					Require: plugin.Optional,
@@ -151,12 +180,16 @@ func tableOpenStackPort(_ context.Context) *plugin.Table {
 					Name:    "mac_address",
 					Require: plugin.Optional,
 				},
-				// TODO: add tags support
-			},
+				&plugin.KeyColumn{
+					Name:      "updated_at",
+					Operators: timeRangeOperators,
+					Require:   plugin.Optional,
+				},
+			}, tagKeyColumns()...),
 		},
 		Get: &plugin.GetConfig{
 			KeyColumns: plugin.SingleColumn("id"),
-			Hydrate:    getOpenStackPort,
+			Hydrate:    getInScope(getOpenStackPort),
 		},
 	}
 }
@@ -169,33 +202,47 @@ func listOpenStackPort(ctx context.Context, d *plugin.QueryData, h *plugin.Hydra
 
 	plugin.Logger(ctx).Debug("retrieving openstack ports list", "query data", utils.ToPrettyJSON(d))
 
-	client, err := getServiceClient(ctx, d, NetworkV2)
+	scope, err := getProjectScope(ctx, d)
 	if err != nil {
-		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
 		return nil, err
 	}
 
-	opts := buildOpenStackPortFilter(ctx, d.EqualsQuals)
+	opts := listPortsOpts{ListOpts: buildOpenStackPortFilter(ctx, d.EqualsQuals)}
+	opts.Limit = getPageSize(d)
+	// Neutron can only filter on the lower end of updated_at ranges
+	opts.ChangedSince = getTimeRange(d, "updated_at").Since()
 
-	allPages, err := ports.List(client, opts).AllPages()
-	if err != nil {
-		plugin.Logger(ctx).Error("error listing ports with options", "options", utils.ToPrettyJSON(opts), "error", err)
-		return nil, err
-	}
-	allPorts, err := ports.ExtractPorts(allPages)
-	if err != nil {
-		plugin.Logger(ctx).Error("error extracting ports", "error", err)
-		return nil, err
-	}
-	plugin.Logger(ctx).Debug("ports retrieved", "count", len(allPorts))
+	err = forEachProject(ctx, d, scope, NetworkV2, func(client *gophercloud.ServiceClient, projectID string) error {
+		opts := opts
+		opts.ProjectID = projectID
 
-	for _, port := range allPorts {
-		if ctx.Err() != nil {
-			plugin.Logger(ctx).Debug("context done, exit")
-			break
+		err := ports.List(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+			allPorts, err := ports.ExtractPorts(page)
+			if err != nil {
+				plugin.Logger(ctx).Error("error extracting ports", "error", err)
+				return false, err
+			}
+			plugin.Logger(ctx).Debug("ports retrieved", "count", len(allPorts))
+
+			for _, port := range allPorts {
+				port := port
+				d.StreamListItem(ctx, &port)
+				if rowsRemaining(ctx, d) == 0 {
+					plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+					return false, nil
+				}
+			}
+			return true, nil
+		})
+		if err != nil {
+			plugin.Logger(ctx).Error("error listing ports with options", "options", utils.ToPrettyJSON(opts), "error", err)
+			return err
 		}
-		port := port
-		d.StreamListItem(ctx, &port)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return nil, nil
 }
@@ -215,6 +262,12 @@ func getOpenStackPort(ctx context.Context, d *plugin.QueryData, h *plugin.Hydrat
 		return nil, err
 	}
 
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+
 	result := ports.Get(client, id)
 	var port *ports.Port
 	port, err = result.Extract()
@@ -223,6 +276,11 @@ func getOpenStackPort(ctx context.Context, d *plugin.QueryData, h *plugin.Hydrat
 		return nil, err
 	}
 
+	if !scope.Includes(port.ProjectID) {
+		plugin.Logger(ctx).Debug("port not in project scope", "id", id, "project", port.ProjectID)
+		return nil, nil
+	}
+
 	return port, nil
 }
 
@@ -259,6 +317,27 @@ func buildOpenStackPortFilter(ctx context.Context, quals plugin.KeyColumnEqualsQ
 	if value, ok := quals["mac_address"]; ok {
 		opts.MACAddress = value.GetStringValue()
 	}
+	tags := getTagFilter(quals)
+	opts.Tags = tags.Tags
+	opts.TagsAny = tags.TagsAny
+	opts.NotTags = tags.NotTags
+	opts.NotTagsAny = tags.NotTagsAny
 	plugin.Logger(ctx).Debug("returning", "filter", utils.ToPrettyJSON(opts))
 	return opts
 }
+
+// listPortsOpts adds to ports.ListOpts the filters that gophercloud does not
+// support.
+type listPortsOpts struct {
+	ports.ListOpts
+	// ChangedSince selects the ports updated since (or at) the given time.
+	ChangedSince string
+}
+
+func (opts listPortsOpts) ToPortListQuery() (string, error) {
+	query, err := opts.ListOpts.ToPortListQuery()
+	if err != nil {
+		return "", err
+	}
+	return addQueryParams(query, map[string]string{"changed_since": opts.ChangedSince})
+}
@@ -0,0 +1,146 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestListOpenStackPort(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackPort(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "id", "d80b1a3b-4fc1-49f3-952e-1e2ab7081d8b", "6a4e0a91-8bcd-4f0e-a6c9-5c3a1e4d2b10")
+	assertRow(t, rows[0], testRow{
+		"name":               "web-01-port",
+		"network_id":         "396f12f8-521e-4b91-8e21-2e003500433a",
+		"network_name":       "private",
+		"admin_state_up":     true,
+		"status":             "ACTIVE",
+		"mac_address":        "fa:16:3e:4c:2c:30",
+		"fixed_ips":          `[{"subnet_id":"a0304c3a-4f08-4c43-88af-d796509c97d2","ip_address":"192.168.0.3"}]`,
+		"project_id":         TestProjectID,
+		"device_owner":       "compute:nova",
+		"device_id":          "9168b536-cd40-4630-b43f-b259807c6e87",
+		"revision_number":    int64(3),
+		"created_at":         "2022-09-24T13:53:30Z",
+		"security_group_ids": "[85cc3048-abc3-43cc-89b3-377341426ac5]",
+		"region":             "RegionOne",
+	})
+	assertRow(t, rows[1], testRow{"admin_state_up": false, "security_group_ids": "[]"})
+}
+
+func TestListOpenStackPortFilters(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	_, err := cloud.list(tableOpenStackPort(context.Background()), testQuery{
+		quals: map[string]any{
+			"id":             "d80b1a3b-4fc1-49f3-952e-1e2ab7081d8b",
+			"name":           "web-01-port",
+			"status":         "ACTIVE",
+			"description":    "",
+			"admin_state_up": true,
+			"network_id":     "396f12f8-521e-4b91-8e21-2e003500433a",
+			"project_id":     TestProjectID,
+			"device_owner":   "compute:nova",
+			"device_id":      "9168b536-cd40-4630-b43f-b259807c6e87",
+			"mac_address":    "fa:16:3e:4c:2c:30",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloud.assertQuery("/network/v2.0/ports", url.Values{
+		"id":             {"d80b1a3b-4fc1-49f3-952e-1e2ab7081d8b"},
+		"name":           {"web-01-port"},
+		"status":         {"ACTIVE"},
+		"admin_state_up": {"true"},
+		"network_id":     {"396f12f8-521e-4b91-8e21-2e003500433a"},
+		"project_id":     {TestProjectID},
+		"device_owner":   {"compute:nova"},
+		"device_id":      {"9168b536-cd40-4630-b43f-b259807c6e87"},
+		"mac_address":    {"fa:16:3e:4c:2c:30"},
+	})
+}
+
+// TestListOpenStackPortPages checks that ports are streamed page by page, and
+// that no more pages are requested once the LIMIT is satisfied.
+func TestListOpenStackPortPages(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.handle("GET /network/v2.0/ports", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("marker") == "" {
+			writeJSON(w, http.StatusOK, map[string]any{
+				"ports":       []map[string]any{{"id": "port-1"}},
+				"ports_links": []map[string]any{{"rel": "next", "href": cloud.URL + "/network/v2.0/ports?marker=port-1"}},
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ports": []map[string]any{{"id": "port-2"}}})
+	})
+	table := tableOpenStackPort(context.Background())
+
+	rows, err := cloud.list(table, testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "id", "port-1", "port-2")
+	if requests := cloud.received("/network/v2.0/ports"); len(requests) != 2 {
+		t.Errorf("expected 2 requests, got %d", len(requests))
+	}
+
+	rows, err = cloud.list(table, testQuery{limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "id", "port-1")
+	if requests := cloud.received("/network/v2.0/ports"); len(requests) != 3 {
+		t.Errorf("expected a single page to be requested, got %d requests", len(requests)-2)
+	}
+	cloud.assertQuery("/network/v2.0/ports", url.Values{"limit": {"1"}})
+}
+
+func TestListOpenStackPortError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /network/v2.0/ports", http.StatusInternalServerError)
+
+	if _, err := cloud.list(tableOpenStackPort(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGetOpenStackPort(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	table := tableOpenStackPort(context.Background())
+
+	row, err := cloud.get(table, testQuery{quals: map[string]any{"id": "d80b1a3b-4fc1-49f3-952e-1e2ab7081d8b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, row, testRow{"name": "web-01-port", "region": "RegionOne"})
+
+	row, err = cloud.get(table, testQuery{quals: map[string]any{"id": "00000000-0000-0000-0000-000000000000"}})
+	if err != nil || row != nil {
+		t.Errorf("expected no row and no error, got %v (error: %v)", row, err)
+	}
+}
+
+func TestListOpenStackPortUpdatedAt(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	_, err := cloud.list(tableOpenStackPort(context.Background()), testQuery{
+		quals: map[string]any{
+			"updated_at >": time.Date(2022, 10, 1, 8, 30, 0, 0, time.UTC),
+			"updated_at <": time.Date(2022, 10, 8, 0, 0, 0, 0, time.UTC),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only the lower end can be pushed down
+	cloud.assertQuery("/network/v2.0/ports", url.Values{"changed_since": {"2022-10-01T08:30:00Z"}})
+}
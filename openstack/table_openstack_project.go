@@ -6,6 +6,7 @@ import (
 	"github.com/dihedron/steampipe-plugin-utils/utils"
 	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/identity/v3/projects"
+	"github.com/gophercloud/gophercloud/pagination"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -48,6 +49,13 @@ func tableOpenStackProject(_ context.Context) *plugin.Table {
 				Description: "The ID of the domain the project belongs to.",
 				Transform:   transform.FromField("DomainID"),
 			},
+			{
+				Name:        "domain_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the domain the project belongs to.",
+				Hydrate:     getDomainName,
+				Transform:   transform.FromValue(),
+			},
 			{
 				Name:        "enabled",
 				Type:        proto.ColumnType_BOOL,
@@ -110,26 +118,28 @@ func listOpenStackProject(ctx context.Context, d *plugin.QueryData, h *plugin.Hy
 
 	opts := buildOpenStackProjectFilter(ctx, d.EqualsQuals)
 
-	allPages, err := projects.List(client, opts).AllPages()
+	err = projects.List(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+		allProjects, err := projects.ExtractProjects(page)
+		if err != nil {
+			plugin.Logger(ctx).Error("error extracting projects", "error", err)
+			return false, err
+		}
+		plugin.Logger(ctx).Debug("projects retrieved", "count", len(allProjects))
+
+		for _, project := range allProjects {
+			project := project
+			d.StreamListItem(ctx, &project)
+			if rowsRemaining(ctx, d) == 0 {
+				plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+				return false, nil
+			}
+		}
+		return true, nil
+	})
 	if err != nil {
 		plugin.Logger(ctx).Error("error listing projects with options", "options", utils.ToPrettyJSON(opts), "error", err)
 		return nil, err
 	}
-	allProjects, err := projects.ExtractProjects(allPages)
-	if err != nil {
-		plugin.Logger(ctx).Error("error extracting projects", "error", err)
-		return nil, err
-	}
-	plugin.Logger(ctx).Debug("projects retrieved", "count", len(allProjects))
-
-	for _, project := range allProjects {
-		if ctx.Err() != nil {
-			plugin.Logger(ctx).Debug("context done, exit")
-			break
-		}
-		project := project
-		d.StreamListItem(ctx, &project)
-	}
 	return nil, nil
 }
 
@@ -0,0 +1,77 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestListOpenStackProject(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackProject(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "admin", "databases")
+	assertRow(t, rows[0], testRow{
+		"id":          TestProjectID,
+		"description": "Bootstrap project for initializing the cloud.",
+		"domain_id":   "default",
+		"domain_name": "Default",
+		"enabled":     true,
+		"is_domain":   false,
+		"parent_id":   "default",
+	})
+	assertRow(t, rows[1], testRow{"enabled": false})
+}
+
+func TestListOpenStackProjectFilters(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	_, err := cloud.list(tableOpenStackProject(context.Background()), testQuery{
+		quals: map[string]any{
+			"name":      "admin",
+			"is_domain": false,
+			"domain_id": "default",
+			"enabled":   true,
+			"parent_id": "default",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloud.assertQuery("/identity/v3/projects", url.Values{
+		"name":      {"admin"},
+		"is_domain": {"false"},
+		"domain_id": {"default"},
+		"enabled":   {"true"},
+		"parent_id": {"default"},
+	})
+}
+
+func TestListOpenStackProjectError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /identity/v3/projects", http.StatusForbidden)
+
+	if _, err := cloud.list(tableOpenStackProject(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGetOpenStackProject(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	table := tableOpenStackProject(context.Background())
+
+	row, err := cloud.get(table, testQuery{quals: map[string]any{"id": TestProjectID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, row, testRow{"name": "admin", "enabled": true})
+
+	row, err = cloud.get(table, testQuery{quals: map[string]any{"id": "00000000000000000000000000000000"}})
+	if err != nil || row != nil {
+		t.Errorf("expected no row and no error, got %v (error: %v)", row, err)
+	}
+}
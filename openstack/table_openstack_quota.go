@@ -0,0 +1,296 @@
+package openstack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud"
+	cinderquotas "github.com/gophercloud/gophercloud/openstack/blockstorage/extensions/quotasets"
+	novaquotas "github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/quotasets"
+	octaviaquotas "github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/quotas"
+	neutronquotas "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/quotas"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableOpenStackQuota(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:              "openstack_quota",
+		Description:       "OpenStack Quota",
+		GetMatrixItemFunc: regionMatrix,
+		Columns: []*plugin.Column{
+			{
+				Name:        "project_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the project the quota applies to.",
+				Transform:   transform.FromField("ProjectID"),
+			},
+			{
+				Name:        "project_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the project the quota applies to.",
+				Hydrate:     getProjectName,
+				Transform:   transform.FromValue(),
+			},
+			{
+				Name:        "service",
+				Type:        proto.ColumnType_STRING,
+				Description: "The service enforcing the quota: compute, network, volume or loadbalancer.",
+				Transform:   transform.FromField("Service"),
+			},
+			{
+				Name:        "resource",
+				Type:        proto.ColumnType_STRING,
+				Description: "The resource the quota applies to, as named by the service, e.g. cores, port or gigabytes.",
+				Transform:   transform.FromField("Resource"),
+			},
+			{
+				Name:        "limit",
+				Type:        proto.ColumnType_INT,
+				Description: "The maximum amount of the resource the project can use; -1 means unlimited.",
+				Transform:   transform.FromField("Limit"),
+			},
+			{
+				Name:        "in_use",
+				Type:        proto.ColumnType_INT,
+				Description: "The amount of the resource the project uses, if the service reports it.",
+				Transform:   transform.FromField("InUse"),
+			},
+			{
+				Name:        "reserved",
+				Type:        proto.ColumnType_INT,
+				Description: "The amount of the resource reserved for operations in progress, if the service reports it.",
+				Transform:   transform.FromField("Reserved"),
+			},
+			{
+				Name:        "percent_used",
+				Type:        proto.ColumnType_DOUBLE,
+				Description: "The percentage of the limit in use or reserved; null for unlimited resources or if the service does not report usage.",
+				Transform:   transform.FromMethod("GetPercentUsed"),
+			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the quota applies to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
+		},
+		List: &plugin.ListConfig{
+			Hydrate: listOpenStackQuota,
+			KeyColumns: plugin.KeyColumnSlice{
+				&plugin.KeyColumn{
+					Name:    "project_id",
+					Require: plugin.Optional,
+				},
+				&plugin.KeyColumn{
+					Name:    "service",
+					Require: plugin.Optional,
+				},
+				&plugin.KeyColumn{
+					Name:    "resource",
+					Require: plugin.Optional,
+				},
+			},
+		},
+	}
+}
+
+//// LIST FUNCTION
+
+func listOpenStackQuota(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	plugin.Logger(ctx).Debug("retrieving openstack quota list", "query data", utils.ToPrettyJSON(d))
+
+	// quotas are retrieved one project at a time, like attachments
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+	projectIDs, err := getProjectIDs(ctx, d, scope)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving projects", "error", err)
+		return nil, err
+	}
+
+	services := []quotaService{}
+	for _, service := range quotaServices {
+		if name := d.EqualsQualString("service"); name == "" || name == service.Name {
+			services = append(services, service)
+		}
+	}
+
+	openstackConfig, err := getConnectionConfig(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving connection configuration", "error", err)
+		return nil, err
+	}
+
+	resource := d.EqualsQualString("resource")
+	err = forEachConcurrently(ctx, getMaxConcurrency(openstackConfig), projectIDs, func(projectID string) error {
+		for _, service := range services {
+			client, err := getServiceClient(scope.Context(ctx, projectID), d, service.Type)
+			var notFound *gophercloud.ErrEndpointNotFound
+			if errors.As(err, &notFound) {
+				plugin.Logger(ctx).Warn("service not available, skipping its quotas", "service", service.Name)
+				continue
+			}
+			if err != nil {
+				plugin.Logger(ctx).Error("error retrieving client", "error", err)
+				return err
+			}
+
+			quotas, err := getQuotas(service, service.Get(client, projectID))
+			if err != nil {
+				plugin.Logger(ctx).Error("error retrieving quotas", "service", service.Name, "project", projectID, "error", err)
+				return err
+			}
+			plugin.Logger(ctx).Debug("quotas retrieved", "service", service.Name, "project", projectID, "count", len(quotas))
+
+			for _, quota := range quotas {
+				if resource != "" && quota.Resource != resource {
+					continue
+				}
+				quota.ProjectID = projectID
+				d.StreamListItem(ctx, quota)
+				if rowsRemaining(ctx, d) == 0 {
+					plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+					return nil
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+//// UTILITY FUNCTIONS
+
+// quota is the limit and usage of a resource in a project.
+type quota struct {
+	ProjectID string
+	Service   string
+	Resource  string
+	Limit     int64
+	InUse     *int64
+	Reserved  *int64
+}
+
+// GetPercentUsed returns the percentage of the limit in use or reserved, or
+// nil if the resource is unlimited or its usage is unknown.
+func (q *quota) GetPercentUsed() *float64 {
+	if q.InUse == nil || q.Limit < 0 {
+		return nil
+	}
+	used := *q.InUse
+	if q.Reserved != nil {
+		used += *q.Reserved
+	}
+	if q.Limit == 0 {
+		if used == 0 {
+			return utils.PointerTo(0.0)
+		}
+		return utils.PointerTo(100.0)
+	}
+	return utils.PointerTo(float64(used) * 100 / float64(q.Limit))
+}
+
+// quotaService describes how the quotas of a project are retrieved from one of
+// the services.
+type quotaService struct {
+	// Name is the value of the service column.
+	Name string
+	// Type is the type of the service client.
+	Type ServiceType
+	// Get retrieves the quotas of the project, with their usage if available.
+	Get func(client *gophercloud.ServiceClient, projectID string) gophercloud.Result
+	// Ignored are the resources still returned for backwards compatibility
+	// under older names.
+	Ignored []string
+}
+
+var quotaServices = []quotaService{
+	{
+		Name: "compute",
+		Type: ComputeV2,
+		Get: func(client *gophercloud.ServiceClient, projectID string) gophercloud.Result {
+			return novaquotas.GetDetail(client, projectID).Result
+		},
+	},
+	{
+		Name: "network",
+		Type: NetworkV2,
+		Get: func(client *gophercloud.ServiceClient, projectID string) gophercloud.Result {
+			return neutronquotas.GetDetail(client, projectID).Result
+		},
+	},
+	{
+		Name: "volume",
+		Type: BlockStorageV3,
+		Get: func(client *gophercloud.ServiceClient, projectID string) gophercloud.Result {
+			return cinderquotas.GetUsage(client, projectID).Result
+		},
+	},
+	{
+		Name: "loadbalancer",
+		Type: LbaasV2,
+		Get: func(client *gophercloud.ServiceClient, projectID string) gophercloud.Result {
+			return octaviaquotas.Get(client, projectID).Result
+		},
+		Ignored: []string{"loadbalancer", "healthmonitor"},
+	},
+}
+
+// getQuotas returns the quotas in the response of a service, sorted by
+// resource; each service wraps them in a quota or quota_set object, where
+// they are either plain limits or objects with the limit and the usage (as
+// in_use or, for Neutron, used).
+func getQuotas(service quotaService, result gophercloud.Result) ([]*quota, error) {
+	var body map[string]map[string]json.RawMessage
+	if err := result.ExtractInto(&body); err != nil {
+		return nil, err
+	}
+	values := body["quota_set"]
+	if values == nil {
+		values = body["quota"]
+	}
+
+	quotas := []*quota{}
+	for resource, value := range values {
+		if resource == "id" || contains(service.Ignored, resource) {
+			continue
+		}
+		q := &quota{Service: service.Name, Resource: resource}
+		if err := json.Unmarshal(value, &q.Limit); err != nil {
+			var detail struct {
+				Limit    int64  `json:"limit"`
+				InUse    *int64 `json:"in_use"`
+				Used     *int64 `json:"used"`
+				Reserved *int64 `json:"reserved"`
+			}
+			if err := json.Unmarshal(value, &detail); err != nil {
+				return nil, err
+			}
+			q.Limit, q.InUse, q.Reserved = detail.Limit, detail.InUse, detail.Reserved
+			if q.InUse == nil {
+				q.InUse = detail.Used
+			}
+		}
+		quotas = append(quotas, q)
+	}
+	sort.Slice(quotas, func(i, j int) bool {
+		return quotas[i].Resource < quotas[j].Resource
+	})
+	return quotas, nil
+}
@@ -0,0 +1,120 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestListOpenStackQuota(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackQuota(context.Background()), testQuery{quals: map[string]any{"project_id": TestProjectID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// services are listed in a fixed order, resources by name
+	assertRows(t, rows, "resource",
+		"cores", "instances", "key_pairs", "ram", "server_group_members", "server_groups",
+		"floatingip", "network", "port", "security_group",
+		"gigabytes", "snapshots", "volumes",
+		"health_monitor", "listener", "load_balancer", "member", "pool")
+	assertRows(t, rows, "limit",
+		int64(20), int64(10), int64(100), int64(51200), int64(-1), int64(10),
+		int64(0), int64(100), int64(50), int64(10),
+		int64(1000), int64(-1), int64(10),
+		int64(-1), int64(-1), int64(5), int64(50), int64(-1))
+	assertRows(t, rows, "percent_used",
+		30.0, 20.0, 0.0, 16.0, nil, 20.0,
+		0.0, 1.0, 90.0, 20.0,
+		5.0, nil, 10.0,
+		nil, nil, nil, nil, nil)
+	assertRow(t, rows[3], testRow{
+		"project_id":   TestProjectID,
+		"project_name": "admin",
+		"service":      "compute",
+		"in_use":       int64(6144),
+		"reserved":     int64(2048),
+		"region":       "RegionOne",
+	})
+	// Octavia does not report usage
+	assertRow(t, rows[15], testRow{"service": "loadbalancer", "in_use": nil, "reserved": nil})
+}
+
+func TestListOpenStackQuotaMissingService(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.withoutService("load-balancer")
+
+	rows, err := cloud.list(tableOpenStackQuota(context.Background()), testQuery{quals: map[string]any{"project_id": TestProjectID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the quotas of the other services are still listed
+	services := map[any]bool{}
+	for _, row := range rows {
+		services[row["service"]] = true
+	}
+	if !services["compute"] || !services["network"] || !services["volume"] || services["loadbalancer"] {
+		t.Errorf("expected the quotas of all services but loadbalancer, got %v", services)
+	}
+}
+
+func TestListOpenStackQuotaFilters(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackQuota(context.Background()), testQuery{quals: map[string]any{
+		"project_id": TestProjectID,
+		"service":    "volume",
+		"resource":   "gigabytes",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "in_use", int64(50))
+
+	// only the quotas of the requested service are retrieved
+	for _, path := range []string{
+		"/compute/v2.1/os-quota-sets/" + TestProjectID + "/detail",
+		"/network/v2.0/quotas/" + TestProjectID + "/details.json",
+		"/load-balancer/v2/quotas/" + TestProjectID,
+	} {
+		if requests := cloud.received(path); len(requests) != 0 {
+			t.Errorf("expected no requests for %s, got %d", path, len(requests))
+		}
+	}
+	if requests := cloud.received("/volumev3/v3/os-quota-sets/" + TestProjectID); len(requests) != 1 || requests[0].Query.Get("usage") != "true" {
+		t.Errorf("expected the volume quotas to be retrieved with their usage, got %v", requests)
+	}
+}
+
+func TestListOpenStackQuotaAllProjects(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.handle("GET /compute/v2.1/os-quota-sets/"+OtherProjectID+"/detail", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"quota_set": map[string]any{
+			"id":    OtherProjectID,
+			"cores": map[string]any{"in_use": 4, "limit": 8, "reserved": 0},
+		}})
+	})
+
+	// without a project_id qual, the quotas of every project are retrieved
+	rows, err := cloud.list(tableOpenStackQuota(context.Background()), testQuery{quals: map[string]any{"service": "compute", "resource": "cores"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	for _, row := range rows {
+		expected := map[string]float64{TestProjectID: 30.0, OtherProjectID: 50.0}[row["project_id"].(string)]
+		assertRow(t, row, testRow{"percent_used": expected})
+	}
+}
+
+func TestListOpenStackQuotaError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /network/v2.0/quotas/"+TestProjectID+"/details.json", http.StatusForbidden)
+
+	if _, err := cloud.list(tableOpenStackQuota(context.Background()), testQuery{quals: map[string]any{"project_id": TestProjectID}}); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 
 	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/groups"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/rules"
+	"github.com/gophercloud/gophercloud/pagination"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -15,9 +17,10 @@ import (
 
 func tableOpenStackSecurityGroup(ctx context.Context) *plugin.Table {
 	return &plugin.Table{
-		Name:        "openstack_security_group",
-		Description: "OpenStack Security Group",
-		Columns: []*plugin.Column{
+		Name:              "openstack_security_group",
+		Description:       "OpenStack Security Group",
+		GetMatrixItemFunc: regionMatrix,
+		Columns: append([]*plugin.Column{
 			{
 				Name:        "id",
 				Description: "The ID of the security group we're retrieving.",
@@ -43,14 +46,21 @@ func tableOpenStackSecurityGroup(ctx context.Context) *plugin.Table {
 				Transform:   transform.FromField("TenantID"),
 			},
 			{
-				Name:        "created_at",
+				Name:        "project_name",
 				Type:        proto.ColumnType_STRING,
-				Description: "The creation time of the security group",
+				Description: "The name of the project the security group belongs to.",
+				Hydrate:     getProjectName,
+				Transform:   transform.FromValue(),
+			},
+			{
+				Name:        "created_at",
+				Type:        proto.ColumnType_TIMESTAMP,
+				Description: "The creation time of the security group; unlike updated_at, filters on it are not passed on to Neutron, which cannot filter on creation time",
 				Transform:   transform.FromField("CreatedAt").Transform(ToTime),
 			},
 			{
 				Name:        "updated_at",
-				Type:        proto.ColumnType_STRING,
+				Type:        proto.ColumnType_TIMESTAMP,
 				Description: "The update time of the security group",
 				Transform:   transform.FromField("UpdatedAt").Transform(ToTime),
 			},
@@ -72,10 +82,16 @@ func tableOpenStackSecurityGroup(ctx context.Context) *plugin.Table {
 				Description: "The security group rules that belong to the current security group.",
 				Transform:   transform.FromField("Rules"), //.Transform(transform.EnsureStringArray),
 			},
-		},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the security group belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
+		}, tagColumns()...),
 		List: &plugin.ListConfig{
 			Hydrate: listOpenStackSecurityGroup,
-			KeyColumns: plugin.KeyColumnSlice{
+			KeyColumns: append(plugin.KeyColumnSlice{
 				&plugin.KeyColumn{
 					Name:    "id",
 					Require: plugin.Optional,
@@ -92,11 +108,16 @@ func tableOpenStackSecurityGroup(ctx context.Context) *plugin.Table {
 					Name:    "project_id",
 					Require: plugin.Optional,
 				},
-			},
+				&plugin.KeyColumn{
+					Name:      "updated_at",
+					Operators: timeRangeOperators,
+					Require:   plugin.Optional,
+				},
+			}, tagKeyColumns()...),
 		},
 		Get: &plugin.GetConfig{
 			KeyColumns: plugin.SingleColumn("id"),
-			Hydrate:    getOpenStackSecurityGroup,
+			Hydrate:    getInScope(getOpenStackSecurityGroup),
 		},
 	}
 }
@@ -109,33 +130,47 @@ func listOpenStackSecurityGroup(ctx context.Context, d *plugin.QueryData, h *plu
 
 	plugin.Logger(ctx).Debug("list security groups", "query data", utils.ToPrettyJSON(d), "hydrate data", utils.ToPrettyJSON(h))
 
-	client, err := getServiceClient(ctx, d, NetworkV2)
+	scope, err := getProjectScope(ctx, d)
 	if err != nil {
-		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
 		return nil, err
 	}
 
 	opts := buildOpenStackSecurityGroupFilter(ctx, d.EqualsQuals)
+	opts.Limit = getPageSize(d)
+	// Neutron can only filter on the lower end of updated_at ranges
+	changedSince := getTimeRange(d, "updated_at").Since()
 
-	allPages, err := groups.List(client, opts).AllPages()
-	if err != nil {
-		plugin.Logger(ctx).Error("error listing security groups with options", "options", utils.ToPrettyJSON(opts), "error", err)
-		return nil, err
-	}
-	allGroups, err := groups.ExtractGroups(allPages)
-	if err != nil {
-		plugin.Logger(ctx).Error("error extracting groups", "error", err)
-		return nil, err
-	}
-	plugin.Logger(ctx).Debug("groups retrieved", "count", len(allGroups))
+	err = forEachProject(ctx, d, scope, NetworkV2, func(client *gophercloud.ServiceClient, projectID string) error {
+		opts := opts
+		opts.ProjectID = projectID
+
+		err := listSecurityGroups(client, opts, changedSince).EachPage(func(page pagination.Page) (bool, error) {
+			allGroups, err := groups.ExtractGroups(page)
+			if err != nil {
+				plugin.Logger(ctx).Error("error extracting groups", "error", err)
+				return false, err
+			}
+			plugin.Logger(ctx).Debug("groups retrieved", "count", len(allGroups))
 
-	for _, group := range allGroups {
-		if ctx.Err() != nil {
-			plugin.Logger(ctx).Debug("context done, exit")
-			break
+			for _, group := range allGroups {
+				group := group
+				d.StreamListItem(ctx, &group)
+				if rowsRemaining(ctx, d) == 0 {
+					plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+					return false, nil
+				}
+			}
+			return true, nil
+		})
+		if err != nil {
+			plugin.Logger(ctx).Error("error listing security groups with options", "options", utils.ToPrettyJSON(opts), "error", err)
+			return err
 		}
-		group := group
-		d.StreamListItem(ctx, &group)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return nil, nil
 
@@ -156,6 +191,12 @@ func getOpenStackSecurityGroup(ctx context.Context, d *plugin.QueryData, h *plug
 		return nil, err
 	}
 
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+
 	result := groups.Get(client, id)
 	var group *groups.SecGroup
 	group, err = result.Extract()
@@ -164,6 +205,11 @@ func getOpenStackSecurityGroup(ctx context.Context, d *plugin.QueryData, h *plug
 		return nil, err
 	}
 
+	if !scope.Includes(group.ProjectID) {
+		plugin.Logger(ctx).Debug("security group not in project scope", "id", id, "project", group.ProjectID)
+		return nil, nil
+	}
+
 	return group, nil
 }
 
@@ -186,7 +232,11 @@ func buildOpenStackSecurityGroupFilter(ctx context.Context, quals plugin.KeyColu
 		opts.ProjectID = value.GetStringValue()
 	}
 
-	// TODO: handle tags
+	tags := getTagFilter(quals)
+	opts.Tags = tags.Tags
+	opts.TagsAny = tags.TagsAny
+	opts.NotTags = tags.NotTags
+	opts.NotTagsAny = tags.NotTagsAny
 	plugin.Logger(ctx).Debug("returning", "filter", utils.ToPrettyJSON(opts))
 	return opts
 }
@@ -202,3 +252,19 @@ func extractSecGroupRuleIDs(_ context.Context, d *transform.TransformData) (inte
 	}
 	return values, nil
 }
+
+// listSecurityGroups is like groups.List, with the changed_since filter that
+// gophercloud does not support (and whose ListOpts cannot be extended).
+func listSecurityGroups(client *gophercloud.ServiceClient, opts groups.ListOpts, changedSince string) pagination.Pager {
+	query, err := gophercloud.BuildQueryString(&opts)
+	if err != nil {
+		return pagination.Pager{Err: err}
+	}
+	q, err := addQueryParams(query.String(), map[string]string{"changed_since": changedSince})
+	if err != nil {
+		return pagination.Pager{Err: err}
+	}
+	return pagination.NewPager(client, client.ServiceURL("security-groups")+q, func(r pagination.PageResult) pagination.Page {
+		return groups.SecGroupPage{LinkedPageBase: pagination.LinkedPageBase{PageResult: r}}
+	})
+}
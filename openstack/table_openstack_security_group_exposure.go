@@ -0,0 +1,487 @@
+package openstack
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/rules"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
+	"github.com/gophercloud/gophercloud/pagination"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableOpenStackSecurityGroupExposure(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:              "openstack_security_group_exposure",
+		Description:       "OpenStack Security Group Exposure, i.e. the effective ingress of each port given the security group rules that apply to it.",
+		GetMatrixItemFunc: regionMatrix,
+		Columns: []*plugin.Column{
+			{
+				Name:        "instance_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the instance the port is attached to, if any.",
+				Transform:   transform.FromField("InstanceID").Transform(transform.NullIfZeroValue),
+			},
+			{
+				Name:        "port_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the exposed port.",
+				Transform:   transform.FromField("PortID"),
+			},
+			{
+				Name:        "network_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the network the port belongs to.",
+				Transform:   transform.FromField("NetworkID"),
+			},
+			{
+				Name:        "project_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the project owning the port.",
+				Transform:   transform.FromField("ProjectID"),
+			},
+			{
+				Name:        "project_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the project owning the port.",
+				Hydrate:     getProjectName,
+				Transform:   transform.FromValue(),
+			},
+			{
+				Name:        "addresses",
+				Type:        proto.ColumnType_JSON,
+				Description: "The addresses of the port that are exposed, i.e. those of the rule's IP version.",
+				Transform:   transform.FromField("Addresses"),
+			},
+			{
+				Name:        "security_group_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the security group allowing the traffic, if any.",
+				Transform:   transform.FromField("SecurityGroupID").Transform(transform.NullIfZeroValue),
+			},
+			{
+				Name:        "security_group_rule_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the security group rule allowing the traffic, if any; there is none when port security is disabled.",
+				Transform:   transform.FromField("SecurityGroupRuleID").Transform(transform.NullIfZeroValue),
+			},
+			{
+				Name:        "ethertype",
+				Type:        proto.ColumnType_STRING,
+				Description: "The IP version of the traffic: IPv4 or IPv6.",
+				Transform:   transform.FromField("EtherType"),
+			},
+			{
+				Name:        "protocol",
+				Type:        proto.ColumnType_STRING,
+				Description: "The protocol allowed (e.g. tcp, udp or icmp), or null for any.",
+				Transform:   transform.FromField("Protocol").Transform(transform.NullIfZeroValue),
+			},
+			{
+				Name:        "port_range_min",
+				Type:        proto.ColumnType_INT,
+				Description: "The lowest port exposed, for protocols with ports.",
+				Transform:   transform.FromField("PortRangeMin"),
+			},
+			{
+				Name:        "port_range_max",
+				Type:        proto.ColumnType_INT,
+				Description: "The highest port exposed, for protocols with ports.",
+				Transform:   transform.FromField("PortRangeMax"),
+			},
+			{
+				Name:        "source",
+				Type:        proto.ColumnType_CIDR,
+				Description: "The network the traffic is allowed from; members of remote groups are listed one by one.",
+				Transform:   transform.FromField("Source"),
+			},
+			{
+				Name:        "remote_group_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the remote group the source is a member of, if any.",
+				Transform:   transform.FromField("RemoteGroupID").Transform(transform.NullIfZeroValue),
+			},
+			{
+				Name:        "open_to_world",
+				Type:        proto.ColumnType_BOOL,
+				Description: "Whether the traffic is allowed from anywhere, i.e. the source covers a large part of the address space, like 0.0.0.0/0, 0.0.0.0/1 or ::/0.",
+				Transform:   transform.FromField("OpenToWorld"),
+			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the port belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
+		},
+		List: &plugin.ListConfig{
+			Hydrate: listOpenStackSecurityGroupExposure,
+			KeyColumns: plugin.KeyColumnSlice{
+				&plugin.KeyColumn{
+					Name:    "instance_id",
+					Require: plugin.Optional,
+				},
+				&plugin.KeyColumn{
+					Name:    "port_id",
+					Require: plugin.Optional,
+				},
+				&plugin.KeyColumn{
+					Name:    "project_id",
+					Require: plugin.Optional,
+				},
+			},
+		},
+	}
+}
+
+//// LIST FUNCTION
+
+func listOpenStackSecurityGroupExposure(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	plugin.Logger(ctx).Debug("retrieving openstack security group exposure list", "query data", utils.ToPrettyJSON(d))
+
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+
+	// all the ports are needed, not only those selected by the quals, to
+	// resolve the members of remote groups
+	var lock sync.Mutex
+	allRules := []rules.SecGroupRule{}
+	allPorts := []exposurePort{}
+	err = forEachProject(ctx, d, scope, NetworkV2, func(client *gophercloud.ServiceClient, projectID string) error {
+		ruleOpts := rules.ListOpts{Direction: string(rules.DirIngress), ProjectID: projectID}
+		err := rules.List(client, ruleOpts).EachPage(func(page pagination.Page) (bool, error) {
+			pageRules, err := rules.ExtractRules(page)
+			if err != nil {
+				plugin.Logger(ctx).Error("error extracting rules", "error", err)
+				return false, err
+			}
+			lock.Lock()
+			allRules = append(allRules, pageRules...)
+			lock.Unlock()
+			return true, nil
+		})
+		if err != nil {
+			plugin.Logger(ctx).Error("error listing security group rules with options", "options", utils.ToPrettyJSON(ruleOpts), "error", err)
+			return err
+		}
+
+		portOpts := ports.ListOpts{ProjectID: projectID}
+		err = ports.List(client, portOpts).EachPage(func(page pagination.Page) (bool, error) {
+			pagePorts := []exposurePort{}
+			if err := ports.ExtractPortsInto(page, &pagePorts); err != nil {
+				plugin.Logger(ctx).Error("error extracting ports", "error", err)
+				return false, err
+			}
+			lock.Lock()
+			allPorts = append(allPorts, pagePorts...)
+			lock.Unlock()
+			return true, nil
+		})
+		if err != nil {
+			plugin.Logger(ctx).Error("error listing ports with options", "options", utils.ToPrettyJSON(portOpts), "error", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	plugin.Logger(ctx).Debug("rules and ports retrieved", "rules", len(allRules), "ports", len(allPorts))
+
+	index := newExposureIndex(allPorts, allRules)
+	instanceID := d.EqualsQualString("instance_id")
+	portID := d.EqualsQualString("port_id")
+	for _, port := range allPorts {
+		if (instanceID != "" && port.DeviceID != instanceID) || (portID != "" && port.ID != portID) {
+			continue
+		}
+		for _, exposure := range getPortExposures(port, index) {
+			plugin.Logger(ctx).Debug("streaming security group exposure", "data", utils.ToPrettyJSON(exposure))
+			d.StreamListItem(ctx, exposure)
+			if rowsRemaining(ctx, d) == 0 {
+				plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+				return nil, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+//// UTILITY FUNCTIONS
+
+// exposurePort is a port along with its port security setting.
+type exposurePort struct {
+	ports.Port
+	PortSecurityExt
+}
+
+// PortSecurityExt is like portsecurity.PortSecurityExt, but tells ports with
+// port security disabled from those of clouds without the extension, where
+// the setting is nil; it is exported since gophercloud fills embedded structs
+// separately.
+type PortSecurityExt struct {
+	PortSecurityEnabled *bool `json:"port_security_enabled"`
+}
+
+// securityGroupExposure is some ingress traffic allowed to a port.
+type securityGroupExposure struct {
+	InstanceID          string
+	PortID              string
+	NetworkID           string
+	ProjectID           string
+	Addresses           []string
+	SecurityGroupID     string
+	SecurityGroupRuleID string
+	EtherType           string
+	Protocol            string
+	PortRangeMin        *int
+	PortRangeMax        *int
+	Source              string
+	RemoteGroupID       string
+	OpenToWorld         bool
+}
+
+// anywhere is the source of the traffic allowed from any address, by IP
+// version.
+var anywhere = map[string]string{
+	string(rules.EtherType4): "0.0.0.0/0",
+	string(rules.EtherType6): "::/0",
+}
+
+// worldPrefixLength is the longest prefix, by IP version, of the sources that
+// count as open to the world: they cover at least a sixteenth of the IPv4
+// address space, or all of the global unicast IPv6 one (2000::/3).
+var worldPrefixLength = map[string]int{
+	string(rules.EtherType4): 4,
+	string(rules.EtherType6): 3,
+}
+
+// portProtocols maps the IANA numbers of the protocols with ports, which
+// Neutron accepts in place of their names, to the names.
+var portProtocols = map[string]string{
+	"6":   "tcp",
+	"17":  "udp",
+	"33":  "dccp",
+	"132": "sctp",
+	"136": "udplite",
+}
+
+// exposureIndex holds the ingress rules and the member ports of each security
+// group, so that they need not be looked for among all rules and ports.
+type exposureIndex struct {
+	rules map[string][]rules.SecGroupRule
+	ports map[string][]exposurePort
+}
+
+// newExposureIndex indexes the ingress rules and the ports by security group.
+func newExposureIndex(allPorts []exposurePort, allRules []rules.SecGroupRule) *exposureIndex {
+	index := &exposureIndex{
+		rules: map[string][]rules.SecGroupRule{},
+		ports: map[string][]exposurePort{},
+	}
+	for _, rule := range allRules {
+		if rule.Direction == string(rules.DirIngress) {
+			index.rules[rule.SecGroupID] = append(index.rules[rule.SecGroupID], rule)
+		}
+	}
+	for _, port := range allPorts {
+		for _, groupID := range port.SecurityGroups {
+			index.ports[groupID] = append(index.ports[groupID], port)
+		}
+	}
+	return index
+}
+
+// getPortExposures returns the ingress traffic allowed to the port by the
+// rules of its security groups, one item per rule and source; identical
+// traffic allowed by more than one rule is only reported once.
+func getPortExposures(port exposurePort, index *exposureIndex) []*securityGroupExposure {
+	// security groups do not apply to the ports of routers, DHCP agents etc.
+	if strings.HasPrefix(port.DeviceOwner, "network:") {
+		return nil
+	}
+	newExposure := func(etherType string) *securityGroupExposure {
+		exposure := &securityGroupExposure{
+			PortID:    port.ID,
+			NetworkID: port.NetworkID,
+			ProjectID: port.ProjectID,
+			Addresses: getPortAddresses(port, etherType),
+			EtherType: etherType,
+		}
+		if strings.HasPrefix(port.DeviceOwner, "compute:") {
+			exposure.InstanceID = port.DeviceID
+		}
+		return exposure
+	}
+
+	exposures := []*securityGroupExposure{}
+
+	// without port security all traffic is allowed
+	if port.PortSecurityEnabled != nil && !*port.PortSecurityEnabled {
+		for _, etherType := range []string{string(rules.EtherType4), string(rules.EtherType6)} {
+			exposure := newExposure(etherType)
+			if len(exposure.Addresses) == 0 {
+				continue
+			}
+			exposure.Source = anywhere[etherType]
+			exposure.OpenToWorld = true
+			exposures = append(exposures, exposure)
+		}
+		return exposures
+	}
+
+	seen := map[string]bool{}
+	for _, groupID := range port.SecurityGroups {
+		for _, rule := range index.rules[groupID] {
+			for _, source := range getRuleSources(rule, port, index) {
+				exposure := newExposure(rule.EtherType)
+				if len(exposure.Addresses) == 0 {
+					continue
+				}
+				exposure.SecurityGroupID = rule.SecGroupID
+				exposure.SecurityGroupRuleID = rule.ID
+				exposure.Protocol = rule.Protocol
+				exposure.PortRangeMin, exposure.PortRangeMax = getRulePortRange(rule)
+				exposure.Source = source
+				exposure.RemoteGroupID = rule.RemoteGroupID
+				exposure.OpenToWorld = isOpenToWorld(source)
+
+				key := fmt.Sprintf("%s/%s/%s/%s", exposure.EtherType, exposure.Protocol, formatPortRange(exposure.PortRangeMin, exposure.PortRangeMax), exposure.Source)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				exposures = append(exposures, exposure)
+			}
+		}
+	}
+	return exposures
+}
+
+// getRuleSources returns the networks a rule allows traffic from, resolving
+// remote groups to the addresses of their members (other than the port
+// itself).
+func getRuleSources(rule rules.SecGroupRule, port exposurePort, index *exposureIndex) []string {
+	switch {
+	case rule.RemoteIPPrefix != "":
+		if source := toCIDR(rule.RemoteIPPrefix); source != "" {
+			return []string{source}
+		}
+		return nil
+	case rule.RemoteGroupID != "":
+		sources := []string{}
+		for _, member := range index.ports[rule.RemoteGroupID] {
+			if member.ID == port.ID {
+				continue
+			}
+			addresses := getPortAddresses(member, rule.EtherType)
+			for _, pair := range member.AllowedAddressPairs {
+				if getEtherType(pair.IPAddress) == rule.EtherType {
+					addresses = append(addresses, pair.IPAddress)
+				}
+			}
+			for _, address := range addresses {
+				if source := toCIDR(address); source != "" {
+					sources = append(sources, source)
+				}
+			}
+		}
+		return sources
+	}
+	return []string{anywhere[rule.EtherType]}
+}
+
+// isOpenToWorld returns whether the source, in CIDR notation, is broad enough
+// to count as anywhere.
+func isOpenToWorld(source string) bool {
+	_, network, err := net.ParseCIDR(source)
+	if err != nil {
+		return false
+	}
+	ones, _ := network.Mask.Size()
+	return ones <= worldPrefixLength[getEtherType(source)]
+}
+
+// getRulePortRange returns the range of ports allowed by the rule, or nil for
+// protocols without ports; no range means all ports.
+func getRulePortRange(rule rules.SecGroupRule) (*int, *int) {
+	protocol := strings.ToLower(rule.Protocol)
+	if name, ok := portProtocols[protocol]; ok {
+		protocol = name
+	}
+	switch protocol {
+	case "tcp", "udp", "sctp", "udplite", "dccp":
+		if rule.PortRangeMin == 0 && rule.PortRangeMax == 0 {
+			return utils.PointerTo(1), utils.PointerTo(65535)
+		}
+		return utils.PointerTo(rule.PortRangeMin), utils.PointerTo(rule.PortRangeMax)
+	}
+	return nil, nil
+}
+
+// formatPortRange returns a port range as a string, e.g. "22-22", or "any" for
+// protocols without ports.
+func formatPortRange(min, max *int) string {
+	if min == nil || max == nil {
+		return "any"
+	}
+	return fmt.Sprintf("%d-%d", *min, *max)
+}
+
+// getPortAddresses returns the fixed IPs of the port of the given IP version.
+func getPortAddresses(port exposurePort, etherType string) []string {
+	addresses := []string{}
+	for _, ip := range port.FixedIPs {
+		if getEtherType(ip.IPAddress) == etherType {
+			addresses = append(addresses, ip.IPAddress)
+		}
+	}
+	return addresses
+}
+
+// getEtherType returns the IP version (IPv4 or IPv6) of an address or
+// network, or an empty string if it is invalid.
+func getEtherType(address string) string {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		var err error
+		if ip, _, err = net.ParseCIDR(address); err != nil {
+			return ""
+		}
+	}
+	if ip.To4() != nil {
+		return string(rules.EtherType4)
+	}
+	return string(rules.EtherType6)
+}
+
+// toCIDR returns an address or network in CIDR notation, with addresses as
+// single-host networks, or an empty string if it is invalid.
+func toCIDR(address string) string {
+	if _, network, err := net.ParseCIDR(address); err == nil {
+		return network.String()
+	}
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return ""
+	}
+	if ip.To4() != nil {
+		return ip.String() + "/32"
+	}
+	return ip.String() + "/128"
+}
@@ -0,0 +1,173 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// withExposureFixtures makes the fake Neutron return the ports and ingress
+// rules of a small deployment: a web server open to the world on SSH (twice)
+// and HTTPS, a database server reachable on PostgreSQL from the members of
+// the web group, a router interface and a port without port security; rules
+// are applied in the order of the port's security groups.
+func withExposureFixtures(cloud *fakeOpenStack) {
+	cloud.handle("GET /network/v2.0/ports", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"ports": []any{
+			map[string]any{
+				"id": "port-web", "network_id": "net", "project_id": TestProjectID,
+				"device_owner": "compute:nova", "device_id": "instance-web",
+				"fixed_ips":             []any{map[string]any{"ip_address": "192.168.0.3"}, map[string]any{"ip_address": "fd00::3"}},
+				"security_groups":       []any{"group-web", "group-ssh"},
+				"allowed_address_pairs": []any{map[string]any{"ip_address": "192.168.0.100"}},
+				"port_security_enabled": true,
+			},
+			map[string]any{
+				"id": "port-db", "network_id": "net", "project_id": TestProjectID,
+				"device_owner": "compute:nova", "device_id": "instance-db",
+				"fixed_ips":       []any{map[string]any{"ip_address": "192.168.0.4"}},
+				"security_groups": []any{"group-db"},
+			},
+			map[string]any{
+				"id": "port-router", "network_id": "net", "project_id": TestProjectID,
+				"device_owner": "network:router_interface", "device_id": "router",
+				"fixed_ips":             []any{map[string]any{"ip_address": "192.168.0.1"}},
+				"security_groups":       []any{},
+				"port_security_enabled": false,
+			},
+			map[string]any{
+				"id": "port-open", "network_id": "net", "project_id": TestProjectID,
+				"device_owner": "", "device_id": "",
+				"fixed_ips":             []any{map[string]any{"ip_address": "192.168.0.5"}},
+				"security_groups":       []any{},
+				"port_security_enabled": false,
+			},
+		}})
+	})
+	cloud.handle("GET /network/v2.0/security-group-rules", func(w http.ResponseWriter, r *http.Request) {
+		rule := func(id, group, etherType, protocol string, min, max any, prefix, remote any) map[string]any {
+			return map[string]any{
+				"id": id, "security_group_id": group, "direction": "ingress", "ethertype": etherType,
+				"protocol": protocol, "port_range_min": min, "port_range_max": max,
+				"remote_ip_prefix": prefix, "remote_group_id": remote, "project_id": TestProjectID,
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"security_group_rules": []any{
+			rule("rule-ssh", "group-ssh", "IPv4", "tcp", 22, 22, "0.0.0.0/0", nil),
+			rule("rule-ssh-again", "group-web", "IPv4", "tcp", 22, 22, "0.0.0.0/0", nil),
+			rule("rule-https", "group-web", "IPv6", "tcp", 443, 443, nil, nil),
+			rule("rule-icmp", "group-web", "IPv4", "icmp", nil, nil, "10.0.0.0/8", nil),
+			rule("rule-udp", "group-web", "IPv4", "udp", nil, nil, "10.1.2.3", nil),
+			rule("rule-v6-on-v4", "group-db", "IPv6", "tcp", 5432, 5432, nil, nil),
+			rule("rule-postgres", "group-db", "IPv4", "tcp", 5432, 5432, nil, "group-web"),
+		}})
+	})
+}
+
+func TestListOpenStackSecurityGroupExposure(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	withExposureFixtures(cloud)
+
+	rows, err := cloud.list(tableOpenStackSecurityGroupExposure(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "security_group_rule_id", "rule-ssh-again", "rule-https", "rule-icmp", "rule-udp", "rule-postgres", "rule-postgres", nil)
+	assertRows(t, rows, "instance_id", "instance-web", "instance-web", "instance-web", "instance-web", "instance-db", "instance-db", nil)
+	assertRows(t, rows, "source", "0.0.0.0/0", "::/0", "10.0.0.0/8", "10.1.2.3/32", "192.168.0.3/32", "192.168.0.100/32", "0.0.0.0/0")
+	assertRows(t, rows, "open_to_world", true, true, false, false, false, false, true)
+	assertRows(t, rows, "port_range_min", int64(22), int64(443), nil, int64(1), int64(5432), int64(5432), nil)
+	assertRows(t, rows, "port_range_max", int64(22), int64(443), nil, int64(65535), int64(5432), int64(5432), nil)
+	assertRows(t, rows, "addresses", `["192.168.0.3"]`, `["fd00::3"]`, `["192.168.0.3"]`, `["192.168.0.3"]`, `["192.168.0.4"]`, `["192.168.0.4"]`, `["192.168.0.5"]`)
+	assertRow(t, rows[4], testRow{
+		"port_id":           "port-db",
+		"network_id":        "net",
+		"project_id":        TestProjectID,
+		"project_name":      "admin",
+		"security_group_id": "group-db",
+		"remote_group_id":   "group-web",
+		"ethertype":         "IPv4",
+		"protocol":          "tcp",
+		"region":            "RegionOne",
+	})
+	cloud.assertQuery("/network/v2.0/security-group-rules", url.Values{"direction": {"ingress"}})
+}
+
+func TestListOpenStackSecurityGroupExposureFilters(t *testing.T) {
+	var tests = []struct {
+		quals    map[string]any
+		expected []any
+	}{
+		{quals: map[string]any{"instance_id": "instance-db"}, expected: []any{"port-db", "port-db"}},
+		{quals: map[string]any{"port_id": "port-open"}, expected: []any{"port-open"}},
+		{quals: map[string]any{"port_id": "port-router"}, expected: []any{}},
+	}
+
+	for _, test := range tests {
+		cloud := newFakeOpenStack(t)
+		withExposureFixtures(cloud)
+
+		rows, err := cloud.list(tableOpenStackSecurityGroupExposure(context.Background()), testQuery{quals: test.quals})
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.quals, err)
+			continue
+		}
+		assertRows(t, rows, "port_id", test.expected...)
+	}
+}
+
+func TestListOpenStackSecurityGroupExposureNumericProtocols(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	withExposureFixtures(cloud)
+	cloud.handle("GET /network/v2.0/security-group-rules", func(w http.ResponseWriter, r *http.Request) {
+		rule := func(id, protocol string, min, max any, prefix string) map[string]any {
+			return map[string]any{
+				"id": id, "security_group_id": "group-db", "direction": "ingress", "ethertype": "IPv4",
+				"protocol": protocol, "port_range_min": min, "port_range_max": max,
+				"remote_ip_prefix": prefix, "project_id": TestProjectID,
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"security_group_rules": []any{
+			rule("rule-tcp", "6", 5432, 5432, "0.0.0.0/1"),
+			rule("rule-udp", "17", nil, nil, "10.0.0.0/8"),
+			rule("rule-sctp", "132", 3868, 3868, "128.0.0.0/4"),
+			rule("rule-icmp", "1", nil, nil, "0.0.0.0/8"),
+		}})
+	})
+
+	rows, err := cloud.list(tableOpenStackSecurityGroupExposure(context.Background()), testQuery{quals: map[string]any{"instance_id": "instance-db"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "security_group_rule_id", "rule-tcp", "rule-udp", "rule-sctp", "rule-icmp")
+	assertRows(t, rows, "port_range_min", int64(5432), int64(1), int64(3868), nil)
+	assertRows(t, rows, "port_range_max", int64(5432), int64(65535), int64(3868), nil)
+	assertRows(t, rows, "open_to_world", true, false, true, false)
+}
+
+func TestIsOpenToWorld(t *testing.T) {
+	var tests = []struct {
+		source   string
+		expected bool
+	}{
+		{source: "0.0.0.0/0", expected: true},
+		{source: "0.0.0.0/1", expected: true},
+		{source: "128.0.0.0/4", expected: true},
+		{source: "0.0.0.0/5", expected: false},
+		{source: "10.0.0.0/8", expected: false},
+		{source: "192.168.0.3/32", expected: false},
+		{source: "::/0", expected: true},
+		{source: "::/1", expected: true},
+		{source: "2000::/3", expected: true},
+		{source: "2001:db8::/32", expected: false},
+		{source: "fd00::/8", expected: false},
+		{source: "invalid", expected: false},
+	}
+
+	for _, test := range tests {
+		if actual := isOpenToWorld(test.source); actual != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.source, test.expected, actual)
+		}
+	}
+}
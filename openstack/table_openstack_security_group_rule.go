@@ -4,7 +4,9 @@ import (
 	"context"
 
 	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/rules"
+	"github.com/gophercloud/gophercloud/pagination"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -14,8 +16,9 @@ import (
 
 func tableOpenStackSecurityGroupRule(ctx context.Context) *plugin.Table {
 	return &plugin.Table{
-		Name:        "openstack_security_group_rule",
-		Description: "OpenStack Security Group Rule",
+		Name:              "openstack_security_group_rule",
+		Description:       "OpenStack Security Group Rule",
+		GetMatrixItemFunc: regionMatrix,
 
 		Columns: []*plugin.Column{
 			{
@@ -37,14 +40,21 @@ func tableOpenStackSecurityGroupRule(ctx context.Context) *plugin.Table {
 				Transform:   transform.FromField("TenantID"),
 			},
 			{
-				Name:        "created_at",
+				Name:        "project_name",
 				Type:        proto.ColumnType_STRING,
+				Description: "The name of the project the security group rule belongs to.",
+				Hydrate:     getProjectName,
+				Transform:   transform.FromValue(),
+			},
+			{
+				Name:        "created_at",
+				Type:        proto.ColumnType_TIMESTAMP,
 				Description: "Time at which the security group rule has been created (in UTC ISO8601 format).",
 				Transform:   transform.FromField("CreatedAt").Transform(ToTime),
 			},
 			{
 				Name:        "updated_at",
-				Type:        proto.ColumnType_STRING,
+				Type:        proto.ColumnType_TIMESTAMP,
 				Description: "Time at which the security group rule has been updated (in UTC ISO8601 format).",
 				Transform:   transform.FromField("UpdatedAt").Transform(ToTime),
 			},
@@ -88,7 +98,7 @@ func tableOpenStackSecurityGroupRule(ctx context.Context) *plugin.Table {
 				Name:        "security_group_id",
 				Type:        proto.ColumnType_STRING,
 				Description: "The security group ID to associate with this security group rule.",
-				Transform:   transform.FromField("SecurityGroupID"),
+				Transform:   transform.FromField("SecGroupID"),
 			},
 			{
 				Name:        "remote_ip_prefix",
@@ -102,6 +112,12 @@ func tableOpenStackSecurityGroupRule(ctx context.Context) *plugin.Table {
 				Description: "The revision number of the resource.",
 				Transform:   transform.FromField("Revision"),
 			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the security group rule belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
 		},
 		List: &plugin.ListConfig{
 			Hydrate: listOpenStackSecurityGroupRule,
@@ -154,7 +170,7 @@ func tableOpenStackSecurityGroupRule(ctx context.Context) *plugin.Table {
 		},
 		Get: &plugin.GetConfig{
 			KeyColumns: plugin.SingleColumn("id"),
-			Hydrate:    getOpenStackSecurityGroupRule,
+			Hydrate:    getInScope(getOpenStackSecurityGroupRule),
 		},
 	}
 }
@@ -167,33 +183,45 @@ func listOpenStackSecurityGroupRule(ctx context.Context, d *plugin.QueryData, h
 
 	plugin.Logger(ctx).Debug("list security groups rules", "query data", utils.ToPrettyJSON(d), "hydrate data", utils.ToPrettyJSON(h))
 
-	client, err := getServiceClient(ctx, d, NetworkV2)
+	scope, err := getProjectScope(ctx, d)
 	if err != nil {
-		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
 		return nil, err
 	}
 
 	opts := buildOpenStackSecurityGroupRuleFilter(ctx, d.EqualsQuals)
+	opts.Limit = getPageSize(d)
 
-	allPages, err := rules.List(client, opts).AllPages()
-	if err != nil {
-		plugin.Logger(ctx).Error("error listing security group rules with options", "options", utils.ToPrettyJSON(opts), "error", err)
-		return nil, err
-	}
-	allRules, err := rules.ExtractRules(allPages)
-	if err != nil {
-		plugin.Logger(ctx).Error("error extracting rules", "error", err)
-		return nil, err
-	}
-	plugin.Logger(ctx).Debug("rules retrieved", "count", len(allRules))
+	err = forEachProject(ctx, d, scope, NetworkV2, func(client *gophercloud.ServiceClient, projectID string) error {
+		opts := opts
+		opts.ProjectID = projectID
 
-	for _, rule := range allRules {
-		if ctx.Err() != nil {
-			plugin.Logger(ctx).Debug("context done, exit")
-			break
+		err := rules.List(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+			allRules, err := rules.ExtractRules(page)
+			if err != nil {
+				plugin.Logger(ctx).Error("error extracting rules", "error", err)
+				return false, err
+			}
+			plugin.Logger(ctx).Debug("rules retrieved", "count", len(allRules))
+
+			for _, rule := range allRules {
+				rule := rule
+				d.StreamListItem(ctx, &rule)
+				if rowsRemaining(ctx, d) == 0 {
+					plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+					return false, nil
+				}
+			}
+			return true, nil
+		})
+		if err != nil {
+			plugin.Logger(ctx).Error("error listing security group rules with options", "options", utils.ToPrettyJSON(opts), "error", err)
+			return err
 		}
-		rule := rule
-		d.StreamListItem(ctx, &rule)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return nil, nil
 }
@@ -213,6 +241,12 @@ func getOpenStackSecurityGroupRule(ctx context.Context, d *plugin.QueryData, h *
 		return nil, err
 	}
 
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+
 	result := rules.Get(client, id)
 	rule, err := result.Extract()
 	if err != nil {
@@ -220,6 +254,11 @@ func getOpenStackSecurityGroupRule(ctx context.Context, d *plugin.QueryData, h *
 		return nil, err
 	}
 
+	if !scope.Includes(rule.ProjectID) {
+		plugin.Logger(ctx).Debug("security group rule not in project scope", "id", id, "project", rule.ProjectID)
+		return nil, nil
+	}
+
 	return rule, nil
 }
 
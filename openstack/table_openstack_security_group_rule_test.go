@@ -0,0 +1,114 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+// the legacy security_group_rule table is the same as openstack_security_group_rule
+var securityGroupRuleTables = []func(context.Context) *plugin.Table{
+	tableOpenStackSecurityGroupRule,
+	func(ctx context.Context) *plugin.Table {
+		return deprecatedTable("security_group_rule", deprecatedTables["security_group_rule"], tableOpenStackSecurityGroupRule(ctx))
+	},
+}
+
+func TestListOpenStackSecurityGroupRule(t *testing.T) {
+	for _, newTable := range securityGroupRuleTables {
+		cloud := newFakeOpenStack(t)
+
+		rows, err := cloud.list(newTable(context.Background()), testQuery{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertRows(t, rows, "id", "3c0e45ff-adaf-4124-b083-bf390e5482ff", "93aa42e5-80db-4581-9391-3a608bd0e448")
+		assertRow(t, rows[0], testRow{
+			"description":       "ssh from anywhere",
+			"project_id":        TestProjectID,
+			"direction":         "ingress",
+			"protocol":          "tcp",
+			"ethertype":         "IPv4",
+			"port_range_min":    int64(22),
+			"port_range_max":    int64(22),
+			"remote_ip_prefix":  "0.0.0.0/0",
+			"security_group_id": "85cc3048-abc3-43cc-89b3-377341426ac5",
+			"region":            "RegionOne",
+		})
+		assertRow(t, rows[1], testRow{
+			"direction":       "egress",
+			"protocol":        "",
+			"remote_group_id": "85cc3048-abc3-43cc-89b3-377341426ac5",
+		})
+	}
+}
+
+func TestListOpenStackSecurityGroupRuleFilters(t *testing.T) {
+	for _, newTable := range securityGroupRuleTables {
+		cloud := newFakeOpenStack(t)
+
+		_, err := cloud.list(newTable(context.Background()), testQuery{
+			quals: map[string]any{
+				"id":                "3c0e45ff-adaf-4124-b083-bf390e5482ff",
+				"description":       "ssh from anywhere",
+				"remote_group_id":   "85cc3048-abc3-43cc-89b3-377341426ac5",
+				"direction":         "ingress",
+				"protocol":          "tcp",
+				"ethertype":         "IPv4",
+				"port_range_min":    22,
+				"port_range_max":    22,
+				"security_group_id": "85cc3048-abc3-43cc-89b3-377341426ac5",
+				"project_id":        TestProjectID,
+				"remote_ip_prefix":  "0.0.0.0/0",
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cloud.assertQuery("/network/v2.0/security-group-rules", url.Values{
+			"id":                {"3c0e45ff-adaf-4124-b083-bf390e5482ff"},
+			"description":       {"ssh from anywhere"},
+			"remote_group_id":   {"85cc3048-abc3-43cc-89b3-377341426ac5"},
+			"direction":         {"ingress"},
+			"protocol":          {"tcp"},
+			"ethertype":         {"IPv4"},
+			"port_range_min":    {"22"},
+			"port_range_max":    {"22"},
+			"security_group_id": {"85cc3048-abc3-43cc-89b3-377341426ac5"},
+			"project_id":        {TestProjectID},
+			"remote_ip_prefix":  {"0.0.0.0/0"},
+		})
+	}
+}
+
+func TestListOpenStackSecurityGroupRuleError(t *testing.T) {
+	for _, newTable := range securityGroupRuleTables {
+		cloud := newFakeOpenStack(t)
+		cloud.fail("GET /network/v2.0/security-group-rules", http.StatusInternalServerError)
+
+		if _, err := cloud.list(newTable(context.Background()), testQuery{}); err == nil {
+			t.Error("expected error")
+		}
+	}
+}
+
+func TestGetOpenStackSecurityGroupRule(t *testing.T) {
+	for _, newTable := range securityGroupRuleTables {
+		cloud := newFakeOpenStack(t)
+		table := newTable(context.Background())
+
+		row, err := cloud.get(table, testQuery{quals: map[string]any{"id": "3c0e45ff-adaf-4124-b083-bf390e5482ff"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertRow(t, row, testRow{"direction": "ingress"})
+
+		row, err = cloud.get(table, testQuery{quals: map[string]any{"id": "00000000-0000-0000-0000-000000000000"}})
+		if err != nil || row != nil {
+			t.Errorf("%s: expected no row and no error, got %v (error: %v)", table.Name, row, err)
+		}
+	}
+}
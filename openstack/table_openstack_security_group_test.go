@@ -0,0 +1,93 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestListOpenStackSecurityGroup(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackSecurityGroup(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "default", "empty")
+	assertRow(t, rows[0], testRow{
+		"id":                      "85cc3048-abc3-43cc-89b3-377341426ac5",
+		"description":             "Default security group",
+		"project_id":              TestProjectID,
+		"created_at":              "2022-09-20T09:00:00Z",
+		"updated_at":              "2022-09-20T09:10:00Z",
+		"tags":                    `["baseline"]`,
+		"security_group_rule_ids": `["3c0e45ff-adaf-4124-b083-bf390e5482ff","93aa42e5-80db-4581-9391-3a608bd0e448"]`,
+		"region":                  "RegionOne",
+	})
+	assertRow(t, rows[1], testRow{"security_group_rule_ids": `null`})
+}
+
+func TestListOpenStackSecurityGroupFilters(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	_, err := cloud.list(tableOpenStackSecurityGroup(context.Background()), testQuery{
+		quals: map[string]any{
+			"id":          "85cc3048-abc3-43cc-89b3-377341426ac5",
+			"name":        "default",
+			"description": "Default security group",
+			"project_id":  TestProjectID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloud.assertQuery("/network/v2.0/security-groups", url.Values{
+		"id":          {"85cc3048-abc3-43cc-89b3-377341426ac5"},
+		"name":        {"default"},
+		"description": {"Default security group"},
+		"project_id":  {TestProjectID},
+	})
+}
+
+func TestListOpenStackSecurityGroupError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /network/v2.0/security-groups", http.StatusInternalServerError)
+
+	if _, err := cloud.list(tableOpenStackSecurityGroup(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGetOpenStackSecurityGroup(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	table := tableOpenStackSecurityGroup(context.Background())
+
+	row, err := cloud.get(table, testQuery{quals: map[string]any{"id": "85cc3048-abc3-43cc-89b3-377341426ac5"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, row, testRow{"name": "default"})
+
+	row, err = cloud.get(table, testQuery{quals: map[string]any{"id": "00000000-0000-0000-0000-000000000000"}})
+	if err != nil || row != nil {
+		t.Errorf("expected no row and no error, got %v (error: %v)", row, err)
+	}
+}
+
+func TestListOpenStackSecurityGroupUpdatedAt(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	_, err := cloud.list(tableOpenStackSecurityGroup(context.Background()), testQuery{
+		quals: map[string]any{
+			"updated_at >": time.Date(2022, 10, 1, 8, 30, 0, 0, time.UTC),
+			"updated_at <": time.Date(2022, 10, 8, 0, 0, 0, 0, time.UTC),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only the lower end can be pushed down
+	cloud.assertQuery("/network/v2.0/security-groups", url.Values{"changed_since": {"2022-10-01T08:30:00Z"}})
+}
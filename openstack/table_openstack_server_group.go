@@ -0,0 +1,328 @@
+package openstack
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/servergroups"
+	"github.com/gophercloud/gophercloud/pagination"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableOpenStackServerGroup(_ context.Context) *plugin.Table {
+	return &plugin.Table{
+		Name:              "openstack_server_group",
+		Description:       "OpenStack Server Group",
+		GetMatrixItemFunc: regionMatrix,
+		Columns: []*plugin.Column{
+			{
+				Name:        "id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The unique ID of the server group.",
+				Transform:   transform.FromField("ID"),
+			},
+			{
+				Name:        "name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the server group.",
+				Transform:   transform.FromField("Name"),
+			},
+			{
+				Name:        "policy",
+				Type:        proto.ColumnType_STRING,
+				Description: "The placement policy of the server group: affinity, anti-affinity, soft-affinity or soft-anti-affinity.",
+				Transform:   transform.FromMethod("GetPolicy").Transform(transform.NullIfZeroValue),
+			},
+			{
+				Name:        "policies",
+				Type:        proto.ColumnType_JSON,
+				Description: "The placement policies of the server group, as returned before microversion 2.64.",
+				Transform:   transform.FromField("Policies"),
+			},
+			{
+				Name:        "max_server_per_host",
+				Type:        proto.ColumnType_INT,
+				Description: "The maximum number of members on the same host, for anti-affinity server groups (since microversion 2.64).",
+				Transform:   transform.FromField("Rules.MaxServerPerHost").Transform(transform.NullIfZeroValue),
+			},
+			{
+				Name:        "members",
+				Type:        proto.ColumnType_JSON,
+				Description: "The IDs of the instances in the server group.",
+				Transform:   transform.FromField("Members"),
+			},
+			{
+				Name:        "member_hosts",
+				Type:        proto.ColumnType_JSON,
+				Description: "The host IDs of the members of the server group, by instance ID; members not scheduled to a host are left out.",
+				Hydrate:     getOpenStackServerGroupPlacement,
+				Transform:   transform.FromField("MemberHosts"),
+			},
+			{
+				Name:        "anti_affinity_violated",
+				Type:        proto.ColumnType_BOOL,
+				Description: "Whether more members of an anti-affinity or soft-anti-affinity server group share a host than allowed (one, unless max_server_per_host says otherwise); null for other policies.",
+				Hydrate:     getOpenStackServerGroupPlacement,
+				Transform:   transform.FromField("AntiAffinityViolated"),
+			},
+			{
+				Name:        "project_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the project the server group belongs to.",
+				Transform:   transform.FromField("ProjectID"),
+			},
+			{
+				Name:        "project_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the project the server group belongs to.",
+				Hydrate:     getProjectName,
+				Transform:   transform.FromValue(),
+			},
+			{
+				Name:        "user_id",
+				Type:        proto.ColumnType_STRING,
+				Description: "The ID of the user who created the server group.",
+				Transform:   transform.FromField("UserID"),
+			},
+			{
+				Name:        "user_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the user who created the server group.",
+				Hydrate:     getUserName,
+				Transform:   transform.FromValue(),
+			},
+			{
+				Name:        "metadata",
+				Type:        proto.ColumnType_JSON,
+				Description: "The metadata of the server group.",
+				Transform:   transform.FromField("Metadata"),
+			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the server group belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
+		},
+		List: &plugin.ListConfig{
+			Hydrate: listOpenStackServerGroup,
+			KeyColumns: plugin.KeyColumnSlice{
+				&plugin.KeyColumn{
+					Name:    "project_id",
+					Require: plugin.Optional,
+				},
+			},
+		},
+		Get: &plugin.GetConfig{
+			KeyColumns: plugin.SingleColumn("id"),
+			Hydrate:    getInScope(getOpenStackServerGroup),
+		},
+	}
+}
+
+//// LIST FUNCTION
+
+func listOpenStackServerGroup(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	plugin.Logger(ctx).Debug("retrieving openstack server group list", "query data", utils.ToPrettyJSON(d))
+
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+
+	// Nova cannot filter server groups by project, so they are listed once
+	// for all projects (or, when re-scoping, once with each project's token)
+	// and filtered here
+	projectID := d.EqualsQualString("project_id")
+	list := func(client *gophercloud.ServiceClient) error {
+		opts := servergroups.ListOpts{
+			AllProjects: scope.AllTenants,
+		}
+		err := servergroups.List(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+			allGroups := []*apiServerGroup{}
+			if err := (page.(servergroups.ServerGroupPage)).ExtractIntoSlicePtr(&allGroups, "server_groups"); err != nil {
+				plugin.Logger(ctx).Error("error extracting server groups", "error", err)
+				return false, err
+			}
+			plugin.Logger(ctx).Debug("server groups retrieved", "count", len(allGroups))
+
+			for _, group := range allGroups {
+				if !scope.Includes(group.ProjectID) || (projectID != "" && group.ProjectID != projectID) {
+					continue
+				}
+				d.StreamListItem(ctx, group)
+				if rowsRemaining(ctx, d) == 0 {
+					plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+					return false, nil
+				}
+			}
+			return true, nil
+		})
+		if err != nil {
+			plugin.Logger(ctx).Error("error listing server groups with options", "options", utils.ToPrettyJSON(opts), "error", err)
+			return err
+		}
+		return nil
+	}
+
+	if scope.Rescoped {
+		err = forEachProject(ctx, d, scope, ComputeV2, func(client *gophercloud.ServiceClient, projectID string) error {
+			return list(client)
+		})
+	} else {
+		var client *gophercloud.ServiceClient
+		if client, err = getServiceClient(ctx, d, ComputeV2); err != nil {
+			plugin.Logger(ctx).Error("error retrieving client", "error", err)
+			return nil, err
+		}
+		err = list(client)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+//// HYDRATE FUNCTIONS
+
+func getOpenStackServerGroup(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	id := d.EqualsQuals["id"].GetStringValue()
+	plugin.Logger(ctx).Debug("retrieving openstack server group", "id", id)
+
+	client, err := getServiceClient(ctx, d, ComputeV2)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		return nil, err
+	}
+
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+
+	group := &apiServerGroup{}
+	if err := servergroups.Get(client, id).ExtractIntoStructPtr(group, "server_group"); err != nil {
+		plugin.Logger(ctx).Error("error retrieving server group", "error", err)
+		return nil, err
+	}
+	if !scope.Includes(group.ProjectID) {
+		plugin.Logger(ctx).Debug("server group not in project scope", "id", id, "project", group.ProjectID)
+		return nil, nil
+	}
+	return group, nil
+}
+
+// getOpenStackServerGroupPlacement retrieves the host ID of each member of the
+// server group and checks them against its policy; host IDs are hashes of the
+// host name and the project, so they can be compared among the members, which
+// all belong to the group's project. Members that no longer exist are left
+// out.
+func getOpenStackServerGroupPlacement(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+
+	setLogLevel(ctx, d)
+
+	group := h.Item.(*apiServerGroup)
+	plugin.Logger(ctx).Debug("retrieving openstack server group placement", "id", group.ID, "members", len(group.Members))
+
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+	openstackConfig, err := getConnectionConfig(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving connection configuration", "error", err)
+		return nil, err
+	}
+
+	var lock sync.Mutex
+	hosts := map[string]string{}
+	err = forEachConcurrently(ctx, getMaxConcurrency(openstackConfig), group.Members, func(id string) error {
+		instance, err := getOpenStackInstanceByID(scope.Context(ctx, group.ProjectID), d, id)
+		if errors.As(err, &gophercloud.ErrDefault404{}) {
+			plugin.Logger(ctx).Debug("server group member not found", "id", group.ID, "member", id)
+			return nil
+		}
+		if err != nil {
+			plugin.Logger(ctx).Error("error retrieving server group member", "id", group.ID, "member", id, "error", err)
+			return err
+		}
+		if instance == nil || instance.(*apiInstance).HostID == "" {
+			return nil
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		hosts[id] = instance.(*apiInstance).HostID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &serverGroupPlacement{
+		MemberHosts:          hosts,
+		AntiAffinityViolated: getAntiAffinityViolation(group, hosts),
+	}, nil
+}
+
+//// UTILITY FUNCTIONS
+
+// apiServerGroup is a server group as returned by any microversion.
+type apiServerGroup struct {
+	servergroups.ServerGroup
+}
+
+// GetPolicy returns the policy of the server group, which before microversion
+// 2.64 is the only entry in its list of policies.
+func (g *apiServerGroup) GetPolicy() string {
+	if g.Policy != nil {
+		return *g.Policy
+	}
+	if len(g.Policies) > 0 {
+		return g.Policies[0]
+	}
+	return ""
+}
+
+// serverGroupPlacement is where the members of a server group are placed.
+type serverGroupPlacement struct {
+	MemberHosts          map[string]string
+	AntiAffinityViolated *bool
+}
+
+// getAntiAffinityViolation returns whether more members of the anti-affinity
+// server group share a host than its rules allow, or nil if the server group
+// has another policy.
+func getAntiAffinityViolation(group *apiServerGroup, hosts map[string]string) *bool {
+	switch group.GetPolicy() {
+	case "anti-affinity", "soft-anti-affinity":
+	default:
+		return nil
+	}
+	limit := 1
+	if group.Rules != nil && group.Rules.MaxServerPerHost > 0 {
+		limit = group.Rules.MaxServerPerHost
+	}
+	counts := map[string]int{}
+	for _, host := range hosts {
+		counts[host]++
+		if counts[host] > limit {
+			return utils.PointerTo(true)
+		}
+	}
+	return utils.PointerTo(false)
+}
@@ -0,0 +1,118 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/servergroups"
+)
+
+// withServerGroupMembers makes the fake Nova return the members of the server
+// groups in the fixtures that are not in the instance fixtures: web-02, on the
+// same host as web-01, and db-01; the other member of db-ha no longer exists.
+func withServerGroupMembers(cloud *fakeOpenStack) {
+	member := func(id, projectID, hostID string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, map[string]any{"server": map[string]any{"id": id, "tenant_id": projectID, "hostId": hostID}})
+		}
+	}
+	cloud.handle("GET /compute/v2.1/servers/e2d3c4b5-a6f7-4809-9a1b-2c3d4e5f6a7b", member("e2d3c4b5-a6f7-4809-9a1b-2c3d4e5f6a7b", TestProjectID, "2091634baaccdc4c5a1d57069c833e402921df696b7f970791b12ec6"))
+	cloud.handle("GET /compute/v2.1/servers/c5c7f1b4-4c8e-4e5b-9d8e-2b1d3c4e5f60", member("c5c7f1b4-4c8e-4e5b-9d8e-2b1d3c4e5f60", OtherProjectID, "8d1e3c5b7a9f0e2d4c6b8a0f1e3d5c7b9a0f2e4d6c8b0a1f3e5d7c9b"))
+}
+
+func TestListOpenStackServerGroup(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	withServerGroupMembers(cloud)
+
+	rows, err := cloud.list(tableOpenStackServerGroup(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "web-ha", "web-spread", "db-ha", "batch")
+	assertRows(t, rows, "policy", "anti-affinity", "anti-affinity", "soft-anti-affinity", "affinity")
+	assertRows(t, rows, "max_server_per_host", nil, int64(2), nil, nil)
+	assertRows(t, rows, "anti_affinity_violated", true, false, false, nil)
+	assertRows(t, rows, "project_name", "admin", "admin", "databases", "databases")
+	assertRow(t, rows[2], testRow{
+		"id":           "5b6c7d8e-9f0a-4b1c-8d2e-3f4a5b6c7d8e",
+		"policies":     `["soft-anti-affinity"]`,
+		"members":      `["c5c7f1b4-4c8e-4e5b-9d8e-2b1d3c4e5f60","6c7d8e9f-0a1b-4c2d-9e3f-4a5b6c7d8e9f"]`,
+		"member_hosts": `{"c5c7f1b4-4c8e-4e5b-9d8e-2b1d3c4e5f60":"8d1e3c5b7a9f0e2d4c6b8a0f1e3d5c7b9a0f2e4d6c8b0a1f3e5d7c9b"}`,
+		"metadata":     `{"tier":"db"}`,
+		"user_id":      TestUserID,
+		"user_name":    "admin",
+		"region":       "RegionOne",
+	})
+	cloud.assertQuery("/compute/v2.1/os-server-groups", url.Values{"all_projects": {"true"}})
+}
+
+func TestListOpenStackServerGroupFilters(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	withServerGroupMembers(cloud)
+
+	// Nova cannot filter server groups by project
+	rows, err := cloud.list(tableOpenStackServerGroup(context.Background()), testQuery{quals: map[string]any{"project_id": OtherProjectID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "db-ha", "batch")
+	cloud.assertQuery("/compute/v2.1/os-server-groups", url.Values{"project_id": nil})
+}
+
+func TestListOpenStackServerGroupError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /compute/v2.1/os-server-groups", http.StatusInternalServerError)
+
+	if _, err := cloud.list(tableOpenStackServerGroup(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGetOpenStackServerGroup(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	withServerGroupMembers(cloud)
+	table := tableOpenStackServerGroup(context.Background())
+
+	row, err := cloud.get(table, testQuery{quals: map[string]any{"id": "3f2a1b4c-5d6e-4f70-8a9b-0c1d2e3f4a5b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, row, testRow{"name": "web-ha", "anti_affinity_violated": true})
+
+	row, err = cloud.get(table, testQuery{quals: map[string]any{"id": "00000000-0000-0000-0000-000000000000"}})
+	if err != nil || row != nil {
+		t.Errorf("expected no row and no error, got %v (error: %v)", row, err)
+	}
+}
+
+func TestGetAntiAffinityViolation(t *testing.T) {
+	policy := func(policy string, max int) *apiServerGroup {
+		group := &apiServerGroup{}
+		group.Policy = &policy
+		if max > 0 {
+			group.Rules = &servergroups.Rules{MaxServerPerHost: max}
+		}
+		return group
+	}
+	var tests = []struct {
+		group    *apiServerGroup
+		hosts    map[string]string
+		expected any
+	}{
+		{group: policy("anti-affinity", 0), hosts: map[string]string{"a": "h1", "b": "h2"}, expected: false},
+		{group: policy("anti-affinity", 0), hosts: map[string]string{"a": "h1", "b": "h1"}, expected: true},
+		{group: policy("anti-affinity", 3), hosts: map[string]string{"a": "h1", "b": "h1", "c": "h1"}, expected: false},
+		{group: policy("soft-anti-affinity", 0), hosts: map[string]string{"a": "h1", "b": "h1"}, expected: true},
+		{group: policy("affinity", 0), hosts: map[string]string{"a": "h1", "b": "h2"}, expected: nil},
+		{group: policy("anti-affinity", 0), hosts: map[string]string{}, expected: false},
+	}
+
+	for _, test := range tests {
+		violated := getAntiAffinityViolation(test.group, test.hosts)
+		if (violated == nil && test.expected != nil) || (violated != nil && *violated != test.expected) {
+			t.Errorf("%s %v: expected %v, got %v", test.group.GetPolicy(), test.hosts, test.expected, violated)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/dihedron/steampipe-plugin-utils/utils"
 	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/subnets"
+	"github.com/gophercloud/gophercloud/pagination"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -15,9 +16,10 @@ import (
 
 func tableOpenStackSubnet(_ context.Context) *plugin.Table {
 	return &plugin.Table{
-		Name:        "openstack_subnet",
-		Description: "OpenStack Subnet",
-		Columns: []*plugin.Column{
+		Name:              "openstack_subnet",
+		Description:       "OpenStack Subnet",
+		GetMatrixItemFunc: regionMatrix,
+		Columns: append([]*plugin.Column{
 			{
 				Name:        "id",
 				Type:        proto.ColumnType_STRING,
@@ -42,6 +44,13 @@ func tableOpenStackSubnet(_ context.Context) *plugin.Table {
 				Description: "The network the subnet belongs to",
 				Transform:   transform.FromField("NetworkID"),
 			},
+			{
+				Name:        "network_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the network the subnet belongs to.",
+				Hydrate:     getNetworkName,
+				Transform:   transform.FromValue(),
+			},
 			{
 				Name:        "cidr",
 				Type:        proto.ColumnType_STRING,
@@ -54,6 +63,13 @@ func tableOpenStackSubnet(_ context.Context) *plugin.Table {
 				Description: "The project id the subnet belongs to",
 				Transform:   transform.FromField("ProjectID"),
 			},
+			{
+				Name:        "project_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the project the subnet belongs to.",
+				Hydrate:     getProjectName,
+				Transform:   transform.FromValue(),
+			},
 			{
 				Name:        "dhcp",
 				Type:        proto.ColumnType_BOOL,
@@ -78,10 +94,22 @@ func tableOpenStackSubnet(_ context.Context) *plugin.Table {
 				Description: "Routes that should be used by devices with IPs from this subnet",
 				Transform:   transform.FromField("HostRoutes"),
 			},
-		},
+			{
+				Name:        "tags",
+				Type:        proto.ColumnType_JSON,
+				Description: "Tags is a list of subnet tags. Tags are arbitrarily defined strings attached to a subnet.",
+				Transform:   transform.FromField("Tags"),
+			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the subnet belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
+		}, tagColumns()...),
 		List: &plugin.ListConfig{
 			Hydrate: listOpenStackSubnet,
-			KeyColumns: plugin.KeyColumnSlice{
+			KeyColumns: append(plugin.KeyColumnSlice{
 				&plugin.KeyColumn{
 					Name:    "id",
 					Require: plugin.Optional,
@@ -98,11 +126,11 @@ func tableOpenStackSubnet(_ context.Context) *plugin.Table {
 					Name:    "project_id",
 					Require: plugin.Optional,
 				},
-			},
+			}, tagKeyColumns()...),
 		},
 		Get: &plugin.GetConfig{
 			KeyColumns: plugin.SingleColumn("id"),
-			Hydrate:    getOpenStackSubnet,
+			Hydrate:    getInScope(getOpenStackSubnet),
 		},
 	}
 }
@@ -115,35 +143,47 @@ func listOpenStackSubnet(ctx context.Context, d *plugin.QueryData, h *plugin.Hyd
 
 	plugin.Logger(ctx).Debug("retrieving openstack subnet list", "query data", utils.ToPrettyJSON(d))
 
-	client, err := getServiceClient(ctx, d, NetworkV2)
+	scope, err := getProjectScope(ctx, d)
 	if err != nil {
-		plugin.Logger(ctx).Error("error retrieving client", "error", err)
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
 		return nil, err
 	}
 
 	opts := buildOpenStackSubnetFilter(ctx, d.EqualsQuals)
+	opts.Limit = getPageSize(d)
 
-	allPages, err := subnets.List(client, opts).AllPages()
-	if err != nil {
-		plugin.Logger(ctx).Error("error listing subnets with options", "options", utils.ToPrettyJSON(opts), "error", err)
-		return nil, err
-	}
-	allSubnets, err := subnets.ExtractSubnets(allPages)
-	plugin.Logger(ctx).Debug("all subnet", "all_subnet", utils.ToPrettyJSON(allSubnets))
-	if err != nil {
-		plugin.Logger(ctx).Error("error extracting subnets", "error", err)
-		return nil, err
-	}
-	plugin.Logger(ctx).Debug("subnets retrieved", "count", len(allSubnets))
+	err = forEachProject(ctx, d, scope, NetworkV2, func(client *gophercloud.ServiceClient, projectID string) error {
+		opts := opts
+		opts.ProjectID = projectID
 
-	for _, subnet := range allSubnets {
-		if ctx.Err() != nil {
-			plugin.Logger(ctx).Debug("context done, exit")
-			break
+		err := subnets.List(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+			allSubnets, err := subnets.ExtractSubnets(page)
+			plugin.Logger(ctx).Debug("all subnet", "all_subnet", utils.ToPrettyJSON(allSubnets))
+			if err != nil {
+				plugin.Logger(ctx).Error("error extracting subnets", "error", err)
+				return false, err
+			}
+			plugin.Logger(ctx).Debug("subnets retrieved", "count", len(allSubnets))
+
+			for _, subnet := range allSubnets {
+				subnet := subnet
+				plugin.Logger(ctx).Debug("subnet", "subnet", utils.ToPrettyJSON(subnet))
+				d.StreamListItem(ctx, &subnet)
+				if rowsRemaining(ctx, d) == 0 {
+					plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+					return false, nil
+				}
+			}
+			return true, nil
+		})
+		if err != nil {
+			plugin.Logger(ctx).Error("error listing subnets with options", "options", utils.ToPrettyJSON(opts), "error", err)
+			return err
 		}
-		subnet := subnet
-		plugin.Logger(ctx).Debug("subnet", "subnet", utils.ToPrettyJSON(subnet))
-		d.StreamListItem(ctx, &subnet)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return nil, nil
 }
@@ -163,6 +203,12 @@ func getOpenStackSubnet(ctx context.Context, d *plugin.QueryData, h *plugin.Hydr
 		return nil, err
 	}
 
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+
 	result := subnets.Get(client, id)
 	var subnet *subnets.Subnet
 	subnet, err = result.Extract()
@@ -178,6 +224,11 @@ func getOpenStackSubnet(ctx context.Context, d *plugin.QueryData, h *plugin.Hydr
 		return nil, err
 	}
 
+	if !scope.Includes(subnet.ProjectID) {
+		plugin.Logger(ctx).Debug("subnet not in project scope", "id", id, "project", subnet.ProjectID)
+		return nil, nil
+	}
+
 	return subnet, nil
 }
 
@@ -198,6 +249,11 @@ func buildOpenStackSubnetFilter(ctx context.Context, quals plugin.KeyColumnEqual
 		opts.Description = value.GetStringValue()
 	}
 
+	tags := getTagFilter(quals)
+	opts.Tags = tags.Tags
+	opts.TagsAny = tags.TagsAny
+	opts.NotTags = tags.NotTags
+	opts.NotTagsAny = tags.NotTagsAny
 	plugin.Logger(ctx).Debug("returning", "filter", utils.ToPrettyJSON(opts))
 	return opts
 }
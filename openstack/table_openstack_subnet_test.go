@@ -0,0 +1,78 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestListOpenStackSubnet(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackSubnet(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "private-subnet", "ipv6-subnet")
+	assertRow(t, rows[0], testRow{
+		"id":              "a0304c3a-4f08-4c43-88af-d796509c97d2",
+		"network":         "396f12f8-521e-4b91-8e21-2e003500433a",
+		"network_name":    "private",
+		"cidr":            "192.168.0.0/24",
+		"project_id":      TestProjectID,
+		"dhcp":            true,
+		"gateway":         "192.168.0.1",
+		"dns_nameservers": `["8.8.8.8","8.8.4.4"]`,
+		"host_routes":     `[{"destination":"10.0.0.0/8","nexthop":"192.168.0.254"}]`,
+		"region":          "RegionOne",
+	})
+	assertRow(t, rows[1], testRow{"cidr": "fd00::/64", "dhcp": false})
+}
+
+func TestListOpenStackSubnetFilters(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	_, err := cloud.list(tableOpenStackSubnet(context.Background()), testQuery{
+		quals: map[string]any{
+			"id":          "a0304c3a-4f08-4c43-88af-d796509c97d2",
+			"name":        "private-subnet",
+			"description": "internal",
+			"project_id":  TestProjectID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloud.assertQuery("/network/v2.0/subnets", url.Values{
+		"id":          {"a0304c3a-4f08-4c43-88af-d796509c97d2"},
+		"name":        {"private-subnet"},
+		"description": {"internal"},
+		"project_id":  {TestProjectID},
+	})
+}
+
+func TestListOpenStackSubnetError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /network/v2.0/subnets", http.StatusInternalServerError)
+
+	if _, err := cloud.list(tableOpenStackSubnet(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGetOpenStackSubnet(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	table := tableOpenStackSubnet(context.Background())
+
+	row, err := cloud.get(table, testQuery{quals: map[string]any{"id": "a0304c3a-4f08-4c43-88af-d796509c97d2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, row, testRow{"name": "private-subnet"})
+
+	row, err = cloud.get(table, testQuery{quals: map[string]any{"id": "00000000-0000-0000-0000-000000000000"}})
+	if err != nil || row != nil {
+		t.Errorf("expected no row and no error, got %v (error: %v)", row, err)
+	}
+}
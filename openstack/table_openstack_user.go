@@ -2,9 +2,11 @@ package openstack
 
 import (
 	"context"
+	"time"
 
 	"github.com/dihedron/steampipe-plugin-utils/utils"
 	"github.com/gophercloud/gophercloud/openstack/identity/v3/users"
+	"github.com/gophercloud/gophercloud/pagination"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -47,6 +49,13 @@ func tableOpenStackUser(_ context.Context) *plugin.Table {
 				Description: "The ID of the domain the user belongs to.",
 				Transform:   transform.FromField("DomainID"),
 			},
+			{
+				Name:        "domain_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the domain the user belongs to.",
+				Hydrate:     getDomainName,
+				Transform:   transform.FromValue(),
+			},
 			{
 				Name:        "enabled",
 				Type:        proto.ColumnType_BOOL,
@@ -55,7 +64,7 @@ func tableOpenStackUser(_ context.Context) *plugin.Table {
 			},
 			{
 				Name:        "password_expires_at",
-				Type:        proto.ColumnType_STRING,
+				Type:        proto.ColumnType_TIMESTAMP,
 				Description: "The timestamp when the user's password expires.",
 				Transform:   transform.FromField("PasswordExpiresAt").Transform(ToTime),
 			},
@@ -109,26 +118,28 @@ func listOpenStackUser(ctx context.Context, d *plugin.QueryData, h *plugin.Hydra
 
 	opts := buildOpenStackUserFilter(ctx, d.EqualsQuals)
 
-	allPages, err := users.List(client, opts).AllPages()
+	err = users.List(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+		allUsers, err := users.ExtractUsers(page)
+		if err != nil {
+			plugin.Logger(ctx).Error("error extracting users", "error", err)
+			return false, err
+		}
+		plugin.Logger(ctx).Debug("users retrieved", "count", len(allUsers))
+
+		for _, user := range allUsers {
+			user := user
+			d.StreamListItem(ctx, &user)
+			if rowsRemaining(ctx, d) == 0 {
+				plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+				return false, nil
+			}
+		}
+		return true, nil
+	})
 	if err != nil {
 		plugin.Logger(ctx).Error("error listing users with options", "options", utils.ToPrettyJSON(opts), "error", err)
 		return nil, err
 	}
-	allUsers, err := users.ExtractUsers(allPages)
-	if err != nil {
-		plugin.Logger(ctx).Error("error extracting users", "error", err)
-		return nil, err
-	}
-	plugin.Logger(ctx).Debug("users retrieved", "count", len(allUsers))
-
-	for _, user := range allUsers {
-		if ctx.Err() != nil {
-			plugin.Logger(ctx).Debug("context done, exit")
-			break
-		}
-		user := user
-		d.StreamListItem(ctx, &user)
-	}
 	return nil, nil
 }
 
@@ -173,7 +184,8 @@ func buildOpenStackUserFilter(ctx context.Context, quals plugin.KeyColumnEqualsQ
 		opts.Enabled = utils.PointerTo(value.GetBoolValue())
 	}
 	if value, ok := quals["password_expires_at"]; ok {
-		opts.PasswordExpiresAt = value.GetStringValue()
+		// Keystone expects an operator and a timestamp, e.g. eq:2016-12-08T22:02:00Z
+		opts.PasswordExpiresAt = "eq:" + value.GetTimestampValue().AsTime().UTC().Format(time.RFC3339)
 	}
 	plugin.Logger(ctx).Debug("returning", "filter", utils.ToPrettyJSON(opts))
 	return opts
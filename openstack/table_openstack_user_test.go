@@ -0,0 +1,82 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestListOpenStackUser(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackUser(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "admin", "alice")
+	assertRow(t, rows[0], testRow{
+		"id":                  "5d3c1a2b0e5f4e8b9c6a7d8e9f0a1b2c",
+		"domain_id":           "default",
+		"domain_name":         "Default",
+		"enabled":             true,
+		"password_expires_at": nil,
+	})
+	assertRow(t, rows[1], testRow{
+		"description":         "Database administrator",
+		"default_project_id":  "a3b4c5d6e7f8091a2b3c4d5e6f708192",
+		"enabled":             false,
+		"password_expires_at": "2023-06-30T12:00:00Z",
+	})
+}
+
+func TestListOpenStackUserFilters(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	_, err := cloud.list(tableOpenStackUser(context.Background()), testQuery{
+		quals: map[string]any{
+			"id":        "0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e",
+			"name":      "alice",
+			"domain_id": "default",
+			"enabled":   false,
+			// timestamps are sent in UTC
+			"password_expires_at": time.Date(2023, 6, 30, 14, 0, 0, 0, time.FixedZone("CEST", 2*60*60)),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloud.assertQuery("/identity/v3/users", url.Values{
+		"unique_id":           {"0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e"},
+		"name":                {"alice"},
+		"domain_id":           {"default"},
+		"enabled":             {"false"},
+		"password_expires_at": {"eq:2023-06-30T12:00:00Z"},
+	})
+}
+
+func TestListOpenStackUserError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /identity/v3/users", http.StatusForbidden)
+
+	if _, err := cloud.list(tableOpenStackUser(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGetOpenStackUser(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	table := tableOpenStackUser(context.Background())
+
+	row, err := cloud.get(table, testQuery{quals: map[string]any{"id": "0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, row, testRow{"name": "alice", "enabled": false})
+
+	row, err = cloud.get(table, testQuery{quals: map[string]any{"id": "00000000000000000000000000000000"}})
+	if err != nil || row != nil {
+		t.Errorf("expected no row and no error, got %v (error: %v)", row, err)
+	}
+}
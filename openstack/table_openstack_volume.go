@@ -6,7 +6,9 @@ import (
 	"strings"
 
 	"github.com/dihedron/steampipe-plugin-utils/utils"
+	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/blockstorage/v3/volumes"
+	"github.com/gophercloud/gophercloud/pagination"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -16,8 +18,9 @@ import (
 
 func tableOpenStackVolume(_ context.Context) *plugin.Table {
 	return &plugin.Table{
-		Name:        "openstack_volume",
-		Description: "OpenStack Disk Volume",
+		Name:              "openstack_volume",
+		Description:       "OpenStack Disk Volume",
+		GetMatrixItemFunc: regionMatrix,
 		Columns: []*plugin.Column{
 			{
 				Name:        "id",
@@ -43,12 +46,26 @@ func tableOpenStackVolume(_ context.Context) *plugin.Table {
 				Description: "The id of the project the volume belongs to.",
 				Transform:   transform.FromField("OsVolTenantAttrTenantID"),
 			},
+			{
+				Name:        "project_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the project the volume belongs to.",
+				Hydrate:     getProjectName,
+				Transform:   transform.FromValue(),
+			},
 			{
 				Name:        "user_id",
 				Type:        proto.ColumnType_STRING,
 				Description: "The id of the user who created the volume.",
 				Transform:   transform.FromField("UserID"),
 			},
+			{
+				Name:        "user_name",
+				Type:        proto.ColumnType_STRING,
+				Description: "The name of the user that created the volume.",
+				Hydrate:     getUserName,
+				Transform:   transform.FromValue(),
+			},
 			{
 				Name:        "status",
 				Type:        proto.ColumnType_STRING,
@@ -129,13 +146,13 @@ func tableOpenStackVolume(_ context.Context) *plugin.Table {
 			},
 			{
 				Name:        "created_at",
-				Type:        proto.ColumnType_STRING,
+				Type:        proto.ColumnType_TIMESTAMP,
 				Description: "Timestamp when the volume was created.",
 				Transform:   transform.FromField("CreatedAt").Transform(ToTime),
 			},
 			{
 				Name:        "updated_at",
-				Type:        proto.ColumnType_STRING,
+				Type:        proto.ColumnType_TIMESTAMP,
 				Description: "The date when this volume was last updated.",
 				Transform:   transform.FromField("UpdatedAt").Transform(ToTime),
 			},
@@ -282,10 +299,10 @@ func tableOpenStackVolume(_ context.Context) *plugin.Table {
 				Transform: transform.FromField("VolumeImageMetadata").Transform(transform.NullIfZeroValue).Transform(func(ctx context.Context, d *transform.TransformData) (any, error) {
 					if d.Value != nil {
 						if value, ok := d.Value.(map[string]string); ok {
-							if value["image_min_disk"] == "" {
+							if value["min_disk"] == "" {
 								return 0, nil
 							}
-							return strconv.Atoi(value["image_min_disk"])
+							return strconv.Atoi(value["min_disk"])
 						}
 					}
 					return nil, nil
@@ -332,6 +349,12 @@ func tableOpenStackVolume(_ context.Context) *plugin.Table {
 				Description: "The volume image metadata.",
 				Transform:   transform.FromField("VolumeImageMetadata"),
 			},
+			{
+				Name:        "region",
+				Type:        proto.ColumnType_STRING,
+				Description: "The region the disk volume belongs to.",
+				Transform:   transform.FromMatrixItem(RegionKey),
+			},
 		},
 		List: &plugin.ListConfig{
 			Hydrate: listOpenStackVolume,
@@ -352,11 +375,21 @@ func tableOpenStackVolume(_ context.Context) *plugin.Table {
 					Name:    "project_id",
 					Require: plugin.Optional,
 				},
+				&plugin.KeyColumn{
+					Name:      "created_at",
+					Operators: timeRangeOperators,
+					Require:   plugin.Optional,
+				},
+				&plugin.KeyColumn{
+					Name:      "updated_at",
+					Operators: timeRangeOperators,
+					Require:   plugin.Optional,
+				},
 			},
 		},
 		Get: &plugin.GetConfig{
 			KeyColumns: plugin.SingleColumn("id"),
-			Hydrate:    getOpenStackVolume,
+			Hydrate:    getInScope(getOpenStackVolume),
 		},
 	}
 }
@@ -375,29 +408,62 @@ func listOpenStackVolume(ctx context.Context, d *plugin.QueryData, h *plugin.Hyd
 		return nil, err
 	}
 
-	opts := buildOpenStackVolumeFilter(ctx, d.EqualsQuals)
-
-	allPages, err := volumes.List(client, opts).AllPages()
+	scope, err := getProjectScope(ctx, d)
 	if err != nil {
-		plugin.Logger(ctx).Error("error listing volumes with options", "options", utils.ToPrettyJSON(opts), "error", err)
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
 		return nil, err
 	}
-	allVolumes := []*apiVolume{}
-	err = volumes.ExtractVolumesInto(allPages, &allVolumes)
-	if err != nil {
-		plugin.Logger(ctx).Error("error extracting volumes", "error", err)
-		return nil, err
+
+	opts := listVolumesOpts{ListOpts: buildOpenStackVolumeFilter(ctx, d.EqualsQuals)}
+	opts.AllTenants = scope.AllTenants
+	opts.Limit = getPageSize(d)
+
+	// time comparison filters require microversion 3.60, which is requested
+	// for the listing if the configured one is older and the server supports it
+	createdAt, updatedAt := getTimeRange(d, "created_at"), getTimeRange(d, "updated_at")
+	timeFilters := false
+	if !createdAt.IsEmpty() || !updatedAt.IsEmpty() {
+		if _, ok := withMicroversion(ctx, d, BlockStorageV3, client, "3.60"); ok {
+			opts.CreatedAt = createdAt.Filter()
+			opts.UpdatedAt = updatedAt.Filter()
+			timeFilters = true
+		}
 	}
-	plugin.Logger(ctx).Debug("volumes retrieved", "count", len(allVolumes))
 
-	for _, volume := range allVolumes {
-		plugin.Logger(ctx).Error("Individual Volume", "--->", utils.ToPrettyJSON(volume))
-		if ctx.Err() != nil {
-			plugin.Logger(ctx).Debug("context done, exit")
-			break
+	err = forEachProject(ctx, d, scope, BlockStorageV3, func(client *gophercloud.ServiceClient, projectID string) error {
+		opts := opts
+		opts.TenantID = projectID
+		if timeFilters {
+			client, _ = withMicroversion(ctx, d, BlockStorageV3, client, "3.60")
 		}
-		volume := volume
-		d.StreamListItem(ctx, volume)
+
+		err := volumes.List(client, opts).EachPage(func(page pagination.Page) (bool, error) {
+			allVolumes := []*apiVolume{}
+			if err := volumes.ExtractVolumesInto(page, &allVolumes); err != nil {
+				plugin.Logger(ctx).Error("error extracting volumes", "error", err)
+				return false, err
+			}
+			plugin.Logger(ctx).Debug("volumes retrieved", "count", len(allVolumes))
+
+			for _, volume := range allVolumes {
+				plugin.Logger(ctx).Error("Individual Volume", "--->", utils.ToPrettyJSON(volume))
+				volume := volume
+				d.StreamListItem(ctx, volume)
+				if rowsRemaining(ctx, d) == 0 {
+					plugin.Logger(ctx).Debug("no more rows required or context done, exit")
+					return false, nil
+				}
+			}
+			return true, nil
+		})
+		if err != nil {
+			plugin.Logger(ctx).Error("error listing volumes with options", "options", utils.ToPrettyJSON(opts), "error", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return nil, nil
 }
@@ -417,6 +483,12 @@ func getOpenStackVolume(ctx context.Context, d *plugin.QueryData, h *plugin.Hydr
 		return nil, err
 	}
 
+	scope, err := getProjectScope(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("error retrieving project scope", "error", err)
+		return nil, err
+	}
+
 	result := volumes.Get(client, id)
 	//plugin.Logger(ctx).Debug("request run", "result", utils.ToPrettyJSON(result))
 
@@ -427,12 +499,15 @@ func getOpenStackVolume(ctx context.Context, d *plugin.QueryData, h *plugin.Hydr
 		return nil, err
 	}
 
+	if !scope.Includes(volume.OsVolTenantAttrTenantID) {
+		plugin.Logger(ctx).Debug("volume not in project scope", "id", id, "project", volume.OsVolTenantAttrTenantID)
+		return nil, nil
+	}
+
 	return volume, nil
 }
 func buildOpenStackVolumeFilter(ctx context.Context, quals plugin.KeyColumnEqualsQualMap) volumes.ListOpts {
-	opts := volumes.ListOpts{
-		AllTenants: true,
-	}
+	opts := volumes.ListOpts{}
 	if value, ok := quals["name"]; ok {
 		opts.Name = value.GetStringValue()
 	}
@@ -447,6 +522,27 @@ func buildOpenStackVolumeFilter(ctx context.Context, quals plugin.KeyColumnEqual
 	return opts
 }
 
+// listVolumesOpts adds to volumes.ListOpts the filters that gophercloud does
+// not support.
+type listVolumesOpts struct {
+	volumes.ListOpts
+	// CreatedAt and UpdatedAt are time comparison filters in the form
+	// "<operator>:<time>", e.g. "gte:2022-10-07T18:56:03Z".
+	CreatedAt string
+	UpdatedAt string
+}
+
+func (opts listVolumesOpts) ToVolumeListQuery() (string, error) {
+	query, err := opts.ListOpts.ToVolumeListQuery()
+	if err != nil {
+		return "", err
+	}
+	return addQueryParams(query, map[string]string{
+		"created_at": opts.CreatedAt,
+		"updated_at": opts.UpdatedAt,
+	})
+}
+
 type apiVolume struct {
 	// Unique identifier for the volume.
 	ID string `json:"id"`
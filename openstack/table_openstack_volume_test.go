@@ -0,0 +1,139 @@
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dihedron/steampipe-plugin-utils/utils"
+)
+
+func TestListOpenStackVolume(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	rows, err := cloud.list(tableOpenStackVolume(context.Background()), testQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, "name", "web-01-root", "scratch")
+	assertRow(t, rows[0], testRow{
+		"id":                "521752a6-acf6-4b2d-bc7a-119f9148cd8c",
+		"description":       "root disk",
+		"project_id":        TestProjectID,
+		"status":            "in-use",
+		"size":              int64(20),
+		"bootable":          true,
+		"encrypted":         false,
+		"volume_type":       "ceph",
+		"created_at":        "2022-09-24T13:53:25Z",
+		"image_id":          "70a599e0-31e7-49b7-b260-868f441e862b",
+		"image_name":        "ubuntu-22.04",
+		"image_disk_format": "qcow2",
+		"image_min_disk":    int64(10),
+		"image_min_ram":     int64(512),
+		"image_os_distro":   "ubuntu",
+		"metadata":          `{"readonly":"False"}`,
+		"backup_id":         nil,
+		"region":            "RegionOne",
+	})
+	// volumes not created from images have no image metadata
+	assertRow(t, rows[1], testRow{
+		"encrypted":      true,
+		"multiattach":    true,
+		"updated_at":     nil,
+		"image_id":       nil,
+		"image_min_disk": nil,
+	})
+	// volumes in all projects are listed
+	cloud.assertQuery("/volumev3/v3/volumes/detail", url.Values{"all_tenants": {"true"}})
+}
+
+func TestListOpenStackVolumeFilters(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+
+	_, err := cloud.list(tableOpenStackVolume(context.Background()), testQuery{
+		quals: map[string]any{
+			"name":       "scratch",
+			"status":     "available",
+			"project_id": TestProjectID,
+		},
+		limit: 20,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloud.assertQuery("/volumev3/v3/volumes/detail", url.Values{
+		"name":       {"scratch"},
+		"status":     {"available"},
+		"project_id": {TestProjectID},
+		"limit":      {"20"},
+	})
+}
+
+func TestListOpenStackVolumeError(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	cloud.fail("GET /volumev3/v3/volumes/detail", http.StatusInternalServerError)
+
+	if _, err := cloud.list(tableOpenStackVolume(context.Background()), testQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGetOpenStackVolume(t *testing.T) {
+	cloud := newFakeOpenStack(t)
+	table := tableOpenStackVolume(context.Background())
+
+	row, err := cloud.get(table, testQuery{quals: map[string]any{"id": "521752a6-acf6-4b2d-bc7a-119f9148cd8c"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRow(t, row, testRow{"name": "web-01-root", "image_name": "ubuntu-22.04"})
+
+	row, err = cloud.get(table, testQuery{quals: map[string]any{"id": "00000000-0000-0000-0000-000000000000"}})
+	if err != nil || row != nil {
+		t.Errorf("expected no row and no error, got %v (error: %v)", row, err)
+	}
+}
+
+func TestListOpenStackVolumeTimeRanges(t *testing.T) {
+	query := testQuery{
+		quals: map[string]any{
+			"created_at >=": time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC),
+			"updated_at <":  time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	// time comparison filters require microversion 3.60
+	cloud := newFakeOpenStack(t)
+	if _, err := cloud.list(tableOpenStackVolume(context.Background()), query); err != nil {
+		t.Fatal(err)
+	}
+	cloud.assertQuery("/volumev3/v3/volumes/detail", url.Values{"created_at": nil, "updated_at": nil})
+
+	// which is requested for the listing if the server supports it
+	cloud = newFakeOpenStack(t)
+	serveVersions(cloud, "/volumev3/", "volumev3")
+	if _, err := cloud.list(tableOpenStackVolume(context.Background()), query); err != nil {
+		t.Fatal(err)
+	}
+	cloud.assertQuery("/volumev3/v3/volumes/detail", url.Values{
+		"created_at": {"gte:2022-09-01T00:00:00Z"},
+		"updated_at": {"lt:2022-10-01T00:00:00Z"},
+	})
+	requests := cloud.received("/volumev3/v3/volumes/detail")
+	if version := requests[len(requests)-1].Header.Get("OpenStack-API-Version"); version != "volumev3 3.60" {
+		t.Errorf("expected microversion 3.60, got %q", version)
+	}
+
+	cloud = newFakeOpenStack(t)
+	cloud.config.BlockStorageV3Microversion = utils.PointerTo("3.60")
+	if _, err := cloud.list(tableOpenStackVolume(context.Background()), query); err != nil {
+		t.Fatal(err)
+	}
+	cloud.assertQuery("/volumev3/v3/volumes/detail", url.Values{
+		"created_at": {"gte:2022-09-01T00:00:00Z"},
+		"updated_at": {"lt:2022-10-01T00:00:00Z"},
+	})
+}
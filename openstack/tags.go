@@ -0,0 +1,130 @@
+package openstack
+
+import (
+	"strings"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// tagFilterColumns are the key columns used to filter resources by tag on the
+// APIs that support it (Nova, Neutron and Octavia); each one takes a comma
+// separated list of tags and is passed down as the corresponding query
+// parameter, e.g.:
+//
+//	select name from openstack_network where tags_all = 'team-x,production'
+//
+// The columns only echo the filter back, since the tags of each resource are
+// in its tags column.
+var tagFilterColumns = []struct {
+	Name        string
+	Parameter   string
+	Description string
+}{
+	{
+		Name:        "tags_all",
+		Parameter:   "tags",
+		Description: "Filter selecting the resources having all the given comma-separated tags.",
+	},
+	{
+		Name:        "tags_any",
+		Parameter:   "tags-any",
+		Description: "Filter selecting the resources having at least one of the given comma-separated tags.",
+	},
+	{
+		Name:        "not_tags",
+		Parameter:   "not-tags",
+		Description: "Filter selecting the resources not having all the given comma-separated tags.",
+	},
+	{
+		Name:        "not_tags_any",
+		Parameter:   "not-tags-any",
+		Description: "Filter selecting the resources having none of the given comma-separated tags.",
+	},
+}
+
+// tagColumns returns the columns holding the tag filters, to be added to the
+// tables of taggable resources.
+func tagColumns() []*plugin.Column {
+	columns := []*plugin.Column{}
+	for _, filter := range tagFilterColumns {
+		columns = append(columns, &plugin.Column{
+			Name:        filter.Name,
+			Type:        proto.ColumnType_STRING,
+			Description: filter.Description,
+			Transform:   transform.FromQual(filter.Name),
+		})
+	}
+	return columns
+}
+
+// tagKeyColumns returns the optional key columns for the tag filters.
+func tagKeyColumns() plugin.KeyColumnSlice {
+	columns := plugin.KeyColumnSlice{}
+	for _, filter := range tagFilterColumns {
+		columns = append(columns, &plugin.KeyColumn{
+			Name:    filter.Name,
+			Require: plugin.Optional,
+		})
+	}
+	return columns
+}
+
+// tagFilter holds the tag filters of a query, as comma-separated lists of
+// tags in the format expected by the APIs.
+type tagFilter struct {
+	Tags       string
+	TagsAny    string
+	NotTags    string
+	NotTagsAny string
+}
+
+// getTagFilter returns the tag filters in the quals.
+func getTagFilter(quals plugin.KeyColumnEqualsQualMap) tagFilter {
+	filter := tagFilter{}
+	for _, column := range tagFilterColumns {
+		value, ok := quals[column.Name]
+		if !ok {
+			continue
+		}
+		tags := joinTags(value.GetStringValue())
+		switch column.Parameter {
+		case "tags":
+			filter.Tags = tags
+		case "tags-any":
+			filter.TagsAny = tags
+		case "not-tags":
+			filter.NotTags = tags
+		case "not-tags-any":
+			filter.NotTagsAny = tags
+		}
+	}
+	return filter
+}
+
+// IsEmpty returns whether the query has no tag filters.
+func (f tagFilter) IsEmpty() bool {
+	return f == tagFilter{}
+}
+
+// joinTags normalises a comma-separated list of tags, removing blanks and
+// empty entries.
+func joinTags(value string) string {
+	tags := []string{}
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return strings.Join(tags, ",")
+}
+
+// toTagList turns a comma-separated list of tags into the single-element list
+// used by the Octavia filters, or nil if there are no tags.
+func toTagList(tags string) []string {
+	if tags == "" {
+		return nil
+	}
+	return []string{tags}
+}
@@ -7,14 +7,32 @@ import (
 	"time"
 
 	"github.com/hashicorp/go-multierror"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
 )
 
 type Time time.Time
 
+// layouts are the formats of the timestamps emitted by the OpenStack APIs;
+// when parsing, Go accepts fractional seconds after the seconds field even if
+// the layout does not mention them, and timestamps without a time zone are
+// in UTC, e.g.:
+//   - Nova: 2022-10-07T18:56:03Z, 2022-10-07T18:56:02.000000 (launched_at,
+//     aggregates, instance actions) and 2022-10-07T18:56:02+00:00 (newer
+//     microversions)
+//   - Neutron: 2022-10-07T18:56:03Z, 2022-10-07 18:56:03 (agent heartbeats)
+//   - Cinder: 2022-10-07T18:56:02.000000 and 2022-10-07T18:56:02.123456+00:00
+//   - Glance: 2022-10-07T18:56:03Z
+//   - Keystone: 2022-10-07T18:56:02.000000 and 2022-10-07T18:56:02.893769Z
+//   - Octavia: 2022-10-07T18:56:02
 var layouts = []string{
+	time.RFC3339Nano,
 	"2006-01-02T15:04:05Z0700",
-	"2006-01-02T15:04:05.000000",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05Z0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
 }
 
 func (t *Time) Format(format string) string {
@@ -24,14 +42,6 @@ func (t *Time) Format(format string) string {
 	return ""
 }
 
-//const layout2 = "2006-01-02T15:04:05Z"
-// CCYY-MM-DDThh:mm:ss±hh:mm
-
-// updated: 2022-10-07T18:56:03Z
-// create: 2022-10-07T18:55:42Z
-// launched at: 2022-10-07T18:56:02.000000
-// terminated at: 2022-10-07T18:56:02.000000
-
 func (t *Time) UnmarshalJSON(b []byte) error {
 	s := strings.Trim(string(b), "\"")
 	if s == "null" || s == "" {
@@ -40,7 +50,6 @@ func (t *Time) UnmarshalJSON(b []byte) error {
 	}
 	var errors error
 	for _, layout := range layouts {
-		//var t0 time.Time
 		if t0, err := time.Parse(layout, s); err != nil {
 			errors = multierror.Append(errors, err)
 			continue
@@ -70,6 +79,8 @@ func (t *Time) IsZero() bool {
 	return time.Time(*t).IsZero()
 }
 
+// ToTime turns Time and time.Time values into values for TIMESTAMP columns,
+// with zero values becoming nulls.
 func ToTime(ctx context.Context, d *transform.TransformData) (any, error) {
 	var err error
 	if d.Value == nil {
@@ -80,19 +91,119 @@ func ToTime(ctx context.Context, d *transform.TransformData) (any, error) {
 		if t == nil || t.IsZero() {
 			return nil, nil
 		}
-		return t.String(), nil
+		return time.Time(*t), nil
 	case Time:
 		if t.IsZero() {
 			return nil, nil
 		}
-		return t.String(), nil
+		return time.Time(t), nil
+	case *time.Time:
+		if t == nil || t.IsZero() {
+			return nil, nil
+		}
+		return *t, nil
 	case time.Time:
 		if t.IsZero() {
 			return nil, nil
 		}
-		return t.String(), nil
+		return t, nil
 	default:
 		err = fmt.Errorf("invalid type: %T", d.Value)
 	}
 	return nil, err
 }
+
+// timeRangeOperators are the operators on TIMESTAMP key columns that can be
+// pushed down to the APIs as time range filters.
+var timeRangeOperators = []string{">", ">=", "<", "<="}
+
+// timeRange is the range of times selected by the quals on a TIMESTAMP key
+// column; either end may be open (nil).
+type timeRange struct {
+	From          *time.Time
+	FromInclusive bool
+	To            *time.Time
+	ToInclusive   bool
+}
+
+// getTimeRange returns the tightest range satisfying all the >, >=, < and <=
+// quals on the given column.
+func getTimeRange(d *plugin.QueryData, column string) timeRange {
+	r := timeRange{}
+	if d.Quals[column] == nil {
+		return r
+	}
+	for _, qual := range d.Quals[column].Quals {
+		value := qual.Value.GetTimestampValue()
+		if value == nil {
+			continue
+		}
+		t := value.AsTime().UTC()
+		switch qual.Operator {
+		case ">", ">=":
+			inclusive := qual.Operator == ">="
+			if r.From == nil || t.After(*r.From) || (t.Equal(*r.From) && !inclusive) {
+				r.From, r.FromInclusive = &t, inclusive
+			}
+		case "<", "<=":
+			inclusive := qual.Operator == "<="
+			if r.To == nil || t.Before(*r.To) || (t.Equal(*r.To) && !inclusive) {
+				r.To, r.ToInclusive = &t, inclusive
+			}
+		}
+	}
+	return r
+}
+
+// IsEmpty returns whether the range is unbounded on both ends.
+func (r timeRange) IsEmpty() bool {
+	return r.From == nil && r.To == nil
+}
+
+// Since returns the lower end of the range for filters such as Nova's
+// changes-since, which are inclusive and have a resolution of one second.
+func (r timeRange) Since() string {
+	if r.From == nil {
+		return ""
+	}
+	return r.From.Truncate(time.Second).Format(time.RFC3339)
+}
+
+// Before returns the upper end of the range for filters such as Nova's
+// changes-before, which are inclusive and have a resolution of one second.
+func (r timeRange) Before() string {
+	if r.To == nil {
+		return ""
+	}
+	return ceilSecond(*r.To).Format(time.RFC3339)
+}
+
+// Filter returns the range as an "<operator>:<time>" filter, as accepted by
+// Glance and Cinder, e.g. "gte:2022-10-07T18:56:03Z"; since these APIs only
+// accept one filter per attribute, the lower end (the most useful for
+// incremental queries) is used if the range has both. Times are rounded to
+// the second so that the filter selects a superset of the range.
+func (r timeRange) Filter() string {
+	switch {
+	case r.From != nil:
+		operator := "gte"
+		if r.From.Equal(r.From.Truncate(time.Second)) && !r.FromInclusive {
+			operator = "gt"
+		}
+		return operator + ":" + r.Since()
+	case r.To != nil:
+		operator := "lte"
+		if r.To.Equal(ceilSecond(*r.To)) && !r.ToInclusive {
+			operator = "lt"
+		}
+		return operator + ":" + r.Before()
+	}
+	return ""
+}
+
+func ceilSecond(t time.Time) time.Time {
+	if truncated := t.Truncate(time.Second); !truncated.Equal(t) {
+		return truncated.Add(time.Second)
+	}
+	return t
+}
@@ -1,8 +1,14 @@
 package openstack
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
+	"time"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/quals"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
 )
 
 func TestOpenStackTime(t *testing.T) {
@@ -11,17 +17,171 @@ func TestOpenStackTime(t *testing.T) {
 		Time Time
 	}
 
-	var tests = []string{
-		`{"Time": "2022-09-24T13:53:23Z"}`,
-		`{"Time": "2022-10-11T14:17:48.000000"}`,
+	var tests = []struct {
+		service  string
+		value    string
+		expected time.Time
+	}{
+		{service: "nova", value: `"2022-09-24T13:53:23Z"`, expected: time.Date(2022, 9, 24, 13, 53, 23, 0, time.UTC)},
+		{service: "nova (launched_at)", value: `"2022-10-11T14:17:48.000000"`, expected: time.Date(2022, 10, 11, 14, 17, 48, 0, time.UTC)},
+		{service: "nova (offset)", value: `"2022-10-11T14:17:48+00:00"`, expected: time.Date(2022, 10, 11, 14, 17, 48, 0, time.UTC)},
+		{service: "nova (usage)", value: `"2012-10-08T21:10:44.587336"`, expected: time.Date(2012, 10, 8, 21, 10, 44, 587336000, time.UTC)},
+		{service: "neutron", value: `"2022-09-20T08:59:00Z"`, expected: time.Date(2022, 9, 20, 8, 59, 0, 0, time.UTC)},
+		{service: "neutron (agents)", value: `"2017-09-12 19:39:56"`, expected: time.Date(2017, 9, 12, 19, 39, 56, 0, time.UTC)},
+		{service: "cinder", value: `"2022-09-24T13:53:25.000000"`, expected: time.Date(2022, 9, 24, 13, 53, 25, 0, time.UTC)},
+		{service: "cinder (offset)", value: `"2022-09-24T13:53:25.123456+02:00"`, expected: time.Date(2022, 9, 24, 11, 53, 25, 123456000, time.UTC)},
+		{service: "glance", value: `"2022-09-01T12:00:00Z"`, expected: time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)},
+		{service: "keystone", value: `"2016-11-06T15:32:17.000000"`, expected: time.Date(2016, 11, 6, 15, 32, 17, 0, time.UTC)},
+		{service: "keystone (token)", value: `"2015-11-06T15:32:17.893769Z"`, expected: time.Date(2015, 11, 6, 15, 32, 17, 893769000, time.UTC)},
+		{service: "octavia", value: `"2022-09-25T10:00:00"`, expected: time.Date(2022, 9, 25, 10, 0, 0, 0, time.UTC)},
+		{service: "offset without colon", value: `"2022-09-25T10:00:00-0500"`, expected: time.Date(2022, 9, 25, 15, 0, 0, 0, time.UTC)},
+		{service: "null", value: `null`},
+		{service: "empty", value: `""`},
 	}
 
 	for _, test := range tests {
 		a := Test{}
-		err := json.Unmarshal([]byte(test), &a)
+		err := json.Unmarshal([]byte(`{"Time": `+test.value+`}`), &a)
 		if err != nil {
-			t.Fatal(err)
+			t.Errorf("%s: unexpected error: %v", test.service, err)
+			continue
+		}
+		if !time.Time(a.Time).Equal(test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.service, test.expected, a.Time.String())
+		}
+	}
+
+	a := Test{}
+	if err := json.Unmarshal([]byte(`{"Time": "yesterday"}`), &a); err == nil {
+		t.Errorf("expected error parsing invalid time")
+	}
+}
+
+func TestOpenStackTimeRoundTrip(t *testing.T) {
+	original := Time(time.Date(2022, 9, 24, 13, 53, 25, 123456000, time.UTC))
+	data, err := json.Marshal(&original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `"2022-09-24T13:53:25.123456Z"` {
+		t.Errorf("unexpected encoding: %s", data)
+	}
+	var parsed Time
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if !time.Time(parsed).Equal(time.Time(original)) {
+		t.Errorf("expected %v, got %v", original.String(), parsed.String())
+	}
+}
+
+func TestToTime(t *testing.T) {
+	value := time.Date(2022, 9, 24, 13, 53, 25, 0, time.UTC)
+	var tests = []struct {
+		name     string
+		value    any
+		expected any
+	}{
+		{name: "Time", value: Time(value), expected: value},
+		{name: "*Time", value: (*Time)(&value), expected: value},
+		{name: "time.Time", value: value, expected: value},
+		{name: "*time.Time", value: &value, expected: value},
+		{name: "zero Time", value: Time{}, expected: nil},
+		{name: "nil *Time", value: (*Time)(nil), expected: nil},
+		{name: "zero time.Time", value: time.Time{}, expected: nil},
+		{name: "nil", value: nil, expected: nil},
+	}
+
+	for _, test := range tests {
+		actual, err := ToTime(context.Background(), &transform.TransformData{Value: test.value})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if actual != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, actual)
+		}
+	}
+
+	if _, err := ToTime(context.Background(), &transform.TransformData{Value: "2022-09-24"}); err == nil {
+		t.Errorf("expected error with invalid type")
+	}
+}
+
+func TestTimeRange(t *testing.T) {
+	at := func(value string) time.Time {
+		t0, _ := time.Parse(time.RFC3339Nano, value)
+		return t0
+	}
+	var tests = []struct {
+		name   string
+		quals  map[string]any
+		since  string
+		before string
+		filter string
+	}{
+		{
+			name: "no quals",
+		},
+		{
+			name:   "inclusive lower end",
+			quals:  map[string]any{"updated_at >=": at("2022-10-07T18:56:03Z")},
+			since:  "2022-10-07T18:56:03Z",
+			filter: "gte:2022-10-07T18:56:03Z",
+		},
+		{
+			name:   "exclusive lower end",
+			quals:  map[string]any{"updated_at >": at("2022-10-07T18:56:03Z")},
+			since:  "2022-10-07T18:56:03Z",
+			filter: "gt:2022-10-07T18:56:03Z",
+		},
+		{
+			name:   "fractional lower end",
+			quals:  map[string]any{"updated_at >": at("2022-10-07T18:56:03.5Z")},
+			since:  "2022-10-07T18:56:03Z",
+			filter: "gte:2022-10-07T18:56:03Z",
+		},
+		{
+			name:   "exclusive upper end",
+			quals:  map[string]any{"updated_at <": at("2022-10-07T18:56:03Z")},
+			before: "2022-10-07T18:56:03Z",
+			filter: "lt:2022-10-07T18:56:03Z",
+		},
+		{
+			name:   "fractional upper end",
+			quals:  map[string]any{"updated_at <": at("2022-10-07T18:56:03.5+02:00")},
+			before: "2022-10-07T16:56:04Z",
+			filter: "lte:2022-10-07T16:56:04Z",
+		},
+		{
+			name: "tightest of both ends",
+			quals: map[string]any{
+				"updated_at >=": at("2022-10-01T00:00:00Z"),
+				"updated_at >":  at("2022-10-02T00:00:00Z"),
+				"updated_at <=": at("2022-10-09T00:00:00Z"),
+				"updated_at <":  at("2022-10-08T00:00:00Z"),
+			},
+			since:  "2022-10-02T00:00:00Z",
+			before: "2022-10-08T00:00:00Z",
+			filter: "gt:2022-10-02T00:00:00Z",
+		},
+	}
+
+	for _, test := range tests {
+		d := &plugin.QueryData{Quals: plugin.KeyColumnQualMap{}}
+		for key, value := range test.quals {
+			name, operator := splitQual(key)
+			if d.Quals[name] == nil {
+				d.Quals[name] = &plugin.KeyColumnQuals{Name: name}
+			}
+			d.Quals[name].Quals = append(d.Quals[name].Quals, &quals.Qual{Column: name, Operator: operator, Value: toQualValue(t, value)})
+		}
+		r := getTimeRange(d, "updated_at")
+		if r.Since() != test.since || r.Before() != test.before || r.Filter() != test.filter {
+			t.Errorf("%s: expected %q, %q and %q, got %q, %q and %q", test.name, test.since, test.before, test.filter, r.Since(), r.Before(), r.Filter())
+		}
+		if r.IsEmpty() != (len(test.quals) == 0) {
+			t.Errorf("%s: unexpected empty range: %v", test.name, r.IsEmpty())
 		}
-		t.Logf("after unmarshalling: %q", a.Time.String())
 	}
 }
@@ -3,6 +3,7 @@ package openstack
 import (
 	"context"
 	"errors"
+	"net/url"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
@@ -19,3 +20,55 @@ func setLogLevel(ctx context.Context, d *plugin.QueryData) {
 		plugin.Logger(ctx).SetLevel(hclog.LevelFromString(level))
 	}
 }
+
+// MaxPageSize is the largest page size requested when pushing down the SQL
+// LIMIT clause; larger limits are left to the APIs' own defaults.
+const MaxPageSize = 1000
+
+// getPageSize returns the page size to request from paginated APIs: the SQL
+// LIMIT if any (and not larger than MaxPageSize), or 0 to let the API use its
+// default; since pages keep being fetched until enough rows have been streamed,
+// a page size that is too small only costs extra requests.
+func getPageSize(d *plugin.QueryData) int {
+	if d.QueryContext == nil || d.QueryContext.Limit == nil {
+		return 0
+	}
+	if limit := *d.QueryContext.Limit; limit > 0 && limit <= MaxPageSize {
+		return int(limit)
+	}
+	return 0
+}
+
+// rowsRemaining returns how many more rows the query needs, so that list
+// functions can stop paging once the SQL LIMIT is reached.
+func rowsRemaining(ctx context.Context, d *plugin.QueryData) int64 {
+	return d.RowsRemaining(ctx)
+}
+
+// addQueryParams adds the given parameters, unless empty, to a query string
+// such as those built by gophercloud's ListOpts; it is used to support filters
+// that gophercloud does not know about.
+func addQueryParams(query string, params map[string]string) (string, error) {
+	u, err := url.Parse(query)
+	if err != nil {
+		return "", err
+	}
+	values := u.Query()
+	for key, value := range params {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	u.RawQuery = values.Encode()
+	return u.String(), nil
+}
+
+// contains returns whether the slice contains the given value.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}